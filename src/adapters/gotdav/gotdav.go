@@ -0,0 +1,546 @@
+// Package gotdav serves a mark over WebDAV.
+package gotdav
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"go.brendoncarroll.net/exp/streams"
+	"go.brendoncarroll.net/stdctx/logctx"
+	"go.uber.org/zap"
+	"golang.org/x/net/webdav"
+
+	"github.com/gotvc/got/src/adapters/gotiofs"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/internal/stores"
+)
+
+// CommitDelay is how long changes are collected for, before they are committed to the mark together.
+const CommitDelay = time.Second
+
+var _ webdav.FileSystem = &FS{}
+
+// FS implements webdav.FileSystem on top of a mark.
+// Changes (creating a directory, writing a file, moving or deleting a path) are collected into a batch,
+// which holds a transaction on the mark open, and are committed to the mark together as a new Commit after CommitDelay.
+// Reads see the changes in the batch, and otherwise the Commit that the mark points to when they are made.
+// If a batch cannot be committed, the changes in it are lost, and all later requests fail with the error.
+type FS struct {
+	repo   *gotrepo.Repo
+	fqm    gotrepo.FQM
+	signer *gotrepo.Signer
+
+	mu    sync.Mutex
+	batch *batch
+}
+
+// New returns an FS for the mark at fqm.
+// Commits are created by, and signed by, signer.
+func New(repo *gotrepo.Repo, fqm gotrepo.FQM, signer *gotrepo.Signer) *FS {
+	return &FS{
+		repo:   repo,
+		fqm:    fqm,
+		signer: signer,
+	}
+}
+
+func (fsys *FS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	p := cleanPath(name)
+	return fsys.modify(ctx, "mkdir "+p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		if _, err := fsmach.GetDirInfo(ctx, ss.Metadata, root, path.Dir(p)); err != nil {
+			return nil, err
+		}
+		return fsmach.Mkdir(ctx, ss.Metadata, root, p)
+	})
+}
+
+func (fsys *FS) RemoveAll(ctx context.Context, name string) error {
+	p := cleanPath(name)
+	if p == "" {
+		return fmt.Errorf("gotdav: cannot remove the root")
+	}
+	return fsys.modify(ctx, "delete "+p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		if _, err := fsmach.GetInfo(ctx, ss.Metadata, root, p); err != nil {
+			return nil, err
+		}
+		return fsmach.RemoveAll(ctx, ss.Metadata, root, p)
+	})
+}
+
+func (fsys *FS) Rename(ctx context.Context, oldName, newName string) error {
+	from, to := cleanPath(oldName), cleanPath(newName)
+	return fsys.modify(ctx, fmt.Sprintf("move %s to %s", from, to), func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		return fsmach.Move(ctx, ss, root, from, to)
+	})
+}
+
+func (fsys *FS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	p := cleanPath(name)
+	var finfo os.FileInfo
+	err := fsys.view(ctx, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+		var err error
+		finfo, err = stat(ctx, fsmach, ss.Metadata, root, p)
+		return err
+	})
+	return finfo, err
+}
+
+// OpenFile opens the file or directory at name.
+// A file opened for reading is read directly from a snapshot of the mark.
+// A file opened for writing is copied to a temporary file, which is written to the mark when it is closed.
+func (fsys *FS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	p := cleanPath(name)
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return fsys.openWrite(ctx, p, flag)
+	}
+	var f *file
+	if err := fsys.view(ctx, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+		info, err := fsmach.GetInfo(ctx, ss.Metadata, root, p)
+		if err != nil {
+			return err
+		}
+		if info.Mode.IsDir() {
+			f = &file{fsys: fsys, ctx: ctx, p: p}
+			return f.loadDir(ctx, fsmach, ss, root)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if f != nil {
+		return f, nil
+	}
+	// the snapshot does not include changes which have not been committed.
+	if err := fsys.Flush(ctx); err != nil {
+		return nil, err
+	}
+	snap, err := fsys.repo.OpenMarkFS(ctx, fsys.fqm)
+	if err != nil {
+		return nil, err
+	}
+	// the file may have been removed after it was looked up.
+	if _, err := snap.FS.GetInfo(ctx, snap.Stores.Metadata, snap.Root, p); err != nil {
+		snap.Close()
+		return nil, err
+	}
+	return &file{
+		fsys: fsys,
+		ctx:  ctx,
+		p:    p,
+		snap: snap,
+		r:    gotiofs.NewFile(ctx, snap.FS, snap.Stores, snap.Root, p),
+	}, nil
+}
+
+func (fsys *FS) openWrite(ctx context.Context, p string, flag int) (*file, error) {
+	tmp, err := os.CreateTemp("", "gotdav-*")
+	if err != nil {
+		return nil, err
+	}
+	f := &file{fsys: fsys, ctx: ctx, p: p, tmp: tmp}
+	if err := fsys.view(ctx, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+		info, err := fsmach.GetInfo(ctx, ss.Metadata, root, p)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if flag&os.O_CREATE == 0 {
+				return os.ErrNotExist
+			}
+			if _, err := fsmach.GetDirInfo(ctx, ss.Metadata, root, path.Dir(p)); err != nil {
+				return err
+			}
+			f.dirty = true
+			return nil
+		case err != nil:
+			return err
+		case flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+			return os.ErrExist
+		case info.Mode.IsDir():
+			return fmt.Errorf("gotdav: %q is a directory", p)
+		case flag&os.O_TRUNC != 0:
+			f.dirty = true
+			return nil
+		default:
+			r, err := fsmach.NewReader(ctx, ss, root, p)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tmp, r)
+			return err
+		}
+	}); err != nil {
+		f.discard()
+		return nil, err
+	}
+	if flag&os.O_APPEND == 0 {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			f.discard()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Flush commits the changes in the current batch, if there is one, without waiting for CommitDelay.
+func (fsys *FS) Flush(ctx context.Context) error {
+	b := fsys.getBatch(ctx, false)
+	if b == nil {
+		return nil
+	}
+	b.commit()
+	select {
+	case <-b.done:
+		return b.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// view calls fn with the filesystem in the current batch, if there is one,
+// and otherwise with the filesystem in the Commit that the mark points to.
+func (fsys *FS) view(ctx context.Context, fn func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error) error {
+	for {
+		b := fsys.getBatch(ctx, false)
+		if b == nil {
+			return fsys.repo.ViewMarkFS(ctx, fsys.fqm, fn)
+		}
+		if ok, err := b.do(ctx, "", func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+			return nil, fn(fsmach, ss.RO(), root)
+		}); ok {
+			return err
+		}
+	}
+}
+
+// modify applies fn to the filesystem in the current batch, starting a new batch if there is none.
+func (fsys *FS) modify(ctx context.Context, msg string, fn func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error)) error {
+	for {
+		b := fsys.getBatch(ctx, true)
+		if ok, err := b.do(ctx, msg, fn); ok {
+			return err
+		}
+	}
+}
+
+// getBatch returns the current batch.
+// If there is none, a new batch is started if create is true, otherwise nil is returned.
+func (fsys *FS) getBatch(ctx context.Context, create bool) *batch {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if fsys.batch == nil && create {
+		fsys.batch = &batch{
+			reqs: make(chan batchReq),
+			stop: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		go fsys.runBatch(context.WithoutCancel(ctx), fsys.batch)
+	}
+	return fsys.batch
+}
+
+// runBatch applies the changes sent to b in a single transaction, until b is committed.
+// If changes were applied to the batch, and then could not be committed, the batch is kept as the current batch,
+// so that every later request fails with the error, instead of working on a filesystem which is missing the lost changes.
+func (fsys *FS) runBatch(ctx context.Context, b *batch) {
+	defer close(b.done)
+	timer := time.AfterFunc(CommitDelay, b.commit)
+	defer timer.Stop()
+
+	errNoChanges := errors.New("no changes")
+	var msgs []string
+	err := fsys.repo.ModifyFS(ctx, fsys.fqm, fsys.signer, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, string, error) {
+		for {
+			select {
+			case req := <-b.reqs:
+				next, err := req.fn(fsmach, ss, root)
+				if err == nil && next != nil {
+					root = *next
+					msgs = append(msgs, req.msg)
+				}
+				req.errc <- err
+			case <-b.stop:
+				if len(msgs) == 0 {
+					return nil, "", errNoChanges
+				}
+				return &root, "dav: " + strings.Join(msgs, ", "), nil
+			}
+		}
+	})
+	if errors.Is(err, errNoChanges) {
+		err = nil
+	}
+	if err != nil {
+		logctx.Error(ctx, "committing WebDAV changes", zap.Error(err))
+	}
+	b.err = err
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if fsys.batch == b && (err == nil || len(msgs) == 0) {
+		fsys.batch = nil
+	}
+}
+
+// batch is a set of changes which are committed to the mark together.
+type batch struct {
+	reqs chan batchReq
+	// stop is closed to commit the batch.
+	stop     chan struct{}
+	stopOnce sync.Once
+	// done is closed after the batch has been committed, and err is set.
+	done chan struct{}
+	err  error
+}
+
+type batchReq struct {
+	msg  string
+	fn   func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error)
+	errc chan error
+}
+
+// do calls fn with the filesystem in the batch.
+// If fn returns a Root, it replaces the filesystem in the batch.
+// ok is false if the batch was committed before fn could be called.
+func (b *batch) do(ctx context.Context, msg string, fn func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error)) (ok bool, _ error) {
+	req := batchReq{msg: msg, fn: fn, errc: make(chan error, 1)}
+	select {
+	case b.reqs <- req:
+		return true, <-req.errc
+	case <-b.done:
+		// if the transaction could not be started, or the changes in it were lost, then retrying will not help.
+		return b.err != nil, b.err
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// commit stops the batch from accepting changes, and commits the changes it has.
+func (b *batch) commit() {
+	b.stopOnce.Do(func() { close(b.stop) })
+}
+
+var _ webdav.File = &file{}
+
+// file is a file or directory opened from an FS.
+type file struct {
+	fsys *FS
+	ctx  context.Context
+	p    string
+
+	// snap and r are only set for a file opened for reading.
+	snap *gotrepo.FSSnapshot
+	r    *gotiofs.File
+
+	// tmp is only set for a file opened for writing, and holds its content until it is closed.
+	tmp *os.File
+	// dirty is true if the content needs to be committed on Close.
+	dirty bool
+
+	// dir and ents are only set for a directory.
+	dir  iofs.FileInfo
+	ents []iofs.FileInfo
+}
+
+func (f *file) loadDir(ctx context.Context, fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+	dir, err := gotiofs.Stat(ctx, fsmach, ss.Metadata, root, f.p)
+	if err != nil {
+		return err
+	}
+	f.dir = dir
+	f.ents = []iofs.FileInfo{}
+	return fsmach.ReadDir(ctx, ss.Metadata, root, f.p, func(de gotfs.DirEnt) error {
+		finfo, err := stat(ctx, fsmach, ss.Metadata, root, path.Join(f.p, de.Name))
+		if err != nil {
+			return err
+		}
+		f.ents = append(f.ents, finfo)
+		return nil
+	})
+}
+
+func (f *file) Read(buf []byte) (int, error) {
+	switch {
+	case f.dir != nil:
+		return 0, fmt.Errorf("gotdav: %q is a directory", f.p)
+	case f.tmp != nil:
+		return f.tmp.Read(buf)
+	default:
+		return f.r.Read(buf)
+	}
+}
+
+func (f *file) Write(data []byte) (int, error) {
+	if f.tmp == nil {
+		return 0, fmt.Errorf("gotdav: %q was not opened for writing", f.p)
+	}
+	f.dirty = true
+	return f.tmp.Write(data)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch {
+	case f.dir != nil:
+		return 0, nil
+	case f.tmp != nil:
+		return f.tmp.Seek(offset, whence)
+	default:
+		return f.r.Seek(offset, whence)
+	}
+}
+
+func (f *file) Readdir(count int) ([]iofs.FileInfo, error) {
+	if f.dir == nil {
+		return nil, fmt.Errorf("gotdav: %q is not a directory", f.p)
+	}
+	if count <= 0 {
+		ents := f.ents
+		f.ents = nil
+		return ents, nil
+	}
+	if len(f.ents) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(f.ents))
+	ents := f.ents[:n]
+	f.ents = f.ents[n:]
+	return ents, nil
+}
+
+func (f *file) Stat() (iofs.FileInfo, error) {
+	switch {
+	case f.dir != nil:
+		return f.dir, nil
+	case f.tmp != nil:
+		finfo, err := f.tmp.Stat()
+		if err != nil {
+			return nil, err
+		}
+		// the content is not in the mark until the file is closed, so the ETag is looked up then.
+		return fileInfo{
+			name:    path.Base(f.p),
+			size:    finfo.Size(),
+			mode:    putFileMode,
+			modTime: time.Unix(0, 0),
+			etag: func(ctx context.Context) (string, error) {
+				finfo, err := f.fsys.Stat(ctx, "/"+f.p)
+				if err != nil {
+					return "", err
+				}
+				if et, ok := finfo.(webdav.ETager); ok {
+					return et.ETag(ctx)
+				}
+				return "", webdav.ErrNotImplemented
+			},
+		}, nil
+	default:
+		return stat(f.ctx, f.snap.FS, f.snap.Stores.Metadata, f.snap.Root, f.p)
+	}
+}
+
+// Close commits the content of the file to the mark, if it was written to.
+func (f *file) Close() error {
+	switch {
+	case f.snap != nil:
+		return f.snap.Close()
+	case f.tmp != nil:
+		defer f.discard()
+		if !f.dirty {
+			return nil
+		}
+		if _, err := f.tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return f.fsys.modify(f.ctx, "put "+f.p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+			return fsmach.PutFile(f.ctx, ss, root, f.p, f.tmp)
+		})
+	default:
+		return nil
+	}
+}
+
+// discard removes the temporary file.
+func (f *file) discard() {
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+}
+
+// putFileMode is the mode which gotfs.Machine.PutFile gives to files.
+const putFileMode iofs.FileMode = 0o755
+
+// stat returns a FileInfo for p.
+// The FileInfo for a regular file implements webdav.ETager.
+func stat(ctx context.Context, fsmach *gotfs.Machine, ms stores.RO, root gotfs.Root, p string) (iofs.FileInfo, error) {
+	finfo, err := gotiofs.Stat(ctx, fsmach, ms, root, p)
+	if err != nil || !finfo.Mode().IsRegular() {
+		return finfo, err
+	}
+	etag, err := contentETag(ctx, fsmach, ms, root, p)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{
+		name:    finfo.Name(),
+		size:    finfo.Size(),
+		mode:    finfo.Mode(),
+		modTime: finfo.ModTime(),
+		etag: func(context.Context) (string, error) {
+			return etag, nil
+		},
+	}, nil
+}
+
+// contentETag returns an ETag for the file at p, which is a hash of the extents of the file.
+// The extents refer to the content, so the ETag changes when the content does, and the content does not need to be read.
+func contentETag(ctx context.Context, fsmach *gotfs.Machine, ms stores.RO, root gotfs.Root, p string) (string, error) {
+	h := sha256.New()
+	it := fsmach.NewIterator(ms, root, gotfs.SpanForPath(p))
+	if err := streams.ForEach[gotfs.Entry](ctx, &it, func(ent gotfs.Entry) error {
+		if ent.Key.IsInfo() {
+			return nil
+		}
+		data, err := ent.Extent.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		h.Write(binary.BigEndian.AppendUint64(nil, ent.Key.EndAt()))
+		h.Write(data)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil)[:16]), nil
+}
+
+var _ webdav.ETager = fileInfo{}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    iofs.FileMode
+	modTime time.Time
+	etag    func(ctx context.Context) (string, error)
+}
+
+func (fi fileInfo) Name() string                             { return fi.name }
+func (fi fileInfo) Size() int64                              { return fi.size }
+func (fi fileInfo) Mode() iofs.FileMode                      { return fi.mode }
+func (fi fileInfo) ModTime() time.Time                       { return fi.modTime }
+func (fi fileInfo) IsDir() bool                              { return false }
+func (fi fileInfo) Sys() any                                 { return nil }
+func (fi fileInfo) ETag(ctx context.Context) (string, error) { return fi.etag(ctx) }
+
+// cleanPath converts a WebDAV name, which starts with a slash, to a gotfs path.
+func cleanPath(name string) string {
+	p := path.Clean("/" + name)
+	return p[1:]
+}
@@ -0,0 +1,149 @@
+package gotdav
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/gottests"
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestFS(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := gottests.NewSite(t)
+	fqm := gotrepo.FQM{Name: "master"}
+	s.CreateMark(fqm)
+	actAs, err := s.WC.GetActAs()
+	require.NoError(t, err)
+	signer, err := s.Repo.GetSigner(ctx, actAs)
+	require.NoError(t, err)
+	fsys := New(s.Repo, fqm, signer)
+	se := gotcore.CommitExpr_Mark{Name: fqm.Name}
+
+	// writes to an empty mark
+	require.NoError(t, fsys.Mkdir(ctx, "/d", 0o755))
+	require.ErrorIs(t, fsys.Mkdir(ctx, "/missing/d", 0o755), os.ErrNotExist)
+	f, err := fsys.OpenFile(ctx, "/d/a.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, fsys.Flush(ctx))
+	require.Equal(t, "hello", string(s.Cat(se, "d/a.txt")))
+
+	f, err = fsys.OpenFile(ctx, "/d/a.txt", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(data))
+	require.NoError(t, f.Close())
+
+	f, err = fsys.OpenFile(ctx, "/d", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	ents, err := f.Readdir(0)
+	require.NoError(t, err)
+	require.Len(t, ents, 1)
+	require.Equal(t, "a.txt", ents[0].Name())
+	require.NoError(t, f.Close())
+
+	require.NoError(t, fsys.Rename(ctx, "/d/a.txt", "/b.txt"))
+	require.NoError(t, fsys.Flush(ctx))
+	require.Equal(t, "hello", string(s.Cat(se, "b.txt")))
+	require.NoError(t, fsys.RemoveAll(ctx, "/d"))
+	_, err = fsys.Stat(ctx, "/d")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	finfo, err := fsys.Stat(ctx, "/b.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), finfo.Size())
+}
+
+func TestBatch(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := gottests.NewSite(t)
+	fqm := gotrepo.FQM{Name: "master"}
+	s.CreateMark(fqm)
+	actAs, err := s.WC.GetActAs()
+	require.NoError(t, err)
+	signer, err := s.Repo.GetSigner(ctx, actAs)
+	require.NoError(t, err)
+	fsys := New(s.Repo, fqm, signer)
+	se := gotcore.CommitExpr_Mark{Name: fqm.Name}
+	put := func(name, data string) {
+		t.Helper()
+		f, err := fsys.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(data))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	getETag := func(name string) string {
+		t.Helper()
+		finfo, err := fsys.Stat(ctx, name)
+		require.NoError(t, err)
+		etag, err := finfo.(webdav.ETager).ETag(ctx)
+		require.NoError(t, err)
+		return etag
+	}
+
+	// changes made together are committed together, and can be read before they are committed.
+	require.NoError(t, fsys.Mkdir(ctx, "/d", 0o755))
+	put("/d/a.txt", "hello")
+	put("/d/b.txt", "world")
+	require.NoError(t, fsys.Rename(ctx, "/d/b.txt", "/d/c.txt"))
+	finfo, err := fsys.Stat(ctx, "/d/c.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), finfo.Size())
+	require.NoError(t, fsys.Flush(ctx))
+	var n int
+	require.NoError(t, s.Repo.History(ctx, se, func(gotrepo.Ref, gotrepo.Commit) error {
+		n++
+		return nil
+	}))
+	require.Equal(t, 1, n)
+	require.Equal(t, "world", string(s.Cat(se, "d/c.txt")))
+
+	// ETags are the same for the same content, and change with the content.
+	etag := getETag("/d/a.txt")
+	require.Equal(t, etag, getETag("/d/a.txt"))
+	require.NotEqual(t, etag, getETag("/d/c.txt"))
+	put("/d/a.txt", "hello again")
+	require.NotEqual(t, etag, getETag("/d/a.txt"))
+}
+
+func TestCommitFails(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := gottests.NewSite(t)
+	fqm := gotrepo.FQM{Name: "master"}
+	s.CreateMark(fqm)
+	actAs, err := s.WC.GetActAs()
+	require.NoError(t, err)
+	signer, err := s.Repo.GetSigner(ctx, actAs)
+	require.NoError(t, err)
+	fsys := New(s.Repo, fqm, signer)
+	require.NoError(t, fsys.Mkdir(ctx, "/d", 0o755))
+	require.NoError(t, fsys.Flush(ctx))
+
+	// a tag cannot be moved, so the changes are accepted into the batch, and then cannot be committed.
+	tag := gotrepo.FQM{Name: "v1.0"}
+	_, err = s.Repo.CreateTag(ctx, tag, gotcore.CommitExpr_Mark{Name: fqm.Name}, "", nil)
+	require.NoError(t, err)
+	fsys = New(s.Repo, tag, signer)
+	f, err := fsys.OpenFile(ctx, "/d/a.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.ErrorIs(t, fsys.Flush(ctx), gotcore.ErrImmutable)
+
+	// the write was lost, so nothing else can succeed.
+	_, err = fsys.Stat(ctx, "/d")
+	require.ErrorIs(t, err, gotcore.ErrImmutable)
+	require.ErrorIs(t, fsys.Mkdir(ctx, "/e", 0o755), gotcore.ErrImmutable)
+	_, err = fsys.OpenFile(ctx, "/d", os.O_RDONLY, 0)
+	require.ErrorIs(t, err, gotcore.ErrImmutable)
+}
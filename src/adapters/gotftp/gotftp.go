@@ -3,21 +3,32 @@ package gotftp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	iofs "io/fs"
+	"os"
 	"path"
 
 	"github.com/gotvc/got/src/adapters/gotiofs"
 	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotrepo"
 	"github.com/gotvc/got/src/internal/gotcore"
 	ftpserver "goftp.io/server/v2"
 )
 
 var _ ftpserver.Driver = &Driver{}
 
+// Driver serves a gotfs filesystem over FTP.
+// A Driver created with NewDriver is read-only, and serves the filesystem in a single Commit.
+// A Driver created with NewWritableDriver serves the filesystem in a mark, and commits each change to the mark.
 type Driver struct {
 	ctx  context.Context
 	vctx *gotcore.ViewCtx
+
+	// repo, fqm and signer are only set for a writable Driver.
+	repo   *gotrepo.Repo
+	fqm    gotrepo.FQM
+	signer *gotrepo.Signer
 }
 
 func NewDriver(ctx context.Context, vctx *gotcore.ViewCtx) *Driver {
@@ -27,25 +38,62 @@ func NewDriver(ctx context.Context, vctx *gotcore.ViewCtx) *Driver {
 	}
 }
 
+// NewWritableDriver returns a Driver for the mark at fqm.
+// Reads see the Commit that the mark points to when they are made,
+// and each operation which changes the filesystem is committed to the mark as a new Commit, signed by signer.
+func NewWritableDriver(ctx context.Context, repo *gotrepo.Repo, fqm gotrepo.FQM, signer *gotrepo.Signer) *Driver {
+	return &Driver{
+		ctx:    ctx,
+		repo:   repo,
+		fqm:    fqm,
+		signer: signer,
+	}
+}
+
 func (d *Driver) Stat(ctx *ftpserver.Context, p string) (iofs.FileInfo, error) {
+	var finfo iofs.FileInfo
+	err := d.view(d.ctx, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+		var err error
+		finfo, err = gotiofs.Stat(d.ctx, fsmach, ss.Metadata, root, p)
+		return err
+	})
+	return finfo, err
+}
+
+// GetFile returns a reader for the file at p, starting at off, and the number of bytes it will read.
+// For a writable Driver, the file is read from a snapshot of the mark, which is released when the reader is closed.
+func (d *Driver) GetFile(ctx *ftpserver.Context, p string, off int64) (int64, io.ReadCloser, error) {
+	if d.vctx == nil {
+		snap, err := d.repo.OpenMarkFS(d.ctx, d.fqm)
+		if err != nil {
+			return 0, nil, err
+		}
+		n, f, err := d.openFile(snap.FS, snap.Stores, snap.Root, p, off)
+		if err != nil {
+			snap.Close()
+			return 0, nil, err
+		}
+		return n, &snapshotFile{File: f, snap: snap}, nil
+	}
 	root, ss, err := d.getRoot(d.ctx)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
-	return gotiofs.Stat(d.ctx, d.vctx.FS, ss.Metadata, *root, p)
+	return d.openFile(d.vctx.FS, ss, *root, p, off)
 }
 
-func (d *Driver) GetFile(ctx *ftpserver.Context, p string, off int64) (int64, io.ReadCloser, error) {
-	root, ss, err := d.getRoot(d.ctx)
+// openFile returns the file at p in root, seeked to off, and the number of bytes after off.
+func (d *Driver) openFile(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root, p string, off int64) (int64, *gotiofs.File, error) {
+	p, err := gotiofs.FollowLinks(d.ctx, fsmach, ss, root, p)
 	if err != nil {
 		return 0, nil, err
 	}
-	size, err := d.vctx.FS.SizeOfFile(d.ctx, ss.Metadata, *root, p)
+	size, err := fsmach.SizeOfFile(d.ctx, ss.Metadata, root, p)
 	if err != nil {
 		return 0, nil, err
 	}
-	f := gotiofs.NewFile(d.ctx, d.vctx.FS, ss, *root, p)
-	off2, err := f.Seek(off, io.SeekStart)
+	f := gotiofs.NewFile(d.ctx, fsmach, ss, root, p)
+	off2, err := f.Seek(min(off, int64(size)), io.SeekStart)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -53,39 +101,125 @@ func (d *Driver) GetFile(ctx *ftpserver.Context, p string, off int64) (int64, io
 }
 
 func (d *Driver) DeleteDir(ctx *ftpserver.Context, p string) error {
-	return newErrReadOnly()
+	return d.modify(d.ctx, "delete "+p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		if _, err := fsmach.GetDirInfo(d.ctx, ss.Metadata, root, p); err != nil {
+			return nil, err
+		}
+		if isRoot(p) {
+			return nil, fmt.Errorf("cannot delete the root")
+		}
+		return fsmach.RemoveAll(d.ctx, ss.Metadata, root, p)
+	})
 }
 
 func (d *Driver) DeleteFile(ctx *ftpserver.Context, p string) error {
-	return newErrReadOnly()
+	return d.modify(d.ctx, "delete "+p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		info, err := fsmach.GetInfo(d.ctx, ss.Metadata, root, p)
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", p)
+		}
+		return fsmach.RemoveAll(d.ctx, ss.Metadata, root, p)
+	})
 }
 
 func (d *Driver) ListDir(ctx *ftpserver.Context, p string, fn func(iofs.FileInfo) error) error {
-	root, ss, err := d.getRoot(d.ctx)
+	return d.view(d.ctx, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+		return fsmach.ReadDir(d.ctx, ss.Metadata, root, p, func(de gotfs.DirEnt) error {
+			p2 := path.Join(p, de.Name)
+			finfo, err := gotiofs.Stat(d.ctx, fsmach, ss.Metadata, root, p2)
+			if err != nil {
+				return err
+			}
+			return fn(finfo)
+		})
+	})
+}
+
+func (d *Driver) MakeDir(ctx *ftpserver.Context, p string) error {
+	return d.modify(d.ctx, "mkdir "+p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		if _, err := fsmach.GetDirInfo(d.ctx, ss.Metadata, root, path.Dir(p)); err != nil {
+			return nil, err
+		}
+		return fsmach.Mkdir(d.ctx, ss.Metadata, root, p)
+	})
+}
+
+// PutFile writes the data from r to the file at p.
+// STOR replaces the file, unless it follows REST, in which case the file is truncated to offset first.
+// APPE appends to the file.
+// The upload is written to a temporary file, before the mark is modified.
+func (d *Driver) PutFile(ctx *ftpserver.Context, p string, r io.Reader, offset int64) (int64, error) {
+	if d.repo == nil {
+		return 0, newErrReadOnly()
+	}
+	tmp, err := os.CreateTemp("", "gotftp-*")
 	if err != nil {
-		return err
+		return 0, err
 	}
-	fsmach := d.vctx.FS
-	return fsmach.ReadDir(d.ctx, ss.Metadata, *root, p, func(de gotfs.DirEnt) error {
-		p2 := path.Join(p, de.Name)
-		finfo, err := gotiofs.Stat(d.ctx, fsmach, ss.Metadata, *root, p2)
-		if err != nil {
-			return err
+	f := &tempFile{tmp}
+	defer f.Close()
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return n, err
+	}
+	appending := ctx.Cmd == "APPE"
+	err = d.modify(d.ctx, "put "+p, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
 		}
-		return fn(finfo)
+		switch info, err := fsmach.GetInfo(d.ctx, ss.Metadata, root, p); {
+		case errors.Is(err, os.ErrNotExist):
+			if _, err := fsmach.GetDirInfo(d.ctx, ss.Metadata, root, path.Dir(p)); err != nil {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		case info.Mode.IsDir():
+			return nil, fmt.Errorf("%s is a directory", p)
+		case offset >= 0:
+			y, err := fsmach.Truncate(d.ctx, ss, root, p, offset)
+			if err != nil {
+				return nil, err
+			}
+			return fsmach.Append(d.ctx, ss, *y, p, tmp)
+		case appending:
+			return fsmach.Append(d.ctx, ss, root, p, tmp)
+		}
+		return fsmach.PutFile(d.ctx, ss, root, p, tmp)
 	})
+	return n, err
 }
 
-func (d *Driver) MakeDir(ctx *ftpserver.Context, p string) error {
-	return newErrReadOnly()
+func (d *Driver) Rename(ctx *ftpserver.Context, oldpath, newpath string) error {
+	return d.modify(d.ctx, fmt.Sprintf("move %s to %s", oldpath, newpath), func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error) {
+		return fsmach.Move(d.ctx, ss, root, oldpath, newpath)
+	})
 }
 
-func (d *Driver) PutFile(ctx *ftpserver.Context, p string, r io.Reader, _ int64) (int64, error) {
-	return 0, newErrReadOnly()
+// view calls fn with the filesystem being served.
+func (d *Driver) view(ctx context.Context, fn func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error) error {
+	if d.vctx == nil {
+		return d.repo.ViewMarkFS(ctx, d.fqm, fn)
+	}
+	root, ss, err := d.getRoot(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(d.vctx.FS, ss, *root)
 }
 
-func (d *Driver) Rename(ctx *ftpserver.Context, oldpath, newpath string) error {
-	return newErrReadOnly()
+// modify commits the result of fn to the mark, or returns an error if the Driver is read-only.
+func (d *Driver) modify(ctx context.Context, msg string, fn func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, error)) error {
+	if d.repo == nil {
+		return newErrReadOnly()
+	}
+	return d.repo.ModifyFS(ctx, d.fqm, d.signer, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, string, error) {
+		y, err := fn(fsmach, ss, root)
+		return y, "ftp: " + msg, err
+	})
 }
 
 func (d *Driver) getRoot(ctx context.Context) (*gotfs.Root, gotfs.RO, error) {
@@ -99,3 +233,34 @@ func (d *Driver) getRoot(ctx context.Context) (*gotfs.Root, gotfs.RO, error) {
 func newErrReadOnly() error {
 	return errors.New("filesystem is read-only")
 }
+
+func isRoot(p string) bool {
+	return path.Clean("/"+p) == "/"
+}
+
+// snapshotFile is a file read from a snapshot, which is closed along with the file.
+type snapshotFile struct {
+	*gotiofs.File
+	snap *gotrepo.FSSnapshot
+}
+
+func (f *snapshotFile) Close() error {
+	err := f.File.Close()
+	if err2 := f.snap.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// tempFile is a temporary file, which is removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	if err2 := os.Remove(f.Name()); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package gotftp
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/gottests"
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+	ftpserver "goftp.io/server/v2"
+)
+
+func TestWritableDriver(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := gottests.NewSite(t)
+	fqm := gotrepo.FQM{Name: "master"}
+	s.CreateMark(fqm)
+	actAs, err := s.WC.GetActAs()
+	require.NoError(t, err)
+	signer, err := s.Repo.GetSigner(ctx, actAs)
+	require.NoError(t, err)
+	d := NewWritableDriver(ctx, s.Repo, fqm, signer)
+	se := gotcore.CommitExpr_Mark{Name: fqm.Name}
+	stor := &ftpserver.Context{Cmd: "STOR"}
+
+	require.NoError(t, d.MakeDir(stor, "/d"))
+	n, err := d.PutFile(stor, "/d/a.txt", strings.NewReader("hello"), -1)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+	require.Equal(t, "hello", string(s.Cat(se, "d/a.txt")))
+
+	_, err = d.PutFile(&ftpserver.Context{Cmd: "APPE"}, "/d/a.txt", strings.NewReader(" world"), -1)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(s.Cat(se, "d/a.txt")))
+	// resuming an upload, after REST
+	_, err = d.PutFile(stor, "/d/a.txt", strings.NewReader("there"), 6)
+	require.NoError(t, err)
+	require.Equal(t, "hello there", string(s.Cat(se, "d/a.txt")))
+
+	size, rc, err := d.GetFile(stor, "/d/a.txt", 6)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, int64(5), size)
+	require.Equal(t, "there", string(data))
+
+	require.NoError(t, d.Rename(stor, "/d/a.txt", "/b.txt"))
+	require.Equal(t, "hello there", string(s.Cat(se, "b.txt")))
+	require.Error(t, d.DeleteFile(stor, "/d"))
+	require.NoError(t, d.DeleteDir(stor, "/d"))
+	require.NoError(t, d.DeleteFile(stor, "/b.txt"))
+	require.Empty(t, s.Ls(se, ""))
+}
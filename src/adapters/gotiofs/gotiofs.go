@@ -9,6 +9,7 @@ import (
 	"io/fs"
 	iofs "io/fs"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/gotvc/got/src/gotfs"
@@ -19,6 +20,7 @@ import (
 )
 
 var _ iofs.FS = &FS{}
+var _ iofs.ReadLinkFS = &FS{}
 
 // FS implements io/fs.FS
 type FS struct {
@@ -46,12 +48,67 @@ func (s *FS) Open(name string) (iofs.File, error) {
 	}
 	ss := s.vctx.FSRO()
 	fsag := s.vctx.FS
-	if _, err := fsag.GetInfo(s.ctx, ss.Metadata, root, name); err != nil {
-		return nil, convertError(err)
+	name, err := FollowLinks(s.ctx, fsag, ss, root, name)
+	if err != nil {
+		return nil, err
 	}
 	return NewFile(s.ctx, fsag, ss, root, name), nil
 }
 
+// ReadLink returns the target of the symbolic link at name.
+func (s *FS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &iofs.PathError{Op: "readlink", Path: name, Err: iofs.ErrInvalid}
+	}
+	if s.vctx.Root == nil {
+		return "", iofs.ErrNotExist
+	}
+	target, err := s.vctx.FS.Readlink(s.ctx, s.vctx.FSRO(), s.vctx.Root.Payload.Snap, name)
+	return target, convertError(err)
+}
+
+// Lstat returns a FileInfo for name, without following a symbolic link at name.
+func (s *FS) Lstat(name string) (iofs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: "lstat", Path: name, Err: iofs.ErrInvalid}
+	}
+	if s.vctx.Root == nil {
+		return nil, iofs.ErrNotExist
+	}
+	return Stat(s.ctx, s.vctx.FS, s.vctx.FSRO().Metadata, s.vctx.Root.Payload.Snap, name)
+}
+
+// maxLinkHops is the maximum number of symbolic links followed when opening a file.
+const maxLinkHops = 40
+
+// FollowLinks returns the path which p refers to, after following symbolic links.
+// Only links which are the last element of the path, and which point to other paths in root, are followed.
+func FollowLinks(ctx context.Context, fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root, p string) (string, error) {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	for range maxLinkHops {
+		info, err := fsmach.GetInfo(ctx, ss.Metadata, root, p)
+		if err != nil {
+			return "", convertError(err)
+		}
+		if !info.IsSymlink() {
+			return p, nil
+		}
+		target, err := fsmach.Readlink(ctx, ss, root, p)
+		if err != nil {
+			return "", convertError(err)
+		}
+		next := path.Join(path.Dir(p), target)
+		if path.IsAbs(target) || next == ".." || strings.HasPrefix(next, "../") {
+			return "", &iofs.PathError{Op: "open", Path: p, Err: fmt.Errorf("symbolic link to %q leaves the filesystem", target)}
+		}
+		if next == "." {
+			next = ""
+		}
+		p = next
+	}
+	return "", &iofs.PathError{Op: "open", Path: p, Err: errors.New("too many levels of symbolic links")}
+}
+
 var _ iofs.File = &File{}
 var _ iofs.ReadDirFile = &File{}
 var _ io.ReaderAt = &File{}
@@ -218,18 +275,25 @@ func Stat(ctx context.Context, fsag *gotfs.Machine, ms stores.RO, root gotfs.Roo
 	}
 	mode := iofs.FileMode(info.Mode)
 	var size int64
-	if mode.IsRegular() {
+	if mode.IsRegular() || info.IsSymlink() {
 		s, err := fsag.SizeOfFile(ctx, ms, root, p)
 		if err != nil {
 			return nil, convertError(err)
 		}
 		size = int64(s)
 	}
+	// the modification time is only stored if it was imported, otherwise it is the epoch.
+	modTime, ok, err := info.ModTime()
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		modTime = time.Unix(0, 0)
+	}
 	return &fileInfo{
 		name:    path.Base(p),
 		mode:    mode,
 		size:    size,
-		modTime: time.Unix(0, 0),
+		modTime: modTime,
 	}, nil
 }
 
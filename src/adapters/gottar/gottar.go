@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 
 	"github.com/gotvc/got/src/gotfs"
 	"github.com/gotvc/got/src/internal/stores"
@@ -24,13 +25,33 @@ func WriteTAR(ctx context.Context, fsag *gotfs.Machine, ms, ds stores.RO, root g
 			}
 			size = int64(s)
 		}
-		if err := tw.WriteHeader(&tar.Header{
+		var linkname string
+		if info.IsSymlink() {
+			target, err := fsag.Readlink(ctx, gotfs.RO{ms, ds}, root, p)
+			if err != nil {
+				return err
+			}
+			linkname = target
+		}
+		th := &tar.Header{
 			Typeflag: typeFlagFromMode(mode),
 			Name:     p,
+			Linkname: linkname,
 			Mode:     int64(mode),
-			Xattrs:   convertAttrs(info.Attrs),
+			Xattrs:   convertAttrs(info.Xattrs()),
 			Size:     size,
-		}); err != nil {
+		}
+		if mtime, ok, err := info.ModTime(); err != nil {
+			return err
+		} else if ok {
+			th.ModTime = mtime
+		}
+		if uid, gid, ok, err := info.Owner(); err != nil {
+			return err
+		} else if ok {
+			th.Uid, th.Gid = int(uid), int(gid)
+		}
+		if err := tw.WriteHeader(th); err != nil {
 			return err
 		}
 		if mode.IsRegular() {
@@ -69,6 +90,10 @@ func ReadTAR(ctx context.Context, b *gotfs.Builder, tr *tar.Reader) error {
 			if _, err := io.Copy(b, tr); err != nil {
 				return err
 			}
+		case mode&fs.ModeSymlink != 0:
+			if err := b.Symlink(th.Name, th.Linkname); err != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("gottar: unrecognized mode %v", th.Mode)
 		}
@@ -82,6 +107,8 @@ func typeFlagFromMode(mode posixfs.FileMode) byte {
 		return tar.TypeDir
 	case mode.IsRegular():
 		return tar.TypeReg
+	case mode&fs.ModeSymlink != 0:
+		return tar.TypeSymlink
 	default:
 		return 0
 	}
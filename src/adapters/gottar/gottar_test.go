@@ -52,6 +52,43 @@ func TestRead(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestReadSymlink(t *testing.T) {
+	ctx := testutil.Context(t)
+	ms, ds := stores.NewMem(), stores.NewMem()
+	fsag := gotfs.NewMachine(gotfs.Params{})
+	var root *gotfs.Root
+	err := WithPipe(func(w io.Writer) error {
+		tw := tar.NewWriter(w)
+		if err := tw.WriteHeader(&tar.Header{
+			Name: "/",
+			Mode: int64(fs.ModeDir | 0o755),
+		}); err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     "/link",
+			Linkname: "../target",
+			Mode:     0o777,
+		}); err != nil {
+			return err
+		}
+		return tw.Close()
+	}, func(r io.Reader) error {
+		b := fsag.NewBuilder(ctx, gotfs.RW{Data: ds, Metadata: ms})
+		if err := ReadTAR(ctx, b, tar.NewReader(r)); err != nil {
+			return err
+		}
+		var err error
+		root, err = b.Finish()
+		return err
+	})
+	require.NoError(t, err)
+	target, err := fsag.Readlink(ctx, gotfs.RO{Data: ds, Metadata: ms}, *root, "link")
+	require.NoError(t, err)
+	require.Equal(t, "../target", target)
+}
+
 func WithPipe(wfn func(w io.Writer) error, rfn func(r io.Reader) error) error {
 	pr, pw := io.Pipe()
 	eg := errgroup.Group{}
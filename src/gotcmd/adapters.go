@@ -1,15 +1,19 @@
 package gotcmd
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 
 	"go.brendoncarroll.net/star"
 	"go.brendoncarroll.net/stdctx/logctx"
 	ftpserver "goftp.io/server/v2"
+	"golang.org/x/net/webdav"
 
+	"github.com/gotvc/got/src/adapters/gotdav"
 	"github.com/gotvc/got/src/adapters/gotftp"
 	"github.com/gotvc/got/src/adapters/gotiofs"
+	"github.com/gotvc/got/src/gotrepo"
 	"github.com/gotvc/got/src/internal/gotcore"
 )
 
@@ -52,39 +56,125 @@ var ftpCmd = star.Command{
 	},
 	Pos: []star.Positional{commExprParam},
 	Flags: map[string]star.Flag{
-		"addr": addrParam,
+		"addr":     addrParam,
+		"writable": ftpWritableParam,
 	},
 	F: func(c star.Context) error {
 		ctx := c.Context
-		repo, close, err := openRepo(c)
-		if err != nil {
-			return err
-		}
-		defer close()
 		addr, _ := addrParam.LoadOpt(c)
 		if addr == "" {
 			addr = "127.0.0.1:6006"
 		}
-		l, err := net.Listen("tcp", addr)
-		if err != nil {
-			return err
-		}
-		defer l.Close()
-		return repo.ViewCommit(ctx, commExprParam.Load(c), func(vcx *gotcore.ViewCtx) error {
+		serve := func(driver ftpserver.Driver) error {
 			s, err := ftpserver.NewServer(&ftpserver.Options{
 				Auth:   ftpAuth{},
-				Driver: gotftp.NewDriver(ctx, vcx),
+				Driver: driver,
 				Perm:   ftpserver.NewSimplePerm("owner", "group"),
 			})
 			if err != nil {
 				return err
 			}
+			l, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			defer l.Close()
 			logctx.Infof(ctx, "serving on ftp://%v", l.Addr())
 			return s.Serve(l)
+		}
+		if writable, _ := ftpWritableParam.LoadOpt(c); writable {
+			se, ok := commExprParam.Load(c).(*gotcore.CommitExpr_Mark)
+			if !ok {
+				return fmt.Errorf("--writable requires a mark to commit to, not %v", commExprParam.Load(c))
+			}
+			fqm := gotrepo.FQM{Space: se.Space, Name: se.Name}
+			wc, err := openWC()
+			if err != nil {
+				return err
+			}
+			defer wc.Close()
+			actAs, err := wc.GetActAs()
+			if err != nil {
+				return err
+			}
+			repo := wc.Repo()
+			signer, err := repo.GetSigner(ctx, actAs)
+			if err != nil {
+				return err
+			}
+			if _, err := repo.InspectMark(ctx, fqm); err != nil {
+				return err
+			}
+			return serve(gotftp.NewWritableDriver(ctx, repo, fqm, signer))
+		}
+		repo, close, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		defer close()
+		return repo.ViewCommit(ctx, commExprParam.Load(c), func(vcx *gotcore.ViewCtx) error {
+			return serve(gotftp.NewDriver(ctx, vcx))
 		})
 	},
 }
 
+var ftpWritableParam = &star.Optional[bool]{
+	PosName:  "writable",
+	ShortDoc: "allow changes to the files, which are committed to the mark",
+	Parse:    parseBoolFlag,
+}
+
+var davCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "serve a mark over WebDAV, committing any changes to it",
+	},
+	Pos: []star.Positional{fqmParam},
+	Flags: map[string]star.Flag{
+		"addr": addrParam,
+	},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		actAs, err := wc.GetActAs()
+		if err != nil {
+			return err
+		}
+		repo := wc.Repo()
+		signer, err := repo.GetSigner(ctx, actAs)
+		if err != nil {
+			return err
+		}
+		fqm := fqmParam.Load(c)
+		if _, err := repo.InspectMark(ctx, fqm); err != nil {
+			return err
+		}
+		h := &webdav.Handler{
+			FileSystem: gotdav.New(repo, fqm, signer),
+			LockSystem: webdav.NewMemLS(),
+			Logger: func(r *http.Request, err error) {
+				if err != nil {
+					logctx.Warnf(ctx, "%s %s: %v", r.Method, r.URL.Path, err)
+				}
+			},
+		}
+		addr, _ := addrParam.LoadOpt(c)
+		if addr == "" {
+			addr = "127.0.0.1:6006"
+		}
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+		logctx.Infof(ctx, "serving %s on http://%v", fqm.Name, l.Addr())
+		return http.Serve(l, h)
+	},
+}
+
 var commExprParam = &star.Required[gotcore.CommitExpr]{
 	PosName:  "commit-expr",
 	Parse:    gotcore.ParseCommitExpr,
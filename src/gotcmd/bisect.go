@@ -0,0 +1,198 @@
+package gotcmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"go.brendoncarroll.net/star"
+
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/gotwc"
+	"github.com/gotvc/got/src/internal/gotcore"
+)
+
+var bisectCmd = star.NewDir(
+	star.Metadata{
+		Short: "searches the history of the HEAD mark for the commit which introduced a bug",
+	}, map[string]star.Command{
+		"start": bisectStartCmd,
+		"good":  bisectGoodCmd,
+		"bad":   bisectBadCmd,
+		"skip":  bisectSkipCmd,
+		"reset": bisectResetCmd,
+		"run":   bisectRunCmd,
+	},
+)
+
+var bisectStartCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "starts a bisect of the history of the HEAD mark",
+	},
+	F: func(c star.Context) error {
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		if err := wc.BisectStart(c); err != nil {
+			return err
+		}
+		c.Printf("bisect started, mark a good and a bad commit\n")
+		return nil
+	},
+}
+
+var bisectGoodCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "marks a commit as good, defaults to the commit which is checked out",
+	},
+	Pos: []star.Positional{commExprOptParam},
+	F: func(c star.Context) error {
+		return bisectMark(c, (*gotwc.WC).BisectGood)
+	},
+}
+
+var bisectBadCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "marks a commit as bad, defaults to the commit which is checked out",
+	},
+	Pos: []star.Positional{commExprOptParam},
+	F: func(c star.Context) error {
+		return bisectMark(c, (*gotwc.WC).BisectBad)
+	},
+}
+
+var bisectSkipCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "marks a commit as untestable, defaults to the commit which is checked out",
+	},
+	Pos: []star.Positional{commExprOptParam},
+	F: func(c star.Context) error {
+		return bisectMark(c, (*gotwc.WC).BisectSkip)
+	},
+}
+
+var bisectResetCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "ends the bisect, and checks out the commit from before it started",
+	},
+	F: func(c star.Context) error {
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		return wc.BisectReset(c)
+	},
+}
+
+var bisectRunCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "tests commits with a command until the first bad commit is found. exit code 0 is good, 125 is skip, and 1-127 is bad",
+	},
+	Pos: []star.Positional{bisectRunCmdParam},
+	F: func(c star.Context) error {
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		bs, err := wc.GetBisect()
+		if err != nil {
+			return err
+		}
+		if bs == nil {
+			return fmt.Errorf("no bisect in progress, start one first")
+		}
+		if bs.Bad == nil || len(bs.Good) == 0 {
+			return fmt.Errorf("mark a good and a bad commit before running")
+		}
+		name, args := bisectRunCmdParam.Load(c), c.Extra
+		for {
+			cmd := exec.CommandContext(c, name, args...)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = c.StdIn, c.StdOut, c.StdErr
+			var code int
+			if err := cmd.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if !errors.As(err, &exitErr) {
+					return err
+				}
+				code = exitErr.ExitCode()
+			}
+			var mark func(*gotwc.WC, context.Context, gotcore.CommitExpr) (*gotcore.BisectStep, error)
+			switch {
+			case code == 0:
+				mark = (*gotwc.WC).BisectGood
+			case code == 125:
+				mark = (*gotwc.WC).BisectSkip
+			case code > 0 && code < 128:
+				mark = (*gotwc.WC).BisectBad
+			default:
+				return fmt.Errorf("bisect run stopped, %q exited with %d", name, code)
+			}
+			step, err := mark(wc, c, nil)
+			if err != nil {
+				return err
+			}
+			if err := printBisectStep(c, wc.Repo(), step); err != nil {
+				return err
+			}
+			if step.Done() {
+				return nil
+			}
+		}
+	},
+}
+
+var bisectRunCmdParam = &star.Required[string]{
+	PosName:  "cmd",
+	Parse:    star.ParseString,
+	ShortDoc: "the command to test each commit with, followed by its arguments",
+}
+
+func bisectMark(c star.Context, mark func(*gotwc.WC, context.Context, gotcore.CommitExpr) (*gotcore.BisectStep, error)) error {
+	wc, err := openWC()
+	if err != nil {
+		return err
+	}
+	defer wc.Close()
+	var se gotcore.CommitExpr
+	if x, ok := commExprOptParam.LoadOpt(c); ok {
+		se = x
+	}
+	step, err := mark(wc, c, se)
+	if err != nil {
+		return err
+	}
+	return printBisectStep(c, wc.Repo(), step)
+}
+
+// printBisectStep prints the next commit to test, or the result of the bisect if it is done.
+func printBisectStep(c star.Context, repo *gotrepo.Repo, step *gotcore.BisectStep) error {
+	switch {
+	case step == nil:
+		c.Printf("waiting for a good and a bad commit\n")
+		return nil
+	case !step.Done():
+		c.Printf("bisecting: %d commits could be the first bad commit, testing %v\n", step.Remaining, step.Next.CID)
+		return nil
+	case len(step.Candidates) > 1:
+		c.Printf("there are only skipped commits left to test, the first bad commit could be any of:\n")
+		for _, ref := range step.Candidates {
+			c.Printf("  %v\n", ref.CID)
+		}
+		return nil
+	}
+	ref := step.Candidates[0]
+	c.Printf("%v is the first bad commit\n", ref.CID)
+	return repo.ViewCommit(c, &gotcore.CommitExpr_Exact{Ref: ref}, func(vctx *gotcore.ViewCtx) error {
+		bufw := bufio.NewWriter(c.StdOut)
+		if err := printcomm(bufw, ref, *vctx.Root); err != nil {
+			return err
+		}
+		return bufw.Flush()
+	})
+}
@@ -3,14 +3,20 @@ package gotcmd
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotfs"
 	"github.com/gotvc/got/src/gotrepo"
 	"github.com/gotvc/got/src/internal/gotcore"
-	"go.brendoncarroll.net/exp/streams"
+	"github.com/gotvc/got/src/internal/graphlog"
 	"go.brendoncarroll.net/star"
 	"golang.org/x/sync/errgroup"
 )
@@ -20,6 +26,13 @@ var historyCmd = star.Command{
 		Short: "prints the commit log",
 	},
 	Pos: []star.Positional{fqmnOptParam},
+	Flags: map[string]star.Flag{
+		// this makes the path follow "--" like `got history -- <path>`
+		"":        historyPathParam,
+		"verify":  historyVerifyParam,
+		"graph":   historyGraphParam,
+		"oneline": historyOnelineParam,
+	},
 	F: func(c star.Context) error {
 		ctx := c.Context
 		wc, err := openWC()
@@ -37,19 +50,62 @@ var historyCmd = star.Command{
 			fqm = gotrepo.FQM{Name: bname}
 		}
 		markExpr := gotcore.CommitExpr_Mark{Space: fqm.Space, Name: fqm.Name}
+		var verify func(context.Context, gotcore.Commit) error
+		if yes, _ := historyVerifyParam.LoadOpt(c); yes {
+			if verify, err = repo.CommitVerifier(ctx); err != nil {
+				return err
+			}
+		}
+		oneline, _ := historyOnelineParam.LoadOpt(c)
+		var graph *graphlog.Renderer[gdat.Ref]
+		if yes, _ := historyGraphParam.LoadOpt(c); yes {
+			if _, ok := historyPathParam.LoadOpt(c); ok {
+				return fmt.Errorf("--graph cannot be used with a path")
+			}
+			graph = &graphlog.Renderer[gdat.Ref]{}
+		}
 		pr, pw := io.Pipe()
 		eg := errgroup.Group{}
 		eg.Go(func() error {
 			bufw := bufio.NewWriter(pw)
-			err := repo.History(ctx, markExpr, func(ref gdat.Ref, comm gotrepo.Commit) error {
-				if err := printcomm(bufw, ref, comm); err != nil {
+			fn := func(ref gdat.Ref, comm gotrepo.Commit) error {
+				var buf bytes.Buffer
+				w := bufio.NewWriter(&buf)
+				if oneline {
+					if err := printcommOneline(w, ref, comm); err != nil {
+						return err
+					}
+					if verify != nil {
+						fmt.Fprintf(w, " [%s]", sigStatus(verify(ctx, comm)))
+					}
+				} else {
+					if err := printcomm(w, ref, comm); err != nil {
+						return err
+					}
+					if verify != nil {
+						fmt.Fprintf(w, "Signature: %s\n", sigStatus(verify(ctx, comm)))
+					}
+				}
+				if err := w.WriteByte('\n'); err != nil {
 					return err
 				}
-				if err := bufw.WriteByte('\n'); err != nil {
+				if err := w.Flush(); err != nil {
 					return err
 				}
+				if graph == nil {
+					bufw.Write(buf.Bytes())
+				} else {
+					lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+					printGraphRow(bufw, graph, graph.Add(ref, comm.Parents), lines)
+				}
 				return bufw.Flush()
-			})
+			}
+			var err error
+			if p, ok := historyPathParam.LoadOpt(c); ok {
+				err = repo.PathHistory(ctx, markExpr, p, fn)
+			} else {
+				err = repo.History(ctx, markExpr, fn)
+			}
 			pw.CloseWithError(err)
 			return err
 		})
@@ -81,57 +137,60 @@ func printcomm(bufw *bufio.Writer, ref gdat.Ref, comm gotcore.Commit) error {
 	return nil
 }
 
-var diffCmd = star.Command{
-	Metadata: star.Metadata{Short: "diff 2 commits. prints what must be applied to <left> to get <right>"},
-	Pos:      []star.Positional{leftCE, rightCE},
-	F: func(c star.Context) error {
-		repo, closer, err := openRepo(c)
-		if err != nil {
-			return err
+// printcommOneline prints the short CID, N, author and the first line of the message of a Commit, on one line.
+func printcommOneline(bufw *bufio.Writer, ref gdat.Ref, comm gotcore.Commit) error {
+	notes, err := comm.Payload.ParseNotes()
+	if err != nil {
+		return err
+	}
+	author := comm.Creator
+	if len(notes.Authors) > 0 {
+		author = notes.Authors[0]
+	}
+	subject, _, _ := strings.Cut(notes.Message, "\n")
+	_, err = fmt.Fprintf(bufw, "%s #%04d %s %s", shorten(ref.CID.String(), 12), comm.N, shorten(author.String(), 12), subject)
+	return err
+}
+
+// printGraphRow prints the lines of text for a Commit next to the Commit's row in the graph.
+// Lines of text beyond the row are printed next to the open lanes.
+func printGraphRow(bufw *bufio.Writer, graph *graphlog.Renderer[gdat.Ref], row graphlog.Row, lines []string) {
+	for _, l := range row.Before {
+		fmt.Fprintln(bufw, strings.TrimRight(l, " "))
+	}
+	padding := graph.Padding()
+	width := max(len(row.Node), len(padding))
+	for _, l := range row.After {
+		width = max(width, len(l))
+	}
+	for i := 0; i < len(lines) || i <= len(row.After); i++ {
+		prefix := padding
+		switch {
+		case i == 0:
+			prefix = row.Node
+		case i <= len(row.After):
+			prefix = row.After[i-1]
 		}
-		defer closer()
-		w := bufio.NewWriter(c.StdOut)
-		if err := repo.DiffFS(c, leftCE.Load(c), rightCE.Load(c), func(dfr *gotfs.Differ) error {
-			var currentPath string
-			return streams.ForEach(c, dfr, func(ent gotfs.DiffEntry) error {
-				if ent.Left.Ok && ent.Right.Ok {
-					return nil
-				}
-				if ent.Key.IsInfo() {
-					currentPath = ent.Key.Path()
-					var dir string
-					if ent.Left.Ok && !ent.Right.Ok {
-						dir = "-"
-					} else if !ent.Left.Ok && ent.Right.Ok {
-						dir = "+"
-					}
-					fmt.Fprintf(w, "%s %v\n", dir, currentPath)
-				} else {
-					if currentPath != ent.Key.Path() {
-						currentPath = ent.Key.Path()
-						fmt.Fprintf(w, "%s \n", currentPath)
-					}
-					var dir string
-					var ext gotfs.Extent
-					if ent.Left.Ok && !ent.Right.Ok {
-						dir = "+"
-						ext = ent.Left.X.Extent
-					} else if !ent.Left.Ok && ent.Right.Ok {
-						dir = "-"
-						ext = ent.Right.X.Extent
-					}
-					l := ext.Length
-					endAt := ent.Key.EndAt()
-					startAt := endAt - uint64(l)
-					fmt.Fprintf(w, "  %s [%v, %v) size=%vB \n", dir, startAt, endAt, l)
-				}
-				return nil
-			})
-		}); err != nil {
-			return err
+		var text string
+		if i < len(lines) {
+			text = lines[i]
 		}
-		return w.Flush()
-	},
+		fmt.Fprintln(bufw, strings.TrimRight(fmt.Sprintf("%-*s%s", width, prefix, text), " "))
+	}
+}
+
+// sigStatus describes the result of verifying a Commit's signature.
+func sigStatus(err error) string {
+	switch {
+	case err == nil:
+		return "OK"
+	case errors.Is(err, gotcore.ErrUnsigned):
+		return "(none)"
+	case errors.Is(err, gotcore.ErrUnknownSigner):
+		return "unknown signer"
+	default:
+		return "INVALID: " + err.Error()
+	}
 }
 
 var leftCE = &star.Required[gotcore.CommitExpr]{
@@ -151,7 +210,8 @@ var lsCmd = star.Command{
 		Short: "lists the children of path in the current volume",
 	},
 	Flags: map[string]star.Flag{
-		"comm": commExprOptParam,
+		"comm":   commExprOptParam,
+		"digest": lsDigestParam,
 	},
 	Pos: []star.Positional{pathParam},
 	F: func(c star.Context) error {
@@ -170,6 +230,18 @@ var lsCmd = star.Command{
 			se = &gotcore.CommitExpr_Mark{Name: mname}
 		}
 		p, _ := pathParam.LoadOpt(c)
+		if digest, _ := lsDigestParam.LoadOpt(c); digest {
+			return wc.Repo().ViewFS(ctx, se, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+				return fsmach.ReadDir(ctx, ss.Metadata, root, p, func(ent gotfs.DirEnt) error {
+					d, err := fsmach.ContentDigest(ctx, ss, root, path.Join(p, ent.Name))
+					if err != nil {
+						return err
+					}
+					_, err = fmt.Fprintf(c.StdOut, "%v %v %s\n", d, ent.Mode, ent.Name)
+					return err
+				})
+			})
+		}
 		return wc.Repo().Ls(ctx, se, p, func(ent gotfs.DirEnt) error {
 			_, err := fmt.Fprintf(c.StdOut, "%v %s\n", ent.Mode, ent.Name)
 			return err
@@ -177,6 +249,12 @@ var lsCmd = star.Command{
 	},
 }
 
+var lsDigestParam = &star.Optional[bool]{
+	PosName:  "digest",
+	ShortDoc: "print the content digest of each entry, which does not depend on how the filesystem was salted",
+	Parse:    parseBoolFlag,
+}
+
 var catCmd = star.Command{
 	Metadata: star.Metadata{
 		Short: "writes the contents of path in the current volume to stdout",
@@ -205,6 +283,66 @@ var catCmd = star.Command{
 	},
 }
 
+var blameCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "prints each line of a file with the commit which last changed it",
+	},
+	Flags: map[string]star.Flag{
+		"comm": commExprOptParam,
+	},
+	Pos: []star.Positional{blamePathParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		se, ok := commExprOptParam.LoadOpt(c)
+		if !ok {
+			mname, err := wc.GetSaveTo()
+			if err != nil {
+				return err
+			}
+			se = &gotcore.CommitExpr_Mark{Name: mname}
+		}
+		lines, err := wc.Repo().Blame(ctx, se, blamePathParam.Load(c))
+		if err != nil {
+			return err
+		}
+		notes := map[gdat.Ref]gotcore.CommitNotes{}
+		bufw := bufio.NewWriter(c.StdOut)
+		for i, line := range lines {
+			n, ok := notes[line.Ref]
+			if !ok {
+				if n, err = line.Commit.Payload.ParseNotes(); err != nil {
+					return err
+				}
+				notes[line.Ref] = n
+			}
+			author := line.Commit.Creator
+			if len(n.Authors) > 0 {
+				author = n.Authors[0]
+			}
+			at := n.AuthoredAt
+			if at == 0 {
+				at = line.Commit.CreatedAt
+			}
+			text := strings.TrimSuffix(line.Line, "\n")
+			fmt.Fprintf(bufw, "%s %s %s %4d) %s\n", shorten(line.Ref.CID.String(), 12), shorten(author.String(), 12), at.GoTime().Local().Format(time.DateOnly), i+1, text)
+		}
+		return bufw.Flush()
+	},
+}
+
+// shorten returns the first n characters of x
+func shorten(x string, n int) string {
+	if len(x) > n {
+		return x[:n]
+	}
+	return x
+}
+
 var commExprOptParam = &star.Optional[gotrepo.CommitExpr]{
 	PosName:  "comm",
 	Parse:    gotcore.ParseCommitExpr,
@@ -219,6 +357,36 @@ var fqmnOptParam = &star.Optional[gotrepo.FQM]{
 	ShortDoc: "a fully qualified mark name",
 }
 
+var historyPathParam = &star.Optional[string]{
+	PosName:  "path",
+	ShortDoc: "only show commits which change the path, or anything beneath it",
+	Parse:    star.ParseString,
+}
+
+var historyGraphParam = &star.Optional[bool]{
+	PosName:  "graph",
+	ShortDoc: "draw the commit graph next to the log",
+	Parse:    parseBoolFlag,
+}
+
+var historyOnelineParam = &star.Optional[bool]{
+	PosName:  "oneline",
+	ShortDoc: "print each commit on a single line",
+	Parse:    parseBoolFlag,
+}
+
+var historyVerifyParam = &star.Optional[bool]{
+	PosName:  "verify",
+	ShortDoc: "check the signature on each commit",
+	Parse:    parseBoolFlag,
+}
+
+var blamePathParam = &star.Required[string]{
+	PosName:  "path",
+	ShortDoc: "the path of a text file",
+	Parse:    star.ParseString,
+}
+
 var pathParam = &star.Optional[string]{
 	PosName: "path",
 	Parse:   star.ParseString,
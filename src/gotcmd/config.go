@@ -30,7 +30,67 @@ var configCmd = star.Command{
 	},
 }
 
-var configSubCmds = map[string]star.Command{}
+var configSubCmds = map[string]star.Command{
+	"attrs": configAttrsCmd,
+}
+
+var configAttrsCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "sets the file metadata recorded by the working copy, any of: xattrs, mtime, owner. with none, only the mode is recorded",
+	},
+	Pos: []star.Positional{attrNamesParam},
+	F: func(c star.Context) error {
+		var ap gotwc.AttrPolicy
+		for _, name := range attrNamesParam.Load(c) {
+			switch name {
+			case "xattrs":
+				ap.Xattrs = true
+			case "mtime":
+				ap.ModTime = true
+			case "owner":
+				ap.Owner = true
+			default:
+				return fmt.Errorf("unknown attribute %q, must be one of: xattrs, mtime, owner", name)
+			}
+		}
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		if err := wc.Configure(c, func(x gotwc.Config) gotwc.Config {
+			x.Attrs = ap
+			return x
+		}); err != nil {
+			return err
+		}
+		c.Printf("ATTRS: %s\n", formatAttrPolicy(ap))
+		return nil
+	},
+}
+
+var attrNamesParam = &star.Repeated[string]{
+	PosName:  "attrs",
+	ShortDoc: "the metadata to record on import, and restore on export",
+	Parse:    star.ParseString,
+}
+
+func formatAttrPolicy(ap gotwc.AttrPolicy) string {
+	var names []string
+	if ap.Xattrs {
+		names = append(names, "xattrs")
+	}
+	if ap.ModTime {
+		names = append(names, "mtime")
+	}
+	if ap.Owner {
+		names = append(names, "owner")
+	}
+	if len(names) == 0 {
+		return "(none)"
+	}
+	return strings.Join(names, " ")
+}
 
 func printConfig(c star.Context) error {
 	workDir, err := os.OpenRoot(".")
@@ -72,6 +132,7 @@ func printWCConfig(c *star.Context, wcCfg gotwc.Config, indent string) error {
 	c.Printf("%sHEAD: %s\n", indent, wcCfg.SaveTo)
 	c.Printf("%sACT AS: %s\n", indent, wcCfg.ActAs)
 	c.Printf("%sREPO: %v\n", indent, wcCfg.Repo)
+	c.Printf("%sATTRS: %s\n", indent, formatAttrPolicy(wcCfg.Attrs))
 	if len(wcCfg.Base) > 0 {
 		c.Printf("%sBASE:\n", indent)
 		for _, ref := range wcCfg.Base {
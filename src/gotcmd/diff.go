@@ -0,0 +1,452 @@
+package gotcmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/linediff"
+	"go.brendoncarroll.net/exp/streams"
+	"go.brendoncarroll.net/star"
+)
+
+const (
+	// defaultDiffContext is the number of unchanged lines shown around each change.
+	defaultDiffContext = 3
+	// maxStatBarWidth is the widest that the +/- bar for a file is drawn by --stat.
+	maxStatBarWidth = 40
+)
+
+var diffCmd = star.Command{
+	Metadata: star.Metadata{Short: "diff 2 commits. prints what must be applied to <left> to get <right>"},
+	Pos:      []star.Positional{leftCE, rightCE},
+	Flags: map[string]star.Flag{
+		"copies":      diffCopiesParam,
+		"similarity":  diffSimilarityParam,
+		"context":     diffContextParam,
+		"stat":        diffStatParam,
+		"name-status": diffNameStatusParam,
+		"raw":         diffRawParam,
+		"by-content":  diffByContentParam,
+	},
+	F: func(c star.Context) error {
+		repo, closer, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		w := bufio.NewWriter(c.StdOut)
+		copies, _ := diffCopiesParam.LoadOpt(c)
+		threshold, _ := diffSimilarityParam.LoadOpt(c)
+		opts := gotfs.ChangeOptions{
+			Threshold: threshold,
+			Copies:    copies,
+		}
+		left, right := leftCE.Load(c), rightCE.Load(c)
+		byContent, _ := diffByContentParam.LoadOpt(c)
+		if raw, _ := diffRawParam.LoadOpt(c); raw {
+			if byContent {
+				return fmt.Errorf("--raw lists changed extents, which cannot be combined with --by-content")
+			}
+			if err := writeRawDiff(c, w, repo, left, right, opts); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		stat, _ := diffStatParam.LoadOpt(c)
+		nameStatus, _ := diffNameStatusParam.LoadOpt(c)
+		numContext, ok := diffContextParam.LoadOpt(c)
+		if !ok {
+			numContext = defaultDiffContext
+		}
+		if err := repo.ViewFSPair(c, left, right, func(fsmach *gotfs.Machine, ss gotfs.RO, left, right gotfs.Root) error {
+			changesFn := fsmach.Changes
+			if byContent {
+				changesFn = fsmach.ContentChanges
+			}
+			changes, err := changesFn(c, ss, left, right, opts)
+			if err != nil {
+				return err
+			}
+			if nameStatus {
+				return writeNameStatus(w, changes)
+			}
+			var stats []diffStat
+			for _, ch := range changes {
+				fd, err := loadFileDiff(c, fsmach, ss, left, right, ch)
+				if err != nil {
+					return err
+				}
+				if fd == nil {
+					continue
+				}
+				if stat {
+					stats = append(stats, fd.stat())
+					continue
+				}
+				if err := fd.writeUnified(w, numContext); err != nil {
+					return err
+				}
+			}
+			if stat {
+				return writeDiffStat(w, stats)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		return w.Flush()
+	},
+}
+
+var diffCopiesParam = &star.Optional[bool]{
+	PosName:  "copies",
+	ShortDoc: "also detect files copied from other files, not just renames",
+	Parse:    parseBoolFlag,
+}
+
+var diffSimilarityParam = &star.Optional[int]{
+	PosName:  "similarity",
+	ShortDoc: "the percentage of content 2 files must share to be a rename or copy, 100 only pairs identical files",
+	Parse: func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 100 {
+			return 0, fmt.Errorf("invalid similarity: %q, must be a percentage from 1 to 100", s)
+		}
+		return n, nil
+	},
+}
+
+var diffContextParam = &star.Optional[int]{
+	PosName:  "context",
+	ShortDoc: "the number of unchanged lines to show around each change, defaults to 3",
+	Parse: func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of context lines: %q", s)
+		}
+		return n, nil
+	},
+}
+
+var diffStatParam = &star.Optional[bool]{
+	PosName:  "stat",
+	ShortDoc: "only print the number of lines changed in each file",
+	Parse:    parseBoolFlag,
+}
+
+var diffNameStatusParam = &star.Optional[bool]{
+	PosName:  "name-status",
+	ShortDoc: "only print the path and kind of change for each file",
+	Parse:    parseBoolFlag,
+}
+
+var diffRawParam = &star.Optional[bool]{
+	PosName:  "raw",
+	ShortDoc: "print the changed paths and extents, instead of the changed lines",
+	Parse:    parseBoolFlag,
+}
+
+var diffByContentParam = &star.Optional[bool]{
+	PosName:  "by-content",
+	ShortDoc: "compare files by the digest of their content, for commits whose filesystems were written with different salts",
+	Parse:    parseBoolFlag,
+}
+
+// writeNameStatus writes a line for each change, with a letter for the kind of change, and the paths.
+func writeNameStatus(w io.Writer, changes []gotfs.Change) error {
+	for _, ch := range changes {
+		var err error
+		switch ch.Type {
+		case gotfs.Change_Add:
+			_, err = fmt.Fprintf(w, "A\t%s\n", ch.To)
+		case gotfs.Change_Delete:
+			_, err = fmt.Fprintf(w, "D\t%s\n", ch.From)
+		case gotfs.Change_Modify:
+			_, err = fmt.Fprintf(w, "M\t%s\n", ch.To)
+		case gotfs.Change_Rename:
+			_, err = fmt.Fprintf(w, "R%03d\t%s\t%s\n", ch.Similarity, ch.From, ch.To)
+		case gotfs.Change_Copy:
+			_, err = fmt.Fprintf(w, "C%03d\t%s\t%s\n", ch.Similarity, ch.From, ch.To)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffSide is one side of a fileDiff.
+type diffSide struct {
+	// info is nil if there is no file on this side.
+	info *gotfs.Info
+	// lines is the content of the file.  The target of a symbolic link is its only line.
+	lines []string
+	// binary is true if the content is not text, or is too large to diff.
+	binary bool
+}
+
+// name is how the file is referred to in a diff header.
+func (s diffSide) name(prefix, p string) string {
+	if s.info == nil {
+		return "/dev/null"
+	}
+	return prefix + p
+}
+
+// loadDiffSide loads the file at p.  Directories, and missing paths, are loaded as no file.
+func loadDiffSide(ctx context.Context, fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root, p string, exists bool) (diffSide, error) {
+	if !exists {
+		return diffSide{}, nil
+	}
+	info, err := fsmach.GetInfo(ctx, ss.Metadata, root, p)
+	if err != nil {
+		return diffSide{}, err
+	}
+	switch {
+	case info.Mode.IsDir():
+		return diffSide{}, nil
+	case info.IsSymlink():
+		target, err := fsmach.Readlink(ctx, ss, root, p)
+		if err != nil {
+			return diffSide{}, err
+		}
+		return diffSide{info: info, lines: []string{target}}, nil
+	}
+	r, err := fsmach.NewReader(ctx, ss, root, p)
+	if err != nil {
+		return diffSide{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, linediff.MaxTextSize+1))
+	if err != nil {
+		return diffSide{}, err
+	}
+	if len(data) > linediff.MaxTextSize || linediff.IsBinary(data) {
+		return diffSide{info: info, binary: true}, nil
+	}
+	return diffSide{info: info, lines: linediff.Lines(data)}, nil
+}
+
+// fileDiff is a Change, along with the content of the files on each side.
+type fileDiff struct {
+	gotfs.Change
+	left, right diffSide
+	// edits is nil if either side is binary.
+	edits []linediff.Edit
+}
+
+// loadFileDiff loads the files on both sides of ch.
+// It returns nil if there is no file on either side, which is the case for directories.
+func loadFileDiff(ctx context.Context, fsmach *gotfs.Machine, ss gotfs.RO, left, right gotfs.Root, ch gotfs.Change) (*fileDiff, error) {
+	l, err := loadDiffSide(ctx, fsmach, ss, left, ch.From, ch.Type != gotfs.Change_Add)
+	if err != nil {
+		return nil, err
+	}
+	r, err := loadDiffSide(ctx, fsmach, ss, right, ch.To, ch.Type != gotfs.Change_Delete)
+	if err != nil {
+		return nil, err
+	}
+	if l.info == nil && r.info == nil {
+		return nil, nil
+	}
+	fd := &fileDiff{Change: ch, left: l, right: r}
+	if !l.binary && !r.binary {
+		fd.edits = linediff.Diff(l.lines, r.lines)
+	}
+	return fd, nil
+}
+
+func (fd *fileDiff) isBinary() bool {
+	return fd.left.binary || fd.right.binary
+}
+
+func (fd *fileDiff) leftPath() string {
+	if fd.From != "" {
+		return fd.From
+	}
+	return fd.To
+}
+
+func (fd *fileDiff) rightPath() string {
+	if fd.To != "" {
+		return fd.To
+	}
+	return fd.From
+}
+
+// writeUnified writes the header for fd, followed by the changed lines, with numContext lines of context.
+func (fd *fileDiff) writeUnified(w io.Writer, numContext int) error {
+	l, r := fd.left, fd.right
+	lp, rp := fd.leftPath(), fd.rightPath()
+	hdr := []string{fmt.Sprintf("diff --got a/%s b/%s", lp, rp)}
+	switch {
+	case l.info == nil:
+		hdr = append(hdr, fmt.Sprintf("new file mode %v", r.info.Mode))
+	case r.info == nil:
+		hdr = append(hdr, fmt.Sprintf("deleted file mode %v", l.info.Mode))
+	case l.info.Mode != r.info.Mode:
+		hdr = append(hdr, fmt.Sprintf("old mode %v", l.info.Mode), fmt.Sprintf("new mode %v", r.info.Mode))
+	}
+	switch fd.Type {
+	case gotfs.Change_Rename, gotfs.Change_Copy:
+		verb := "rename"
+		if fd.Type == gotfs.Change_Copy {
+			verb = "copy"
+		}
+		hdr = append(hdr,
+			fmt.Sprintf("similarity index %d%%", fd.Similarity),
+			fmt.Sprintf("%s from %s", verb, fd.From),
+			fmt.Sprintf("%s to %s", verb, fd.To),
+		)
+	}
+	var hunks []linediff.Hunk
+	if !fd.isBinary() {
+		hunks = linediff.Hunks(fd.edits, numContext)
+		if len(hunks) > 0 {
+			hdr = append(hdr, "--- "+l.name("a/", lp), "+++ "+r.name("b/", rp))
+		}
+	} else {
+		hdr = append(hdr, fmt.Sprintf("Binary files %s and %s differ", l.name("a/", lp), r.name("b/", rp)))
+	}
+	if len(hdr) == 1 && len(hunks) == 0 {
+		// the file was rewritten, but nothing which would be shown has changed.
+		return nil
+	}
+	for _, line := range hdr {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	for _, h := range hunks {
+		if err := linediff.WriteHunk(w, l.lines, r.lines, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffStat is the number of lines changed in a file.
+type diffStat struct {
+	name              string
+	binary            bool
+	deleted, inserted int
+}
+
+func (fd *fileDiff) stat() diffStat {
+	name := fd.rightPath()
+	if fd.Type == gotfs.Change_Rename || fd.Type == gotfs.Change_Copy {
+		name = fd.From + " => " + fd.To
+	}
+	st := diffStat{name: name, binary: fd.isBinary()}
+	st.deleted, st.inserted = linediff.Count(fd.edits)
+	return st
+}
+
+// writeDiffStat writes a line for each file, with the number of lines changed, and a bar of +'s and -'s.
+// It finishes with the totals.
+func writeDiffStat(w io.Writer, stats []diffStat) error {
+	var nameWidth, maxChanged, totalIns, totalDel int
+	for _, st := range stats {
+		nameWidth = max(nameWidth, len(st.name))
+		maxChanged = max(maxChanged, st.deleted+st.inserted)
+		totalIns += st.inserted
+		totalDel += st.deleted
+	}
+	countWidth := len(strconv.Itoa(maxChanged))
+	for _, st := range stats {
+		if st.binary {
+			if _, err := fmt.Fprintf(w, " %-*s | Bin\n", nameWidth, st.name); err != nil {
+				return err
+			}
+			continue
+		}
+		ins, del := st.inserted, st.deleted
+		if maxChanged > maxStatBarWidth {
+			// scale the bar down, but never hide a change completely.
+			ins = scaleStat(ins, maxChanged)
+			del = scaleStat(del, maxChanged)
+		}
+		bar := strings.Repeat("+", ins) + strings.Repeat("-", del)
+		line := fmt.Sprintf(" %-*s | %*d %s", nameWidth, st.name, countWidth, st.deleted+st.inserted, bar)
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, " %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n", len(stats), totalIns, totalDel)
+	return err
+}
+
+func scaleStat(n, maxChanged int) int {
+	if n == 0 {
+		return 0
+	}
+	return max(1, n*maxStatBarWidth/maxChanged)
+}
+
+// writeRawDiff writes the paths which were added and removed, and the ranges of the extents which changed in each file.
+// Renames and copies are listed first.
+func writeRawDiff(ctx context.Context, w io.Writer, repo *gotrepo.Repo, left, right gotcore.CommitExpr, opts gotfs.ChangeOptions) error {
+	changes, err := repo.Changes(ctx, left, right, opts)
+	if err != nil {
+		return err
+	}
+	// paths which are part of a rename or copy are not listed again below.
+	paired := make(map[string]bool)
+	for _, ch := range changes {
+		switch ch.Type {
+		case gotfs.Change_Rename:
+			paired[ch.From] = true
+			paired[ch.To] = true
+			fmt.Fprintf(w, "R %d%% %s -> %s\n", ch.Similarity, ch.From, ch.To)
+		case gotfs.Change_Copy:
+			paired[ch.To] = true
+			fmt.Fprintf(w, "C %d%% %s -> %s\n", ch.Similarity, ch.From, ch.To)
+		}
+	}
+	return repo.DiffFS(ctx, left, right, func(dfr *gotfs.Differ) error {
+		var currentPath string
+		return streams.ForEach(ctx, dfr, func(ent gotfs.DiffEntry) error {
+			if ent.Left.Ok && ent.Right.Ok {
+				return nil
+			}
+			if paired[ent.Key.Path()] {
+				return nil
+			}
+			if ent.Key.IsInfo() {
+				currentPath = ent.Key.Path()
+				var dir string
+				if ent.Left.Ok && !ent.Right.Ok {
+					dir = "-"
+				} else if !ent.Left.Ok && ent.Right.Ok {
+					dir = "+"
+				}
+				fmt.Fprintf(w, "%s %v\n", dir, currentPath)
+			} else {
+				if currentPath != ent.Key.Path() {
+					currentPath = ent.Key.Path()
+					fmt.Fprintf(w, "%s \n", currentPath)
+				}
+				var dir string
+				var ext gotfs.Extent
+				if ent.Left.Ok && !ent.Right.Ok {
+					dir = "+"
+					ext = ent.Left.X.Extent
+				} else if !ent.Left.Ok && ent.Right.Ok {
+					dir = "-"
+					ext = ent.Right.X.Extent
+				}
+				l := ext.Length
+				endAt := ent.Key.EndAt()
+				startAt := endAt - uint64(l)
+				fmt.Fprintf(w, "  %s [%v, %v) size=%vB \n", dir, startAt, endAt, l)
+			}
+			return nil
+		})
+	})
+}
@@ -25,6 +25,9 @@ var markCmd = star.NewDir(
 		"as":      markAsCmd,
 		"cp":      markCpCmd,
 		"mv":      markMvCmd,
+		"log":     markLogCmd,
+		"tag":     markTagCmd,
+		"verify":  markVerifyCmd,
 
 		"load":    markLoadCmd,
 		"sync":    markSyncCmd,
@@ -96,6 +99,9 @@ var markListCmd = star.Command{
 				target = ref.CID.String()[:8]
 			}
 			annots := len(info.Annotations)
+			if info.IsTag() {
+				k += " (tag)"
+			}
 			fmt.Fprintf(c.StdOut, "%s%-20s %-20s %-8x %-8s %-10d\n", isHead, k, createdAt, salt, target, annots)
 			return nil
 		})
@@ -114,6 +120,7 @@ var markDeleteCmd = star.Command{
 	},
 	Flags: map[string]star.Flag{
 		"space": spaceNameOptParam,
+		"force": deleteForceParam,
 	},
 	Pos: []star.Positional{markNameParam},
 	F: func(c star.Context) error {
@@ -125,7 +132,8 @@ var markDeleteCmd = star.Command{
 		defer close()
 		name := markNameParam.Load(c)
 		spaceName, _ := spaceNameOptParam.LoadOpt(c)
-		return repo.DeleteMark(ctx, gotrepo.FQM{Space: spaceName, Name: name})
+		force, _ := deleteForceParam.LoadOpt(c)
+		return repo.DeleteMark(ctx, gotrepo.FQM{Space: spaceName, Name: name}, force)
 	},
 }
 
@@ -135,6 +143,7 @@ var markDeletePrefixCmd = star.Command{
 	},
 	Flags: map[string]star.Flag{
 		"space": spaceNameOptParam,
+		"force": deleteForceParam,
 	},
 	Pos: []star.Positional{markNamePrefixParam},
 	F: func(c star.Context) error {
@@ -146,6 +155,7 @@ var markDeletePrefixCmd = star.Command{
 		defer close()
 		prefix := markNamePrefixParam.Load(c)
 		spaceName, _ := spaceNameOptParam.LoadOpt(c)
+		force, _ := deleteForceParam.LoadOpt(c)
 		toDelete := []string{}
 		if err := repo.ForEachMark(ctx, spaceName, func(name string) error {
 			if strings.HasPrefix(name, prefix) {
@@ -155,8 +165,20 @@ var markDeletePrefixCmd = star.Command{
 		}); err != nil {
 			return err
 		}
+		// check for tags first, so that nothing is deleted if any of the marks cannot be.
+		if !force {
+			for _, name := range toDelete {
+				info, err := repo.InspectMark(ctx, gotrepo.FQM{Space: spaceName, Name: name})
+				if err != nil {
+					return err
+				}
+				if info.IsTag() {
+					return fmt.Errorf("%q is a tag, use --force to delete it", name)
+				}
+			}
+		}
 		for _, name := range toDelete {
-			if err := repo.DeleteMark(ctx, gotrepo.FQM{Space: spaceName, Name: name}); err != nil {
+			if err := repo.DeleteMark(ctx, gotrepo.FQM{Space: spaceName, Name: name}, force); err != nil {
 				return err
 			}
 		}
@@ -302,7 +324,16 @@ var markAsCmd = star.Command{
 		if err != nil {
 			return err
 		}
+		actAs, err := wc.GetActAs()
+		if err != nil {
+			return err
+		}
 		repo := wc.Repo()
+		signer, err := repo.GetSigner(ctx, actAs)
+		if err != nil {
+			return err
+		}
+		ctx = gotcore.WithLogInfo(ctx, signer.ID(), "")
 		newfqn := gotrepo.FQM{Name: newMarkNameParam.Load(c)}
 		if err := repo.CloneMark(ctx,
 			gotrepo.FQM{Name: head},
@@ -314,6 +345,126 @@ var markAsCmd = star.Command{
 	},
 }
 
+var markLogCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "prints the changes to the target of a mark, most recent first",
+	},
+	Pos: []star.Positional{fqmParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		repo, close, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		defer close()
+		fqm := fqmParam.Load(c)
+		ents, err := repo.MarkLog(ctx, fqm)
+		if err != nil {
+			return err
+		}
+		for i, ent := range ents {
+			op := ent.Op
+			if op == "" {
+				op = "(unknown)"
+			}
+			actor := "(unknown)"
+			if !ent.Actor.IsZero() {
+				actor = ent.Actor.String()
+			}
+			at := ent.At.GoTime().Local().Format(time.DateTime)
+			c.Printf("%s@{%d}\t%s -> %s\t%s\t%s\t%s\n", fqm.Name, i+1, shortRef(ent.Prev), shortRef(ent.Next), at, op, actor)
+		}
+		return nil
+	},
+}
+
+// shortRef returns a short form of ref's CID for display, or "(empty)" for the zero Ref.
+func shortRef(ref gotrepo.Ref) string {
+	if ref.IsZero() {
+		return "(empty)"
+	}
+	return ref.CID.String()[:8]
+}
+
+var markTagCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "creates an immutable tag pointed at a commit, the current mark's target by default",
+	},
+	Flags: map[string]star.Flag{
+		"comm":    commExprOptParam,
+		"message": tagMessageParam,
+		"sign":    tagSignParam,
+	},
+	Pos: []star.Positional{fqmParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		repo := wc.Repo()
+		fqm := fqmParam.Load(c)
+		se, ok := commExprOptParam.LoadOpt(c)
+		if !ok {
+			head, err := wc.GetSaveTo()
+			if err != nil {
+				return err
+			}
+			se = &gotcore.CommitExpr_Mark{Name: head}
+		}
+		msg, _ := tagMessageParam.LoadOpt(c)
+		var signer *gotrepo.Signer
+		if yes, _ := tagSignParam.LoadOpt(c); yes {
+			actAs, err := wc.GetActAs()
+			if err != nil {
+				return err
+			}
+			if signer, err = repo.GetSigner(ctx, actAs); err != nil {
+				return err
+			}
+		}
+		if _, err := repo.CreateTag(ctx, fqm, se, msg, signer); err != nil {
+			return err
+		}
+		c.Printf("tagged commit as %v\n", fqm.Name)
+		return nil
+	},
+}
+
+var markVerifyCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "checks the signature on a tag",
+	},
+	Pos: []star.Positional{fqmParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		repo, close, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		defer close()
+		fqm := fqmParam.Load(c)
+		if err := repo.VerifyTag(ctx, fqm); err != nil {
+			return err
+		}
+		c.Printf("tag %v has a good signature\n", fqm.Name)
+		return nil
+	},
+}
+
+var tagMessageParam = &star.Optional[string]{
+	PosName:  "message",
+	Parse:    star.ParseString,
+	ShortDoc: "a message to store with the tag",
+}
+
+var tagSignParam = &star.Optional[bool]{
+	PosName:  "sign",
+	ShortDoc: "sign the tag with the identity the working copy is acting as",
+	Parse:    parseBoolFlag,
+}
+
 var fqmParam = &star.Required[gotrepo.FQM]{
 	PosName: "fqm",
 	Parse: func(s string) (gotrepo.FQM, error) {
@@ -398,12 +549,13 @@ var newMarkNameParam = &star.Required[string]{
 
 var forceParam = &star.Optional[bool]{
 	PosName: "force",
-	Parse: func(s string) (bool, error) {
-		if s == "" || s == "true" {
-			return true, nil
-		}
-		return false, nil
-	},
+	Parse:   parseBoolFlag,
+}
+
+var deleteForceParam = &star.Optional[bool]{
+	PosName:  "force",
+	ShortDoc: "also delete tags",
+	Parse:    parseBoolFlag,
 }
 
 func prettyPrintJSON(w io.Writer, x any) error {
@@ -0,0 +1,186 @@
+package gotcmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gotvc/got/src/gotwc"
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/metrics"
+	"go.brendoncarroll.net/star"
+	"go.brendoncarroll.net/tai64"
+)
+
+var mergeCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "merges a commit into the HEAD mark, creating a merge commit if the histories have diverged",
+	},
+	Pos: []star.Positional{commExprParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		r := metrics.NewTTYRenderer(metrics.FromContext(ctx), c.StdIn, c.StdOut)
+		defer r.Close()
+		now := tai64.Now().TAI64()
+		err = wc.Merge(ctx, commExprParam.Load(c), gotwc.CommitParams{
+			AuthoredAt: now,
+		})
+		var mc gotcore.ErrMergeConflict
+		if errors.As(err, &mc) {
+			for _, p := range mc.Paths {
+				c.Printf("CONFLICT %s\n", p)
+			}
+			c.Printf("resolve the conflicts with add or rm, and then commit to finish the merge\n")
+		}
+		return err
+	},
+}
+
+var mergeBaseCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "prints the best common ancestors of 2 commits",
+	},
+	Pos: []star.Positional{mergeBaseLeftParam, mergeBaseRightParam},
+	F: func(c star.Context) error {
+		repo, closer, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		refs, err := repo.MergeBase(c, mergeBaseLeftParam.Load(c), mergeBaseRightParam.Load(c))
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			return fmt.Errorf("commits do not have a common ancestor")
+		}
+		for _, ref := range refs {
+			c.Printf("%v\n", ref)
+		}
+		return nil
+	},
+}
+
+var mergeBaseLeftParam = &star.Required[gotcore.CommitExpr]{
+	PosName:  "a",
+	ShortDoc: "the first commit",
+	Parse:    gotcore.ParseCommitExpr,
+}
+
+var mergeBaseRightParam = &star.Required[gotcore.CommitExpr]{
+	PosName:  "b",
+	ShortDoc: "the second commit",
+	Parse:    gotcore.ParseCommitExpr,
+}
+
+var cherryPickCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "applies the change made by a commit to the HEAD mark, as a new commit",
+	},
+	Pos: []star.Positional{commExprParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		r := metrics.NewTTYRenderer(metrics.FromContext(ctx), c.StdIn, c.StdOut)
+		defer r.Close()
+		return wc.CherryPick(ctx, commExprParam.Load(c))
+	},
+}
+
+var revertCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "creates a commit on the HEAD mark which undoes the change made by a commit",
+	},
+	Pos: []star.Positional{commExprParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		r := metrics.NewTTYRenderer(metrics.FromContext(ctx), c.StdIn, c.StdOut)
+		defer r.Close()
+		return wc.Revert(ctx, commExprParam.Load(c))
+	},
+}
+
+var rebaseCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "replays the commits on the HEAD mark, which are not in the history of <onto>, on top of <onto>",
+	},
+	Pos: []star.Positional{rebaseOntoParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		r := metrics.NewTTYRenderer(metrics.FromContext(ctx), c.StdIn, c.StdOut)
+		defer r.Close()
+		return wc.Rebase(ctx, rebaseOntoParam.Load(c))
+	},
+}
+
+var rebaseOntoParam = &star.Required[gotcore.CommitExpr]{
+	PosName:  "onto",
+	ShortDoc: "the commit to replay the HEAD mark's commits on top of",
+	Parse:    gotcore.ParseCommitExpr,
+}
+
+var squashCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "collapses the last <n> commits on the HEAD mark into one, or all of the commits after --until",
+	},
+	Pos: []star.Positional{squashNParam},
+	Flags: map[string]star.Flag{
+		"until": squashUntilParam,
+	},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		n, nOk := squashNParam.LoadOpt(c)
+		until, untilOk := squashUntilParam.LoadOpt(c)
+		if nOk == untilOk {
+			return fmt.Errorf("must provide exactly one of <n> or --until")
+		}
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		r := metrics.NewTTYRenderer(metrics.FromContext(ctx), c.StdIn, c.StdOut)
+		defer r.Close()
+		if untilOk {
+			return wc.SquashUntil(ctx, until)
+		}
+		return wc.Squash(ctx, n)
+	},
+}
+
+var squashNParam = &star.Optional[int]{
+	PosName:  "n",
+	ShortDoc: "the number of commits to squash",
+	Parse: func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of commits: %q", s)
+		}
+		return n, nil
+	},
+}
+
+var squashUntilParam = &star.Optional[gotcore.CommitExpr]{
+	PosName:  "until",
+	ShortDoc: "the commit to squash down to, it is not included in the squash",
+	Parse:    gotcore.ParseCommitExpr,
+}
@@ -48,12 +48,18 @@ var rootCmd = star.NewGroupedDir(
 			"iden",
 			"config",
 			"merge",
+			"merge-base",
+			"cherry-pick",
+			"revert",
+			"rebase",
+			"squash",
 		}},
 		{Title: "WORKING COPY & STAGING", Commands: []string{
 			"wc",
 			"status",
 			"add",
 			"rm",
+			"mv",
 			"put",
 			"discard",
 			"clear",
@@ -61,11 +67,13 @@ var rootCmd = star.NewGroupedDir(
 			"head",
 			"fork",
 			"checkout",
+			"bisect",
 		}},
 		{Title: "BOOKMARKS", Commands: []string{
 			"mark",
 			"history",
 			"cat",
+			"blame",
 			"ls",
 			"diff",
 		}},
@@ -77,6 +85,7 @@ var rootCmd = star.NewGroupedDir(
 		{Title: "ADAPTERS", Commands: []string{
 			"http",
 			"ftp",
+			"dav",
 		}},
 		{Title: "MISCELLANEOUS", Commands: []string{
 			"config",
@@ -92,22 +101,34 @@ var rootCmd = star.NewGroupedDir(
 		// staging area commands
 		"add":     addCmd,
 		"rm":      rmCmd,
+		"mv":      mvCmd,
 		"put":     putCmd,
 		"discard": discardCmd,
 		"clear":   clearCmd,
 		"commit":  commitCmd,
 
+		// merging
+		"merge":       mergeCmd,
+		"merge-base":  mergeBaseCmd,
+		"cherry-pick": cherryPickCmd,
+		"revert":      revertCmd,
+		"rebase":      rebaseCmd,
+		"squash":      squashCmd,
+
 		// other working copy methods
 		"wc":       wcCmd,
 		"head":     headCmd,
 		"fork":     forkCmd,
 		"checkout": checkoutCmd,
+		"bisect":   bisectCmd,
 
-		"ls":   lsCmd,
-		"cat":  catCmd,
-		"diff": diffCmd,
-		"http": httpCmd,
-		"ftp":  ftpCmd,
+		"ls":    lsCmd,
+		"cat":   catCmd,
+		"blame": blameCmd,
+		"diff":  diffCmd,
+		"http":  httpCmd,
+		"ftp":   ftpCmd,
+		"dav":   davCmd,
 
 		// marks
 		"mark":    markCmd,
@@ -167,6 +188,15 @@ func openRepo(ctx context.Context) (*gotrepo.Repo, func() error, error) {
 	return wc.Repo(), wc.Close, nil
 }
 
+// parseBoolFlag parses the value of a boolean flag.
+// A flag given without a value is true.
+func parseBoolFlag(s string) (bool, error) {
+	if s == "" || s == "true" {
+		return true, nil
+	}
+	return false, nil
+}
+
 func openWC() (*gotwc.WC, error) {
 	r, err := os.OpenRoot(".")
 	if err != nil {
@@ -3,8 +3,10 @@ package gotcmd
 import (
 	"bufio"
 	"fmt"
+	"path"
 
 	"github.com/fatih/color"
+	"github.com/gotvc/got/src/gotfs"
 	"github.com/gotvc/got/src/gotwc"
 	"github.com/gotvc/got/src/internal/metrics"
 	"go.brendoncarroll.net/star"
@@ -17,6 +19,7 @@ var wcCmd = star.NewDir(star.Metadata{
 		"cleanup":  cleanupCmd,
 		"add":      addCmd,
 		"rm":       rmCmd,
+		"mv":       mvCmd,
 		"discard":  discardCmd,
 		"clear":    clearCmd,
 		"head":     headCmd,
@@ -48,20 +51,87 @@ var statusCmd = star.Command{
 		if _, err := fmt.Fprintf(bufw, "STAGED:\n"); err != nil {
 			return err
 		}
+		renames, err := wc.StagedRenames(ctx, gotfs.ChangeOptions{})
+		if err != nil {
+			return err
+		}
+		type stagedOp struct {
+			p  string
+			op gotwc.FileOperation
+		}
+		var staged []stagedOp
 		if err := wc.ForEachStaging(ctx, func(p string, op gotwc.FileOperation) error {
+			staged = append(staged, stagedOp{p: p, op: op})
+			return nil
+		}); err != nil {
+			return err
+		}
+		// renamed files are listed under their new path, instead of as a DELETE and a CREATE.
+		// The files in a directory which was put are listed beneath the directory.
+		renamedFrom := make(map[string]bool)
+		renamesUnder := make(map[string][]gotfs.Change)
+		putPaths := make(map[string]bool)
+		for _, s := range staged {
+			if s.op.Create != nil || s.op.Modify != nil {
+				putPaths[s.p] = true
+			}
+		}
+		for _, ch := range renames {
+			if ch.Type != gotfs.Change_Rename {
+				continue
+			}
+			renamedFrom[ch.From] = true
+			for p := ch.To; ; p = path.Dir(p) {
+				if p == "." {
+					p = ""
+				}
+				if putPaths[p] {
+					renamesUnder[p] = append(renamesUnder[p], ch)
+					break
+				}
+				if p == "" {
+					break
+				}
+			}
+		}
+		for _, s := range staged {
+			p, op := s.p, s.op
+			var renamed *gotfs.Change
+			var nested []gotfs.Change
+			for i, ch := range renamesUnder[p] {
+				if ch.To == p {
+					renamed = &renamesUnder[p][i]
+				} else {
+					nested = append(nested, ch)
+				}
+			}
 			var desc = "UNKNOWN"
 			switch {
+			case op.Delete != nil && (renamedFrom[p] || op.Delete.MovedTo != ""):
+				continue
+			case op.Move != nil:
+				desc = color.CyanString("MOVE")
+				p = fmt.Sprintf("%s -> %s", op.Move.From, p)
+			case op.Create != nil && renamed != nil:
+				desc = color.CyanString("RENAME")
+				p = fmt.Sprintf("%s -> %s (%d%%)", renamed.From, p, renamed.Similarity)
 			case op.Delete != nil:
 				desc = color.RedString("DELETE")
 			case op.Create != nil:
 				desc = color.BlueString("CREATE")
 			case op.Modify != nil:
 				desc = color.GreenString("MODIFY")
+			case op.Conflict != nil:
+				desc = color.MagentaString("CONFLICT")
+			}
+			if _, err := fmt.Fprintf(bufw, "  %7s %s\n", desc, p); err != nil {
+				return err
+			}
+			for _, ch := range nested {
+				if _, err := fmt.Fprintf(bufw, "    %7s %s -> %s (%d%%)\n", color.CyanString("RENAME"), ch.From, ch.To, ch.Similarity); err != nil {
+					return err
+				}
 			}
-			_, err := fmt.Fprintf(bufw, "  %7s %s\n", desc, p)
-			return err
-		}); err != nil {
-			return err
 		}
 		if _, err := fmt.Fprintf(bufw, "DIRTY:\n"); err != nil {
 			return err
@@ -56,6 +56,22 @@ var rmCmd = star.Command{
 	},
 }
 
+var mvCmd = star.Command{
+	Metadata: star.Metadata{
+		Short: "moves a path, in the working directory and the staging area",
+	},
+	Pos: []star.Positional{mvFromParam, mvToParam},
+	F: func(c star.Context) error {
+		ctx := c.Context
+		wc, err := openWC()
+		if err != nil {
+			return err
+		}
+		defer wc.Close()
+		return wc.Mv(ctx, mvFromParam.Load(c), mvToParam.Load(c))
+	},
+}
+
 var putCmd = star.Command{
 	Metadata: star.Metadata{
 		Short: "stages paths for replacement",
@@ -141,6 +157,18 @@ var pathsParam = &star.Repeated[string]{
 	Parse:    star.ParseString,
 }
 
+var mvFromParam = &star.Required[string]{
+	PosName:  "from",
+	ShortDoc: "the path to move",
+	Parse:    star.ParseString,
+}
+
+var mvToParam = &star.Required[string]{
+	PosName:  "to",
+	ShortDoc: "the path to move to",
+	Parse:    star.ParseString,
+}
+
 func pipeToLess(r io.Reader) error {
 	cmd := exec.Command("/usr/bin/less")
 	cmd.Stdin = r
@@ -0,0 +1,217 @@
+package gotdag
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+
+	"blobcache.io/blobcache/src/bcsdk"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/sbe"
+	"github.com/gotvc/got/src/internal/stores"
+)
+
+// IndexEntry is what a commit graph index stores for each Vertex.
+// It holds everything needed to answer ancestry queries, without reading the Vertex.
+type IndexEntry struct {
+	// N is the generation number of the Vertex. See Vertex.N
+	N       uint64
+	Parents []Ref
+}
+
+func (e IndexEntry) Marshal(out []byte) []byte {
+	out = sbe.AppendUint64(out, e.N)
+	out = sbe.AppendUint16(out, uint16(len(e.Parents)))
+	for _, parent := range e.Parents {
+		out = gdat.AppendRef(out, parent)
+	}
+	return out
+}
+
+func (e *IndexEntry) Unmarshal(data []byte) error {
+	n, data, err := sbe.ReadUint64(data)
+	if err != nil {
+		return err
+	}
+	numParents, data, err := sbe.ReadUint16(data)
+	if err != nil {
+		return err
+	}
+	if len(data) != int(numParents)*gdat.RefSize {
+		return fmt.Errorf("gotdag: index entry has wrong size for %d parents", numParents)
+	}
+	e.N = n
+	e.Parents = make([]Ref, numParents)
+	for i := range e.Parents {
+		ref, err := gdat.ParseRef(data[i*gdat.RefSize : (i+1)*gdat.RefSize])
+		if err != nil {
+			return err
+		}
+		e.Parents[i] = ref
+	}
+	return nil
+}
+
+// AddToIndex returns a version of the commit graph index idx, which contains the Vertex at ref and all of its ancestors.
+// The index is a gotkv instance, mapping the CID of each Vertex to its IndexEntry.
+// idx can be the zero value, in which case a new index is created.
+// Only the Vertices which are not already in idx are read.
+func (m *Machine[T]) AddToIndex(ctx context.Context, s stores.RW, idx gotkv.Root, ref Ref) (gotkv.Root, error) {
+	if idx.Ref.IsZero() {
+		var err error
+		if idx, err = m.kv.NewEmpty(ctx, s); err != nil {
+			return gotkv.Root{}, err
+		}
+	}
+	added := map[Ref]IndexEntry{}
+	stack := []Ref{ref}
+	for len(stack) > 0 {
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, exists := added[x]; exists {
+			continue
+		}
+		if ent, err := m.GetIndexEntry(ctx, s, idx, x); err != nil {
+			return gotkv.Root{}, err
+		} else if ent != nil {
+			continue
+		}
+		vert, err := m.GetVertex(ctx, s, x)
+		if err != nil {
+			return gotkv.Root{}, err
+		}
+		added[x] = IndexEntry{N: vert.N, Parents: vert.Parents}
+		stack = append(stack, vert.Parents...)
+	}
+	if len(added) == 0 {
+		return idx, nil
+	}
+	edits := make([]gotkv.Edit, 0, len(added))
+	for x, ent := range added {
+		key := x.CID[:]
+		edits = append(edits, gotkv.Edit{
+			Span:    gotkv.SingleKeySpan(key),
+			Entries: []gotkv.Entry{{Key: key, Value: ent.Marshal(nil)}},
+		})
+	}
+	slices.SortFunc(edits, func(a, b gotkv.Edit) int {
+		return bytes.Compare(a.Span.Begin, b.Span.Begin)
+	})
+	return m.kv.Edit(ctx, s, idx, edits...)
+}
+
+// GetIndexEntry returns the entry for the Vertex at ref in the commit graph index idx.
+// If idx does not contain the Vertex, then (nil, nil) is returned.
+func (m *Machine[T]) GetIndexEntry(ctx context.Context, s stores.RO, idx gotkv.Root, ref Ref) (*IndexEntry, error) {
+	if idx.Ref.IsZero() {
+		return nil, nil
+	}
+	var ent IndexEntry
+	if err := m.kv.GetF(ctx, s, idx, ref.CID[:], ent.Unmarshal); err != nil {
+		if gotkv.IsErrKeyNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ent, nil
+}
+
+// lookup returns the IndexEntry for the Vertex at ref.
+// The entry is read from idx if it is there, otherwise the Vertex is read.
+func (m *Machine[T]) lookup(ctx context.Context, s stores.RO, idx gotkv.Root, ref Ref) (IndexEntry, error) {
+	if ent, err := m.GetIndexEntry(ctx, s, idx, ref); err != nil {
+		return IndexEntry{}, err
+	} else if ent != nil {
+		return *ent, nil
+	}
+	vert, err := m.GetVertex(ctx, s, ref)
+	if err != nil {
+		return IndexEntry{}, err
+	}
+	return IndexEntry{N: vert.N, Parents: vert.Parents}, nil
+}
+
+// IsAncestor returns true if the Vertex at a is a strict ancestor of the Vertex at x.
+// The commit graph index idx is used where possible, and can be the zero value.
+// If a is not in s, then it cannot be an ancestor of x, and false is returned.
+//
+// The walk does not descend past Vertices with an N less than or equal to a's N,
+// since none of them can lead to a.
+func (m *Machine[T]) IsAncestor(ctx context.Context, s stores.RO, idx gotkv.Root, a, x Ref) (bool, error) {
+	if exists, err := bcsdk.ExistsUnit(ctx, s, a.CID); err != nil {
+		return false, err
+	} else if !exists {
+		return false, nil
+	}
+	aEnt, err := m.lookup(ctx, s, idx, a)
+	if err != nil {
+		return false, err
+	}
+	xEnt, err := m.lookup(ctx, s, idx, x)
+	if err != nil {
+		return false, err
+	}
+	visited := map[Ref]struct{}{}
+	stack := slices.Clone(xEnt.Parents)
+	for len(stack) > 0 {
+		ref := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if ref.Equals(&a) {
+			return true, nil
+		}
+		if _, exists := visited[ref]; exists {
+			continue
+		}
+		visited[ref] = struct{}{}
+		ent, err := m.lookup(ctx, s, idx, ref)
+		if err != nil {
+			return false, err
+		}
+		if ent.N <= aEnt.N {
+			continue
+		}
+		stack = append(stack, ent.Parents...)
+	}
+	return false, nil
+}
+
+// WalkIndex calls fn once for each Vertex reachable from xs, including xs themselves, with its IndexEntry.
+// Vertices are visited in descending order of N, so every Vertex is visited before its parents.
+// Only the Vertices which are not in the commit graph index idx are read, and idx can be the zero value.
+func (m *Machine[T]) WalkIndex(ctx context.Context, s stores.RO, idx gotkv.Root, xs []Ref, fn func(Ref, IndexEntry) error) error {
+	var queue walkQueue
+	queued := map[Ref]struct{}{}
+	push := func(ref Ref) error {
+		if _, exists := queued[ref]; exists {
+			return nil
+		}
+		ent, err := m.lookup(ctx, s, idx, ref)
+		if err != nil {
+			return err
+		}
+		heap.Push(&queue, &walkItem{ref: ref, n: ent.N, parents: ent.Parents})
+		queued[ref] = struct{}{}
+		return nil
+	}
+	for _, x := range xs {
+		if err := push(x); err != nil {
+			return err
+		}
+	}
+	for queue.Len() > 0 {
+		x := heap.Pop(&queue).(*walkItem)
+		if err := fn(x.ref, IndexEntry{N: x.n, Parents: x.parents}); err != nil {
+			return err
+		}
+		for _, parentRef := range x.parents {
+			if err := push(parentRef); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
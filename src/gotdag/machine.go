@@ -1,10 +1,12 @@
 package gotdag
 
 import (
+	"container/heap"
 	"container/list"
 	"context"
 
 	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotkv"
 	"github.com/gotvc/got/src/internal/stores"
 )
 
@@ -21,6 +23,8 @@ type Machine[T Marshalable] struct {
 	cfg      Params[T]
 	readOnly bool
 	da       *gdat.Machine
+	// kv is used for the commit graph index
+	kv gotkv.Machine
 }
 
 func NewMachine[T Marshalable](p Params[T]) Machine[T] {
@@ -33,6 +37,11 @@ func NewMachine[T Marshalable](p Params[T]) Machine[T] {
 		cfg:   p,
 	}
 	m.da = gdat.NewMachine(p.Data)
+	m.kv = gotkv.NewMachine(gotkv.Params{
+		Salt:     p.Data.Salt,
+		MeanSize: 1 << 13,
+		MaxSize:  1 << 18,
+	})
 	return m
 }
 
@@ -60,6 +69,48 @@ func (m *Machine[T]) ForEach(ctx context.Context, s stores.RO, xs []Ref, fn func
 	return nil
 }
 
+// ForEachByN is like ForEach, except that Vertices are visited in descending order of N,
+// so every Vertex is visited before its parents.
+// Each Vertex is read once.
+func (m *Machine[T]) ForEachByN(ctx context.Context, s stores.RO, xs []Ref, fn func(Ref, Vertex[T]) error) error {
+	var queue walkQueue
+	queued := map[Ref]struct{}{}
+	// pending holds the Vertices which have been read, but not yet visited.
+	pending := map[Ref]Vertex[T]{}
+	push := func(ref Ref) error {
+		if _, exists := queued[ref]; exists {
+			return nil
+		}
+		vert, err := m.GetVertex(ctx, s, ref)
+		if err != nil {
+			return err
+		}
+		heap.Push(&queue, &walkItem{ref: ref, n: vert.N, parents: vert.Parents})
+		queued[ref] = struct{}{}
+		pending[ref] = vert
+		return nil
+	}
+	for _, x := range xs {
+		if err := push(x); err != nil {
+			return err
+		}
+	}
+	for queue.Len() > 0 {
+		x := heap.Pop(&queue).(*walkItem)
+		vert := pending[x.ref]
+		delete(pending, x.ref)
+		if err := fn(x.ref, vert); err != nil {
+			return err
+		}
+		for _, parentRef := range x.parents {
+			if err := push(parentRef); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type refQueue struct {
 	list *list.List
 }
@@ -0,0 +1,189 @@
+package gotdag
+
+import (
+	"container/heap"
+	"context"
+
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+)
+
+// MergeBase returns the best common ancestors of the vertices at a and b.
+// A common ancestor is best if it is not an ancestor of any other common ancestor.
+// A vertex is considered an ancestor of itself, so if a is an ancestor of b, then a is returned.
+//
+// The walk visits vertices in descending order of N, and stops as soon as every vertex
+// left to visit is already known to be beneath a common ancestor.
+// The returned refs are sorted by descending N.
+// If a and b do not share any history then no refs are returned.
+//
+// Parents and generation numbers are read from the commit graph index idx, which can be the zero value.
+// Only the Vertices which are not in idx are read.
+func (mach *Machine[T]) MergeBase(ctx context.Context, s stores.RO, idx gotkv.Root, a, b Ref) ([]Ref, error) {
+	if a.Equals(&b) {
+		return []Ref{a}, nil
+	}
+	w := newWalker(mach, s, idx)
+	if err := w.push(ctx, a, flagA); err != nil {
+		return nil, err
+	}
+	if err := w.push(ctx, b, flagB); err != nil {
+		return nil, err
+	}
+	var candidates []*walkItem
+	for w.live > 0 {
+		x := w.pop()
+		flags := w.flags[x.ref]
+		if flags == flagA|flagB {
+			candidates = append(candidates, x)
+			flags |= flagStale
+			w.flags[x.ref] = flags
+		}
+		for _, parentRef := range x.parents {
+			if w.flags[parentRef]&flags == flags {
+				continue
+			}
+			if err := w.push(ctx, parentRef, flags); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return mach.removeRedundant(ctx, s, idx, candidates)
+}
+
+// removeRedundant removes any of xs which are ancestors of another of xs.
+func (mach *Machine[T]) removeRedundant(ctx context.Context, s stores.RO, idx gotkv.Root, xs []*walkItem) ([]Ref, error) {
+	var ret []Ref
+	for i, x := range xs {
+		var others []Ref
+		for j, y := range xs {
+			if i != j && y.n > x.n {
+				others = append(others, y.ref)
+			}
+		}
+		yes, err := mach.reaches(ctx, s, idx, others, x.ref, x.n)
+		if err != nil {
+			return nil, err
+		}
+		if !yes {
+			ret = append(ret, x.ref)
+		}
+	}
+	return ret, nil
+}
+
+// reaches returns true if target is reachable from any of srcs.
+// Vertices with N below minN are not visited, since they cannot lead to the target.
+func (mach *Machine[T]) reaches(ctx context.Context, s stores.RO, idx gotkv.Root, srcs []Ref, target Ref, minN uint64) (bool, error) {
+	visited := map[Ref]struct{}{}
+	refs := newRefQueue()
+	refs.push(srcs...)
+	for refs.len() > 0 {
+		ref := refs.pop()
+		if ref.Equals(&target) {
+			return true, nil
+		}
+		if _, exists := visited[ref]; exists {
+			continue
+		}
+		visited[ref] = struct{}{}
+		ent, err := mach.lookup(ctx, s, idx, ref)
+		if err != nil {
+			return false, err
+		}
+		if ent.N <= minN {
+			continue
+		}
+		refs.push(ent.Parents...)
+	}
+	return false, nil
+}
+
+const (
+	flagA = 1 << iota
+	flagB
+	flagStale
+)
+
+type walkItem struct {
+	ref     Ref
+	n       uint64
+	parents []Ref
+}
+
+// walker visits vertices in descending order of N.
+type walker[T Marshalable] struct {
+	mach   *Machine[T]
+	s      stores.RO
+	idx    gotkv.Root
+	flags  map[Ref]uint8
+	queued map[Ref]struct{}
+	queue  walkQueue
+	// live is the number of queued vertices which are not stale.
+	live int
+}
+
+func newWalker[T Marshalable](mach *Machine[T], s stores.RO, idx gotkv.Root) *walker[T] {
+	return &walker[T]{
+		mach:   mach,
+		s:      s,
+		idx:    idx,
+		flags:  make(map[Ref]uint8),
+		queued: make(map[Ref]struct{}),
+	}
+}
+
+// push adds flags to the vertex at ref, and queues it to be visited.
+func (w *walker[T]) push(ctx context.Context, ref Ref, flags uint8) error {
+	prev := w.flags[ref]
+	w.flags[ref] = prev | flags
+	if _, exists := w.queued[ref]; exists {
+		if prev&flagStale == 0 && flags&flagStale != 0 {
+			w.live--
+		}
+		return nil
+	}
+	ent, err := w.mach.lookup(ctx, w.s, w.idx, ref)
+	if err != nil {
+		return err
+	}
+	heap.Push(&w.queue, &walkItem{ref: ref, n: ent.N, parents: ent.Parents})
+	w.queued[ref] = struct{}{}
+	if w.flags[ref]&flagStale == 0 {
+		w.live++
+	}
+	return nil
+}
+
+// pop removes the vertex with the largest N from the queue.
+func (w *walker[T]) pop() *walkItem {
+	x := heap.Pop(&w.queue).(*walkItem)
+	delete(w.queued, x.ref)
+	if w.flags[x.ref]&flagStale == 0 {
+		w.live--
+	}
+	return x
+}
+
+// walkQueue is a max-heap of walkItems, ordered by N.
+type walkQueue []*walkItem
+
+func (q walkQueue) Len() int { return len(q) }
+
+func (q walkQueue) Less(i, j int) bool {
+	if q[i].n != q[j].n {
+		return q[i].n > q[j].n
+	}
+	return q[i].ref.CID.Compare(q[j].ref.CID) < 0
+}
+
+func (q walkQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *walkQueue) Push(x any) { *q = append(*q, x.(*walkItem)) }
+
+func (q *walkQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -33,6 +33,10 @@ type Vertex[T Marshalable] struct {
 
 	// Payload is the thing being committed.
 	Payload T
+
+	// Sig is a signature of SigData by the Creator.
+	// It is empty if the Vertex is not signed.
+	Sig []byte
 }
 
 func ParseVertex[T Marshalable](data []byte, parser Parser[T]) (Vertex[T], error) {
@@ -44,6 +48,17 @@ func ParseVertex[T Marshalable](data []byte, parser Parser[T]) (Vertex[T], error
 }
 
 func (a Vertex[T]) Marshal(out []byte) []byte {
+	out = a.SigData(out)
+	// the signature is optional, and comes last, so Vertices without one are unchanged.
+	if len(a.Sig) > 0 {
+		out = sbe.AppendLP(out, a.Sig)
+	}
+	return out
+}
+
+// SigData appends the part of the Vertex covered by the signature to out.
+// That is everything except the signature itself.
+func (a Vertex[T]) SigData(out []byte) []byte {
 	out = sbe.AppendUint64(out, a.N)
 	out = append(out, a.CreatedAt.Marshal()...)
 
@@ -106,7 +121,7 @@ func (a *Vertex[T]) Unmarshal(data []byte, parsePayload Parser[T]) error {
 	a.Creator = inet256.ID(creatorData)
 
 	// payload
-	payloadData, _, err := sbe.ReadLP(data)
+	payloadData, data, err := sbe.ReadLP(data)
 	if err != nil {
 		return err
 	}
@@ -116,6 +131,15 @@ func (a *Vertex[T]) Unmarshal(data []byte, parsePayload Parser[T]) error {
 	}
 	a.Payload = payload
 
+	// signature
+	a.Sig = nil
+	if len(data) > 0 {
+		sig, _, err := sbe.ReadLP(data)
+		if err != nil {
+			return err
+		}
+		a.Sig = bytes.Clone(sig)
+	}
 	return nil
 }
 
@@ -0,0 +1,88 @@
+package gotfs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.brendoncarroll.net/tai64"
+)
+
+// Keys for Info.Attrs which hold metadata from POSIX filesystems.
+// Attrs is otherwise free form, these are only set when metadata is imported on purpose.
+const (
+	// AttrModTime is the modification time, as a TAI64N timestamp.
+	AttrModTime = "posix.mtime"
+	// AttrUID is the user id of the owner, as a big endian uint32.
+	AttrUID = "posix.uid"
+	// AttrGID is the group id of the owner, as a big endian uint32.
+	AttrGID = "posix.gid"
+	// AttrXattrPrefix is prepended to the name of each extended attribute.
+	AttrXattrPrefix = "xattr."
+)
+
+// ModTime returns the modification time stored in Attrs.
+func (info *Info) ModTime() (time.Time, bool, error) {
+	data, ok := info.Attrs[AttrModTime]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	t, err := tai64.ParseN(data)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parsing %s: %w", AttrModTime, err)
+	}
+	return t.GoTime(), true, nil
+}
+
+// SetModTime stores the modification time t in Attrs.
+func (info *Info) SetModTime(t time.Time) {
+	info.setAttr(AttrModTime, tai64.FromGoTime(t).Marshal())
+}
+
+// Owner returns the user and group ids stored in Attrs.
+func (info *Info) Owner() (uid, gid uint32, ok bool, err error) {
+	uidData, ok1 := info.Attrs[AttrUID]
+	gidData, ok2 := info.Attrs[AttrGID]
+	if !ok1 || !ok2 {
+		return 0, 0, false, nil
+	}
+	if len(uidData) != 4 || len(gidData) != 4 {
+		return 0, 0, false, fmt.Errorf("owner attrs have wrong length")
+	}
+	return binary.BigEndian.Uint32(uidData), binary.BigEndian.Uint32(gidData), true, nil
+}
+
+// SetOwner stores the user and group ids in Attrs.
+func (info *Info) SetOwner(uid, gid uint32) {
+	info.setAttr(AttrUID, binary.BigEndian.AppendUint32(nil, uid))
+	info.setAttr(AttrGID, binary.BigEndian.AppendUint32(nil, gid))
+}
+
+// Xattrs returns the extended attributes stored in Attrs, keyed by their names.
+func (info *Info) Xattrs() map[string][]byte {
+	var ret map[string][]byte
+	for k, v := range info.Attrs {
+		name, ok := strings.CutPrefix(k, AttrXattrPrefix)
+		if !ok {
+			continue
+		}
+		if ret == nil {
+			ret = make(map[string][]byte)
+		}
+		ret[name] = v
+	}
+	return ret
+}
+
+// SetXattr stores the extended attribute name in Attrs.
+func (info *Info) SetXattr(name string, value []byte) {
+	info.setAttr(AttrXattrPrefix+name, value)
+}
+
+func (info *Info) setAttr(k string, v []byte) {
+	if info.Attrs == nil {
+		info.Attrs = make(map[string][]byte)
+	}
+	info.Attrs[k] = v
+}
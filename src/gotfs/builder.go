@@ -3,6 +3,7 @@ package gotfs
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"strings"
 
@@ -42,7 +43,12 @@ func (b *Builder) BeginFile(p string, mode os.FileMode) error {
 	if !mode.IsRegular() {
 		return fmt.Errorf("mode must be for regular file")
 	}
-	if err := b.writeInfo(p, &Info{Mode: mode}); err != nil {
+	return b.beginFile(p, &Info{Mode: mode})
+}
+
+// beginFile is like BeginFile, but writes info for the file as is.
+func (b *Builder) beginFile(p string, info *Info) error {
+	if err := b.writeInfo(p, info); err != nil {
 		return err
 	}
 	return b.b.SetPrefix(newInfoKey(p).Prefix(nil))
@@ -96,6 +102,22 @@ func (b *Builder) writeExtents(ctx context.Context, exts []*Extent) error {
 	return nil
 }
 
+// writeZeros adds n zeros to the current file.
+// Long runs of zeros are stored as holes.
+func (b *Builder) writeZeros(ctx context.Context, n uint64) error {
+	if b.IsFinished() {
+		return errBuilderIsFinished()
+	}
+	for n > 0 {
+		k := min(n, math.MaxUint32)
+		if err := b.b.CopyExtent(ctx, gotlob.NewHole(uint32(k)), false); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
+
 func (b *Builder) copyFrom(ctx context.Context, root gotkv.Root, span gotkv.Span) error {
 	if err := b.b.CopyFrom(ctx, root, span); err != nil {
 		return err
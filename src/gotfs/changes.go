@@ -0,0 +1,473 @@
+package gotfs
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"io/fs"
+	"slices"
+
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+	"go.brendoncarroll.net/exp/streams"
+)
+
+const (
+	// DefaultSimilarity is the similarity threshold used when ChangeOptions.Threshold is 0.
+	DefaultSimilarity = 50
+	// maxSimilarityReadSize is the largest file whose content is compared line by line.
+	// Larger files are only compared by their extents.
+	maxSimilarityReadSize = 1 << 20
+	// maxRenameCandidates is the most files on either side, for which inexact matches are found.
+	// Exact matches are always found.
+	maxRenameCandidates = 1000
+)
+
+type ChangeType uint8
+
+const (
+	Change_Add ChangeType = iota + 1
+	Change_Delete
+	Change_Modify
+	Change_Rename
+	Change_Copy
+)
+
+func (ct ChangeType) String() string {
+	switch ct {
+	case Change_Add:
+		return "add"
+	case Change_Delete:
+		return "delete"
+	case Change_Modify:
+		return "modify"
+	case Change_Rename:
+		return "rename"
+	case Change_Copy:
+		return "copy"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a change to a path between 2 filesystems.
+type Change struct {
+	Type ChangeType
+	// From is the path in the left filesystem.  It is empty for Change_Add.
+	From string
+	// To is the path in the right filesystem.  It is empty for Change_Delete.
+	To string
+	// Similarity is the percentage of the content which is the same in From and To.
+	// It is only set for renames and copies.
+	Similarity int
+}
+
+// Path returns the path the Change should be listed under.
+func (c Change) Path() string {
+	if c.To != "" {
+		return c.To
+	}
+	return c.From
+}
+
+// ChangeOptions controls how removed and added files are paired up as renames and copies.
+type ChangeOptions struct {
+	// Threshold is the minimum Similarity for a pair of files to be a rename or a copy.
+	// If it is 0, DefaultSimilarity is used.  100 only pairs identical files.
+	Threshold int
+	// Copies also pairs added files with modified files, and with removed files which have already been renamed.
+	Copies bool
+}
+
+func (o ChangeOptions) threshold() int {
+	if o.Threshold <= 0 {
+		return DefaultSimilarity
+	}
+	return min(o.Threshold, 100)
+}
+
+// FileRef refers to the file at Path in Root.
+type FileRef struct {
+	Root Root
+	Path string
+}
+
+// RenameCandidates are the files which FindRenames pairs up, keyed by the path to report them under.
+type RenameCandidates struct {
+	// Removed files can be renamed, or copied.
+	Removed map[string]FileRef
+	// Modified files can only be copied.
+	Modified map[string]FileRef
+	// Added files are each paired with at most one other file.
+	Added map[string]FileRef
+}
+
+// Changes returns the changes to each path between left and right.
+// Removed and added files with similar content are paired, and reported as renames or copies.
+// Changes are sorted by Path.
+func (mach *Machine) Changes(ctx context.Context, ss RO, left, right Root, opts ChangeOptions) ([]Change, error) {
+	cands := RenameCandidates{
+		Removed:  make(map[string]FileRef),
+		Modified: make(map[string]FileRef),
+		Added:    make(map[string]FileRef),
+	}
+	var changes []Change
+	var lastPath *string
+	dfr := mach.NewDiffer(ss.Metadata, left, right)
+	if err := streams.ForEach(ctx, dfr, func(ent DiffEntry) error {
+		p := ent.Key.Path()
+		if lastPath != nil && *lastPath == p {
+			// the Info comes first, so the path has already been classified.
+			return nil
+		}
+		lastPath = &p
+		c := Change{Type: Change_Modify, From: p, To: p}
+		if ent.Key.IsInfo() {
+			switch {
+			case ent.Left.Ok && !ent.Right.Ok:
+				c = Change{Type: Change_Delete, From: p}
+			case !ent.Left.Ok && ent.Right.Ok:
+				c = Change{Type: Change_Add, To: p}
+			}
+		}
+		switch {
+		case c.Type == Change_Delete && !ent.Left.X.Info.Mode.IsDir():
+			cands.Removed[p] = FileRef{Root: left, Path: p}
+		case c.Type == Change_Add && !ent.Right.X.Info.Mode.IsDir():
+			cands.Added[p] = FileRef{Root: right, Path: p}
+		case c.Type == Change_Modify:
+			cands.Modified[p] = FileRef{Root: left, Path: p}
+		}
+		changes = append(changes, c)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return mach.pairRenames(ctx, ss, changes, cands, opts)
+}
+
+// pairRenames replaces the adds and deletes in changes which FindRenames pairs up with renames and copies.
+// The result is sorted by path.
+func (mach *Machine) pairRenames(ctx context.Context, ss RO, changes []Change, cands RenameCandidates, opts ChangeOptions) ([]Change, error) {
+	pairs, err := mach.FindRenames(ctx, ss, cands, opts)
+	if err != nil {
+		return nil, err
+	}
+	paired := make(map[string]bool)
+	for _, pair := range pairs {
+		paired[pair.To] = true
+		if pair.Type == Change_Rename {
+			paired[pair.From] = true
+		}
+	}
+	changes = slices.DeleteFunc(changes, func(c Change) bool {
+		return (c.Type == Change_Add || c.Type == Change_Delete) && paired[c.Path()]
+	})
+	changes = append(changes, pairs...)
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		return cmp.Compare(a.Path(), b.Path())
+	})
+	return changes, nil
+}
+
+// FindRenames pairs each added file with the most similar removed or modified file, if it is similar enough.
+// Exact matches are preferred, and each removed file is renamed at most once.
+// The returned Changes are all renames or copies.
+// Directories and empty files are never paired.
+//
+// Exact matches are found by bucketing the files by their size and extents, or their content,
+// so content is only read for files of the same size as a file on the other side,
+// and for the files left unmatched, when finding inexact matches.
+func (mach *Machine) FindRenames(ctx context.Context, ss RO, cands RenameCandidates, opts ChangeOptions) ([]Change, error) {
+	if len(cands.Added) == 0 || len(cands.Removed) == 0 && (!opts.Copies || len(cands.Modified) == 0) {
+		return nil, nil
+	}
+	loadAll := func(m map[string]FileRef) ([]*fileSig, error) {
+		var ret []*fileSig
+		for name, fr := range m {
+			sig, err := mach.loadFileSig(ctx, ss, name, fr)
+			if err != nil {
+				return nil, err
+			}
+			if sig != nil {
+				ret = append(ret, sig)
+			}
+		}
+		slices.SortFunc(ret, func(a, b *fileSig) int {
+			return cmp.Compare(a.name, b.name)
+		})
+		return ret, nil
+	}
+	added, err := loadAll(cands.Added)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := loadAll(cands.Removed)
+	if err != nil {
+		return nil, err
+	}
+	srcs := removed
+	if opts.Copies {
+		modified, err := loadAll(cands.Modified)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, modified...)
+	}
+	isRemoved := make(map[*fileSig]bool, len(removed))
+	for _, sig := range removed {
+		isRemoved[sig] = true
+	}
+
+	var pairs []Change
+	dstUsed := make(map[*fileSig]bool)
+	renamed := make(map[*fileSig]bool)
+	// pair takes the most similar candidates first, then renames before copies.
+	pair := func(candidates []renameCandidate) {
+		slices.SortStableFunc(candidates, func(a, b renameCandidate) int {
+			if c := cmp.Compare(b.similarity, a.similarity); c != 0 {
+				return c
+			}
+			if isRemoved[a.src] != isRemoved[b.src] {
+				if isRemoved[a.src] {
+					return -1
+				}
+				return 1
+			}
+			return 0
+		})
+		for _, cand := range candidates {
+			if dstUsed[cand.dst] {
+				continue
+			}
+			ty := Change_Copy
+			if isRemoved[cand.src] && !renamed[cand.src] {
+				ty = Change_Rename
+			} else if !opts.Copies {
+				continue
+			}
+			dstUsed[cand.dst] = true
+			if ty == Change_Rename {
+				renamed[cand.src] = true
+			}
+			pairs = append(pairs, Change{
+				Type:       ty,
+				From:       cand.src.name,
+				To:         cand.dst.name,
+				Similarity: cand.similarity,
+			})
+		}
+	}
+
+	exact, err := mach.exactRenames(ctx, ss, srcs, added)
+	if err != nil {
+		return nil, err
+	}
+	pair(exact)
+
+	threshold := opts.threshold()
+	if threshold >= 100 {
+		return pairs, nil
+	}
+	// inexact matches are only looked for between the files which are left.
+	var dsts []*fileSig
+	for _, dst := range added {
+		if !dstUsed[dst] {
+			dsts = append(dsts, dst)
+		}
+	}
+	srcs = slices.DeleteFunc(srcs, func(src *fileSig) bool {
+		return !opts.Copies && renamed[src]
+	})
+	if len(dsts) == 0 || len(srcs) == 0 || len(dsts) > maxRenameCandidates || len(srcs) > maxRenameCandidates {
+		return pairs, nil
+	}
+	for _, sig := range slices.Concat(srcs, dsts) {
+		if err := mach.loadContent(ctx, ss, sig); err != nil {
+			return nil, err
+		}
+	}
+	var inexact []renameCandidate
+	for _, dst := range dsts {
+		for _, src := range srcs {
+			if src.mode.Type() != dst.mode.Type() {
+				continue
+			}
+			if sim := src.similarity(dst); sim >= threshold {
+				inexact = append(inexact, renameCandidate{src: src, dst: dst, similarity: sim})
+			}
+		}
+	}
+	pair(inexact)
+	return pairs, nil
+}
+
+// renameCandidate is a possible rename or copy of src to dst.
+type renameCandidate struct {
+	src, dst   *fileSig
+	similarity int
+}
+
+// exactRenames returns a candidate for each src and dst with the same content.
+// Files are looked up by their size and extents, and then by a hash of their content,
+// which is only read for files with the same size as a file on the other side.
+func (mach *Machine) exactRenames(ctx context.Context, ss RO, srcs, dsts []*fileSig) ([]renameCandidate, error) {
+	type extsKey struct {
+		typ   fs.FileMode
+		size  uint64
+		first Extent
+	}
+	type contentKey struct {
+		typ  fs.FileMode
+		hash [32]byte
+	}
+	byExts := make(map[extsKey][]*fileSig)
+	srcSizes := make(map[uint64]bool)
+	for _, src := range srcs {
+		k := extsKey{typ: src.mode.Type(), size: src.size, first: src.exts[0]}
+		byExts[k] = append(byExts[k], src)
+		srcSizes[src.size] = true
+	}
+	dstSizes := make(map[uint64]bool)
+	for _, dst := range dsts {
+		dstSizes[dst.size] = true
+	}
+	// small files are packed together, so the same content can have different extents.
+	byContent := make(map[contentKey][]*fileSig)
+	for _, src := range srcs {
+		if src.size > maxSimilarityReadSize || !dstSizes[src.size] {
+			continue
+		}
+		if err := mach.loadContent(ctx, ss, src); err != nil {
+			return nil, err
+		}
+		k := contentKey{typ: src.mode.Type(), hash: stores.Hash(src.content)}
+		byContent[k] = append(byContent[k], src)
+	}
+
+	var ret []renameCandidate
+	for _, dst := range dsts {
+		if !srcSizes[dst.size] {
+			continue
+		}
+		for _, src := range byExts[extsKey{typ: dst.mode.Type(), size: dst.size, first: dst.exts[0]}] {
+			if slices.Equal(src.exts, dst.exts) {
+				ret = append(ret, renameCandidate{src: src, dst: dst, similarity: 100})
+			}
+		}
+		if dst.size > maxSimilarityReadSize {
+			continue
+		}
+		if err := mach.loadContent(ctx, ss, dst); err != nil {
+			return nil, err
+		}
+		for _, src := range byContent[contentKey{typ: dst.mode.Type(), hash: stores.Hash(dst.content)}] {
+			// files with the same extents have already been added.
+			if !slices.Equal(src.exts, dst.exts) {
+				ret = append(ret, renameCandidate{src: src, dst: dst, similarity: 100})
+			}
+		}
+	}
+	return ret, nil
+}
+
+// fileSig is what is needed to compare the content of 2 files.
+type fileSig struct {
+	name string
+	fr   FileRef
+	mode fs.FileMode
+	size uint64
+	exts []Extent
+	// content is only loaded for small files, when it is needed. See loadContent.
+	content []byte
+}
+
+// loadFileSig returns the fileSig for fr, or nil if fr is a directory or empty.
+// The content of the file is not read.
+func (mach *Machine) loadFileSig(ctx context.Context, ss RO, name string, fr FileRef) (*fileSig, error) {
+	p := cleanPath(fr.Path)
+	info, err := mach.GetInfo(ctx, ss.Metadata, fr.Root, p)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode.IsDir() {
+		return nil, nil
+	}
+	sig := &fileSig{name: name, fr: FileRef{Root: fr.Root, Path: p}, mode: info.Mode}
+	prefix := newInfoKey(p).Prefix(nil)
+	it := mach.NewIterator(ss.Metadata, fr.Root, gotkv.PrefixSpan(prefix))
+	if err := streams.ForEach[Entry](ctx, &it, func(ent Entry) error {
+		if ent.Key.IsInfo() {
+			return nil
+		}
+		sig.exts = append(sig.exts, ent.Extent)
+		sig.size = ent.Key.EndAt()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if sig.size == 0 {
+		return nil, nil
+	}
+	return sig, nil
+}
+
+// loadContent reads the content of the file into sig.content, if it is small enough, and it has not been read already.
+func (mach *Machine) loadContent(ctx context.Context, ss RO, sig *fileSig) error {
+	if sig.content != nil || sig.size > maxSimilarityReadSize {
+		return nil
+	}
+	prefix := newInfoKey(sig.fr.Path).Prefix(nil)
+	r, err := mach.lob.NewReader(ctx, ss.Metadata, ss.Data, sig.fr.Root.toGotKV(), prefix)
+	if err != nil {
+		return err
+	}
+	sig.content, err = io.ReadAll(r)
+	return err
+}
+
+// similarity returns the percentage of the larger file, which is also in the smaller file.
+// Small files are compared by their lines, and large files by their extents.
+func (a *fileSig) similarity(b *fileSig) int {
+	var shared uint64
+	if a.content != nil && b.content != nil {
+		counts := make(map[string]uint64)
+		forEachLine(a.content, func(line []byte) {
+			counts[string(line)] += uint64(len(line))
+		})
+		forEachLine(b.content, func(line []byte) {
+			if n := min(counts[string(line)], uint64(len(line))); n > 0 {
+				counts[string(line)] -= n
+				shared += n
+			}
+		})
+	} else {
+		counts := make(map[Extent]int)
+		for _, ext := range a.exts {
+			counts[ext]++
+		}
+		for _, ext := range b.exts {
+			if counts[ext] > 0 {
+				counts[ext]--
+				shared += uint64(ext.Length)
+			}
+		}
+	}
+	return int(shared * 100 / max(a.size, b.size))
+}
+
+// forEachLine calls fn with each line in data, including the newline.
+// Long lines are split, so that a change to one doesn't hide everything else which is the same.
+func forEachLine(data []byte, fn func(line []byte)) {
+	const maxLineLen = 64
+	for len(data) > 0 {
+		n := len(data)
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			n = i + 1
+		}
+		n = min(n, maxLineLen)
+		fn(data[:n])
+		data = data[n:]
+	}
+}
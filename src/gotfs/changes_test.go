@@ -0,0 +1,120 @@
+package gotfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	ctx := testutil.Context(t)
+	ag := NewMachine(Params{})
+
+	var lines []string
+	for i := range 20 {
+		lines = append(lines, fmt.Sprintf("line %02d\n", i))
+	}
+	text := []byte(strings.Join(lines, ""))
+	edited := []byte(strings.Replace(string(text), "line 05", "LINE 05", 1))
+	other := []byte(strings.Repeat("something else entirely\n", 8))
+
+	tcs := []struct {
+		Left, Right MemFS
+		Opts        ChangeOptions
+		Expected    []Change
+	}{
+		{
+			Left:  MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{"a.txt": {Mode: 0o644, Data: edited}},
+			Expected: []Change{
+				{Type: Change_Modify, From: "a.txt", To: "a.txt"},
+			},
+		},
+		{
+			Left:  MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{"b.txt": {Mode: 0o644, Data: text}},
+			Expected: []Change{
+				{Type: Change_Rename, From: "a.txt", To: "b.txt", Similarity: 100},
+			},
+		},
+		{
+			Left:  MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{"b.txt": {Mode: 0o644, Data: edited}},
+			Expected: []Change{
+				{Type: Change_Rename, From: "a.txt", To: "b.txt", Similarity: 95},
+			},
+		},
+		{
+			// the same edit, but too different for the threshold.
+			Left:  MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{"b.txt": {Mode: 0o644, Data: edited}},
+			Opts:  ChangeOptions{Threshold: 100},
+			Expected: []Change{
+				{Type: Change_Delete, From: "a.txt"},
+				{Type: Change_Add, To: "b.txt"},
+			},
+		},
+		{
+			Left:  MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{"b.txt": {Mode: 0o644, Data: other}},
+			Expected: []Change{
+				{Type: Change_Delete, From: "a.txt"},
+				{Type: Change_Add, To: "b.txt"},
+			},
+		},
+		{
+			// only one of the new files can be the rename, the other is a copy.
+			Left: MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{
+				"b.txt": {Mode: 0o644, Data: text},
+				"c.txt": {Mode: 0o644, Data: edited},
+			},
+			Opts: ChangeOptions{Copies: true},
+			Expected: []Change{
+				{Type: Change_Rename, From: "a.txt", To: "b.txt", Similarity: 100},
+				{Type: Change_Copy, From: "a.txt", To: "c.txt", Similarity: 95},
+			},
+		},
+		{
+			Left: MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{
+				"a.txt": {Mode: 0o644, Data: other},
+				"b.txt": {Mode: 0o644, Data: text},
+			},
+			Opts: ChangeOptions{Copies: true},
+			Expected: []Change{
+				{Type: Change_Modify, From: "a.txt", To: "a.txt"},
+				{Type: Change_Copy, From: "a.txt", To: "b.txt", Similarity: 100},
+			},
+		},
+		{
+			// without Copies, modified files are not sources.
+			Left: MemFS{"a.txt": {Mode: 0o644, Data: text}},
+			Right: MemFS{
+				"a.txt": {Mode: 0o644, Data: other},
+				"b.txt": {Mode: 0o644, Data: text},
+			},
+			Expected: []Change{
+				{Type: Change_Modify, From: "a.txt", To: "a.txt"},
+				{Type: Change_Add, To: "b.txt"},
+			},
+		},
+	}
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			s := stores.NewMem()
+			ss := RW{s, s}
+			left := buildFS(t, ag.NewBuilder(ctx, ss), tc.Left)
+			right := buildFS(t, ag.NewBuilder(ctx, ss), tc.Right)
+
+			actual, err := ag.Changes(ctx, ss.RO(), left, right, tc.Opts)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, actual)
+		})
+	}
+}
@@ -25,7 +25,12 @@ type Differ struct {
 }
 
 func (mach *Machine) NewDiffer(ms stores.RO, left, right Root) *Differ {
-	span := gotkv.TotalSpan()
+	return mach.NewDifferSpan(ms, left, right, gotkv.TotalSpan())
+}
+
+// NewDifferSpan returns a Differ which only yields the differences with keys in span.
+// Use SpanForPath to diff a single path, and everything beneath it.
+func (mach *Machine) NewDifferSpan(ms stores.RO, left, right Root, span gotkv.Span) *Differ {
 	return &Differ{
 		kvdiff: mach.gotkv.NewDiffer(ms, left.ToGotKV(), right.ToGotKV(), span),
 	}
@@ -0,0 +1,247 @@
+package gotfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"io"
+	"maps"
+	"path"
+	"slices"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// DigestSize is the size of a Digest in bytes.
+const DigestSize = 32
+
+// Digest is a hash of the content and Info of a file or directory.
+// Unlike a Ref, it only depends on the plaintext, so it is the same for the same content,
+// regardless of the Params, such as the Salt, used to store it.
+//
+// The Digest of a file covers its Info and data.
+// The Digest of a directory covers its Info, and the name and Digest of each child,
+// so it is a Merkle tree over everything beneath it.
+type Digest [DigestSize]byte
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// ContentDigest returns the Digest of the file or directory at p.
+// All of the data beneath p is read, which can take a while for large directories.
+func (mach *Machine) ContentDigest(ctx context.Context, ss RO, root Root, p string) (Digest, error) {
+	d := mach.newDigester(ss, root)
+	return d.digest(ctx, cleanPath(p))
+}
+
+// digester computes Digests for paths in a single filesystem.
+// It remembers the Digests of every path it has visited, so a path beneath a directory can be
+// compared, without reading it again.
+type digester struct {
+	mach  *Machine
+	ss    RO
+	root  Root
+	cache map[string]Digest
+}
+
+func (mach *Machine) newDigester(ss RO, root Root) *digester {
+	return &digester{mach: mach, ss: ss, root: root, cache: make(map[string]Digest)}
+}
+
+func (d *digester) digest(ctx context.Context, p string) (Digest, error) {
+	if dg, ok := d.cache[p]; ok {
+		return dg, nil
+	}
+	info, err := d.mach.GetInfo(ctx, d.ss.Metadata, d.root, p)
+	if err != nil {
+		return Digest{}, err
+	}
+	h := newDigestHash(info)
+	if info.Mode.IsDir() {
+		names, err := d.children(ctx, p)
+		if err != nil {
+			return Digest{}, err
+		}
+		for _, name := range names {
+			child, err := d.digest(ctx, path.Join(p, name))
+			if err != nil {
+				return Digest{}, err
+			}
+			writeDigestField(h, []byte(name))
+			h.Write(child[:])
+		}
+	} else {
+		r, err := d.mach.lob.NewReader(ctx, d.ss.Metadata, d.ss.Data, d.root.toGotKV(), newInfoKey(p).Prefix(nil))
+		if err != nil {
+			return Digest{}, err
+		}
+		// the data is last, so it does not need to be length prefixed.
+		if _, err := io.Copy(h, r); err != nil {
+			return Digest{}, err
+		}
+	}
+	var dg Digest
+	h.Sum(dg[:0])
+	d.cache[p] = dg
+	return dg, nil
+}
+
+// children returns the names of the children of the directory at p, sorted.
+func (d *digester) children(ctx context.Context, p string) ([]string, error) {
+	var names []string
+	if err := d.mach.ReadDir(ctx, d.ss.Metadata, d.root, p, func(ent DirEnt) error {
+		names = append(names, ent.Name)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// newDigestHash returns a hash which has already been written to with info.
+// The Attrs are written in sorted order, since Info.Marshal does not sort them.
+func newDigestHash(info *Info) hash.Hash {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	h.Write([]byte("gotfs-digest-v1"))
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(info.Mode)))
+	keys := slices.Sorted(maps.Keys(info.Attrs))
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(keys))))
+	for _, k := range keys {
+		writeDigestField(h, []byte(k))
+		writeDigestField(h, info.Attrs[k])
+	}
+	return h
+}
+
+// writeDigestField writes data to h, prefixed with its length.
+func writeDigestField(h hash.Hash, data []byte) {
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(data))))
+	h.Write(data)
+}
+
+// ContentChanges is like Changes, except that it compares left and right by their Digests.
+// This works for filesystems stored with different Params, where Changes would report every file as modified.
+// Directories with the same Digest on both sides are skipped without being compared any further.
+//
+// Renames and copies are only found between files with the same extents, or between small files.
+func (mach *Machine) ContentChanges(ctx context.Context, ss RO, left, right Root, opts ChangeOptions) ([]Change, error) {
+	cc := contentComparer{
+		mach:  mach,
+		left:  mach.newDigester(ss, left),
+		right: mach.newDigester(ss, right),
+		cands: RenameCandidates{
+			Removed:  make(map[string]FileRef),
+			Modified: make(map[string]FileRef),
+			Added:    make(map[string]FileRef),
+		},
+	}
+	if err := cc.compare(ctx, ""); err != nil {
+		return nil, err
+	}
+	return mach.pairRenames(ctx, ss, cc.changes, cc.cands, opts)
+}
+
+type contentComparer struct {
+	mach        *Machine
+	left, right *digester
+
+	changes []Change
+	cands   RenameCandidates
+}
+
+// compare adds the changes to p, and everything beneath it, which exists on both sides.
+func (cc *contentComparer) compare(ctx context.Context, p string) error {
+	ld, err := cc.left.digest(ctx, p)
+	if err != nil {
+		return err
+	}
+	rd, err := cc.right.digest(ctx, p)
+	if err != nil {
+		return err
+	}
+	if ld == rd {
+		return nil
+	}
+	linfo, err := cc.mach.GetInfo(ctx, cc.left.ss.Metadata, cc.left.root, p)
+	if err != nil {
+		return err
+	}
+	rinfo, err := cc.mach.GetInfo(ctx, cc.right.ss.Metadata, cc.right.root, p)
+	if err != nil {
+		return err
+	}
+	if !linfo.Mode.IsDir() || !rinfo.Mode.IsDir() {
+		if linfo.Mode.IsDir() || rinfo.Mode.IsDir() {
+			// a file replaced a directory, or the other way around.
+			if err := cc.removeAll(ctx, p); err != nil {
+				return err
+			}
+			return cc.addAll(ctx, p)
+		}
+		cc.changes = append(cc.changes, Change{Type: Change_Modify, From: p, To: p})
+		cc.cands.Modified[p] = FileRef{Root: cc.left.root, Path: p}
+		return nil
+	}
+	if !infoEqual(linfo, rinfo) {
+		cc.changes = append(cc.changes, Change{Type: Change_Modify, From: p, To: p})
+	}
+	lnames, err := cc.left.children(ctx, p)
+	if err != nil {
+		return err
+	}
+	rnames, err := cc.right.children(ctx, p)
+	if err != nil {
+		return err
+	}
+	for len(lnames) > 0 || len(rnames) > 0 {
+		var err error
+		switch {
+		case len(rnames) == 0 || len(lnames) > 0 && lnames[0] < rnames[0]:
+			err = cc.removeAll(ctx, path.Join(p, lnames[0]))
+			lnames = lnames[1:]
+		case len(lnames) == 0 || rnames[0] < lnames[0]:
+			err = cc.addAll(ctx, path.Join(p, rnames[0]))
+			rnames = rnames[1:]
+		default:
+			err = cc.compare(ctx, path.Join(p, lnames[0]))
+			lnames, rnames = lnames[1:], rnames[1:]
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeAll adds a Change_Delete for p and everything beneath it in left.
+func (cc *contentComparer) removeAll(ctx context.Context, p string) error {
+	return cc.mach.ForEach(ctx, cc.left.ss.Metadata, cc.left.root, p, func(p string, info *Info) error {
+		cc.changes = append(cc.changes, Change{Type: Change_Delete, From: p})
+		if !info.Mode.IsDir() {
+			cc.cands.Removed[p] = FileRef{Root: cc.left.root, Path: p}
+		}
+		return nil
+	})
+}
+
+// addAll adds a Change_Add for p and everything beneath it in right.
+func (cc *contentComparer) addAll(ctx context.Context, p string) error {
+	return cc.mach.ForEach(ctx, cc.right.ss.Metadata, cc.right.root, p, func(p string, info *Info) error {
+		cc.changes = append(cc.changes, Change{Type: Change_Add, To: p})
+		if !info.Mode.IsDir() {
+			cc.cands.Added[p] = FileRef{Root: cc.right.root, Path: p}
+		}
+		return nil
+	})
+}
+
+func infoEqual(a, b *Info) bool {
+	return a.Mode == b.Mode && maps.EqualFunc(a.Attrs, b.Attrs, bytes.Equal)
+}
@@ -0,0 +1,58 @@
+package gotfs
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentDigest(t *testing.T) {
+	ctx := testutil.Context(t)
+	ag1 := NewMachine(Params{})
+	ag2 := NewMachine(Params{Salt: [32]byte{1, 2, 3}})
+
+	text := []byte(strings.Repeat("some text\n", 1000))
+	m := MemFS{
+		"a.txt":   {Mode: 0o644, Data: text},
+		"d":       {Mode: fs.ModeDir | 0o755},
+		"d/b.txt": {Mode: 0o644, Data: []byte("b")},
+	}
+	s := stores.NewMem()
+	ss := RW{s, s}
+	root1 := buildFS(t, ag1.NewBuilder(ctx, ss), m)
+	root2 := buildFS(t, ag2.NewBuilder(ctx, ss), m)
+	require.NotEqual(t, root1, root2)
+
+	// the same content has the same digest, regardless of the salt.
+	for _, p := range []string{"", "a.txt", "d", "d/b.txt"} {
+		d1, err := ag1.ContentDigest(ctx, ss.RO(), root1, p)
+		require.NoError(t, err)
+		d2, err := ag2.ContentDigest(ctx, ss.RO(), root2, p)
+		require.NoError(t, err)
+		require.Equal(t, d1, d2, "path %q", p)
+	}
+	changes, err := ag1.ContentChanges(ctx, ss.RO(), root1, root2, ChangeOptions{})
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	m["d/b.txt"] = MemFile{Mode: 0o644, Data: []byte("B")}
+	m["c.txt"] = MemFile{Mode: 0o644, Data: []byte("c")}
+	root3 := buildFS(t, ag2.NewBuilder(ctx, ss), m)
+	for p, same := range map[string]bool{"": false, "a.txt": true, "d": false, "d/b.txt": false} {
+		d1, err := ag1.ContentDigest(ctx, ss.RO(), root1, p)
+		require.NoError(t, err)
+		d3, err := ag2.ContentDigest(ctx, ss.RO(), root3, p)
+		require.NoError(t, err)
+		require.Equal(t, same, d1 == d3, "path %q", p)
+	}
+	changes, err = ag1.ContentChanges(ctx, ss.RO(), root1, root3, ChangeOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Type: Change_Add, To: "c.txt"},
+		{Type: Change_Modify, From: "d/b.txt", To: "d/b.txt"},
+	}, changes)
+}
@@ -56,6 +56,65 @@ func TestMkdirAll(t *testing.T) {
 	requireChildren(t, &mach, s, *x, "path/to/the", []string{"dir"})
 }
 
+func TestMove(t *testing.T) {
+	ctx, mach, s := setup(t)
+	ss := RW{s, s}
+	x, err := mach.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	x, err = mach.MkdirAll(ctx, s, *x, "a/b")
+	require.NoError(t, err)
+	x, err = mach.Mkdir(ctx, s, *x, "c")
+	require.NoError(t, err)
+	data := bytes.Repeat([]byte("move me\n"), 1000)
+	x, err = mach.CreateFile(ctx, ss, *x, "a/b/file.txt", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	y, err := mach.Move(ctx, ss, *x, "a/b", "c/d")
+	require.NoError(t, err)
+	requireChildren(t, &mach, s, *y, "a", nil)
+	requireChildren(t, &mach, s, *y, "c", []string{"d"})
+	requireChildren(t, &mach, s, *y, "c/d", []string{"file.txt"})
+	actual, err := mach.ReadFile(ctx, ss.RO(), *y, "c/d/file.txt", len(data)+1)
+	require.NoError(t, err)
+	require.Equal(t, data, actual)
+
+	// the destination must not exist, and its parent must.
+	_, err = mach.Move(ctx, ss, *x, "a/b", "c")
+	require.Error(t, err)
+	_, err = mach.Move(ctx, ss, *x, "a/b", "e/f")
+	require.Error(t, err)
+	_, err = mach.Move(ctx, ss, *x, "a", "a/b/g")
+	require.Error(t, err)
+}
+
+// TestForEach tests that ForEach and ForEachLeaf call fn with the path of each entry, not the path they were called with.
+func TestForEach(t *testing.T) {
+	ctx, mach, s := setup(t)
+	ss := RW{s, s}
+	x, err := mach.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	x, err = mach.MkdirAll(ctx, s, *x, "a/b")
+	require.NoError(t, err)
+	for _, p := range []string{"a/b/c.txt", "a/d.txt", "e.txt"} {
+		x, err = mach.CreateFile(ctx, ss, *x, p, bytes.NewReader([]byte(p)))
+		require.NoError(t, err)
+	}
+
+	var actual []string
+	require.NoError(t, mach.ForEach(ctx, s, *x, "a", func(p string, _ *Info) error {
+		actual = append(actual, p)
+		return nil
+	}))
+	require.Equal(t, []string{"a", "a/b", "a/b/c.txt", "a/d.txt"}, actual)
+
+	actual = nil
+	require.NoError(t, mach.ForEachLeaf(ctx, s, *x, "", func(p string, _ *Info) error {
+		actual = append(actual, p)
+		return nil
+	}))
+	require.Equal(t, []string{"a/b/c.txt", "a/d.txt", "e.txt"}, actual)
+}
+
 func requireChildren(t *testing.T, ag *Machine, s Store, x Root, p string, expected []string) {
 	ctx := testutil.Context(t)
 	var actual []string
@@ -1,14 +1,17 @@
 package gotfs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 
 	"github.com/gotvc/got/src/gotfs/gotlob"
+	"github.com/gotvc/got/src/gotkv"
 	"github.com/gotvc/got/src/internal/metrics"
 	"github.com/gotvc/got/src/internal/stores"
+	"go.brendoncarroll.net/exp/streams"
 	"go.brendoncarroll.net/state/posixfs"
 	"golang.org/x/sync/errgroup"
 )
@@ -70,6 +73,123 @@ func (mach *Machine) PutFile(ctx context.Context, ss RW, x Root, p string, r io.
 	return mach.Graft(ctx, ss, x, p, *fileRoot)
 }
 
+// WriteAt writes data to the file at p, starting at offset.
+// If offset is past the end of the file, the gap is filled with zeros.
+// Only the extents which overlap the written range are rechunked, the rest are reused.
+func (mach *Machine) WriteAt(ctx context.Context, ss RW, x Root, p string, offset int64, data []byte) (*Root, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("gotfs: negative offset %d", offset)
+	}
+	start := uint64(offset)
+	return mach.spliceFile(ctx, ss, x, p, start, start+uint64(len(data)), bytes.NewReader(data))
+}
+
+// Truncate changes the size of the file at p to size.
+// If the file is extended, the new data reads as zeros.
+func (mach *Machine) Truncate(ctx context.Context, ss RW, x Root, p string, size int64) (*Root, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("gotfs: negative size %d", size)
+	}
+	p = cleanPath(p)
+	oldSize, err := mach.SizeOfFile(ctx, ss.Metadata, x, p)
+	if err != nil {
+		return nil, err
+	}
+	return mach.spliceFile(ctx, ss, x, p, uint64(size), max(oldSize, uint64(size)), bytes.NewReader(nil))
+}
+
+// Append writes the data from r to the end of the file at p.
+func (mach *Machine) Append(ctx context.Context, ss RW, x Root, p string, r io.Reader) (*Root, error) {
+	p = cleanPath(p)
+	size, err := mach.SizeOfFile(ctx, ss.Metadata, x, p)
+	if err != nil {
+		return nil, err
+	}
+	return mach.spliceFile(ctx, ss, x, p, size, size, r)
+}
+
+// spliceFile replaces the data in [start, end) of the file at p with the data from r.
+// Data past end is kept, and if start is past the end of the file, the gap is filled with a hole.
+// The Info for the file is not changed.
+// Extents which are entirely outside of [start, end) are copied by reference,
+// and extents which are cut at start or end are trimmed and rechunked with the new data.
+func (mach *Machine) spliceFile(ctx context.Context, ss RW, x Root, p string, start, end uint64, r io.Reader) (*Root, error) {
+	p = cleanPath(p)
+	info, err := mach.GetFileInfo(ctx, ss.Metadata, x, p)
+	if err != nil {
+		return nil, err
+	}
+	b := mach.NewBuilder(ctx, ss)
+	if err := b.beginFile("", info); err != nil {
+		return nil, err
+	}
+	// size is the end of the last extent which has been looked at.
+	var size uint64
+	var written bool
+	// writeNew writes gap zeros, and then the new data.
+	writeNew := func(gap uint64) error {
+		if err := b.writeZeros(ctx, gap); err != nil {
+			return err
+		}
+		written = true
+		_, err := io.Copy(b, r)
+		return err
+	}
+	it := mach.NewIterator(ss.Metadata, x, gotkv.PrefixSpan(newInfoKey(p).Prefix(nil)))
+	if err := streams.ForEach[Entry](ctx, &it, func(ent Entry) error {
+		if ent.Key.IsInfo() {
+			return nil
+		}
+		ext := ent.Extent
+		extEnd := ent.Key.EndAt()
+		extBeg := extEnd - uint64(ext.Length)
+		switch {
+		case extEnd <= start:
+			if err := b.b.CopyExtent(ctx, &ext, false); err != nil {
+				return err
+			}
+		case extBeg < start:
+			if err := b.b.CopyExtent(ctx, subExtent(ext, extBeg, extBeg, start), true); err != nil {
+				return err
+			}
+		}
+		if extEnd > start && !written {
+			if err := writeNew(0); err != nil {
+				return err
+			}
+		}
+		if extEnd > end {
+			from := max(extBeg, end)
+			if err := b.b.CopyExtent(ctx, subExtent(ext, extBeg, from, extEnd), from > extBeg); err != nil {
+				return err
+			}
+		}
+		size = extEnd
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if !written {
+		if err := writeNew(start - min(start, size)); err != nil {
+			return nil, err
+		}
+	}
+	fileRoot, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return mach.Graft(ctx, ss, x, p, *fileRoot)
+}
+
+// subExtent returns the part of ext, which starts at base in the file, that is in [from, to).
+func subExtent(ext Extent, base, from, to uint64) *Extent {
+	return &Extent{
+		Ref:    ext.Ref,
+		Offset: ext.Offset + uint32(from-base),
+		Length: uint32(to - from),
+	}
+}
+
 // SizeOfFile returns the size of the file at p in bytes.
 func (mach *Machine) SizeOfFile(ctx context.Context, s stores.RO, x Root, p string) (uint64, error) {
 	p = cleanPath(p)
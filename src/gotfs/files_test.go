@@ -10,7 +10,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
 	"github.com/stretchr/testify/require"
+	"go.brendoncarroll.net/exp/streams"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -93,3 +97,168 @@ func TestLargeFiles(t *testing.T) {
 	}
 	require.NoError(t, eg.Wait())
 }
+
+func TestWriteAt(t *testing.T) {
+	ctx, ag, s := setup(t)
+	ss := RW{s, s}
+	rng := mrand.New(mrand.NewSource(0))
+	expected := make([]byte, 1<<20)
+	rng.Read(expected)
+	x, err := ag.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	x, err = ag.CreateFile(ctx, ss, *x, "a.txt", strings.NewReader("before"))
+	require.NoError(t, err)
+	x, err = ag.CreateFile(ctx, ss, *x, "b.bin", bytes.NewReader(expected))
+	require.NoError(t, err)
+	x, err = ag.CreateFile(ctx, ss, *x, "c.txt", strings.NewReader("after"))
+	require.NoError(t, err)
+
+	check := func() {
+		t.Helper()
+		actual, err := ag.ReadFile(ctx, ss.RO(), *x, "b.bin", 1<<22)
+		require.NoError(t, err)
+		require.Equal(t, len(expected), len(actual))
+		require.True(t, bytes.Equal(expected, actual))
+		for p, data := range map[string]string{"a.txt": "before", "c.txt": "after"} {
+			actual, err := ag.ReadFile(ctx, ss.RO(), *x, p, 100)
+			require.NoError(t, err)
+			require.Equal(t, data, string(actual))
+		}
+	}
+	ops := []func(){
+		func() {
+			// overwrite in the middle
+			data := bytes.Repeat([]byte("x"), 10_000)
+			x, err = ag.WriteAt(ctx, ss, *x, "b.bin", 300_000, data)
+			copy(expected[300_000:], data)
+		},
+		func() {
+			// overwrite the start
+			x, err = ag.WriteAt(ctx, ss, *x, "b.bin", 0, []byte("hello"))
+			copy(expected, "hello")
+		},
+		func() {
+			// write across the end
+			data := []byte("across the end")
+			x, err = ag.WriteAt(ctx, ss, *x, "b.bin", int64(len(expected)-5), data)
+			expected = append(expected[:len(expected)-5], data...)
+		},
+		func() {
+			x, err = ag.Append(ctx, ss, *x, "b.bin", strings.NewReader("appended"))
+			expected = append(expected, "appended"...)
+		},
+		func() {
+			x, err = ag.Truncate(ctx, ss, *x, "b.bin", 500_000)
+			expected = expected[:500_000]
+		},
+		func() {
+			// extend with a hole
+			x, err = ag.Truncate(ctx, ss, *x, "b.bin", 700_000)
+			expected = append(expected, make([]byte, 200_000)...)
+		},
+		func() {
+			// write past the end
+			x, err = ag.WriteAt(ctx, ss, *x, "b.bin", 800_000, []byte("past the end"))
+			expected = append(expected, make([]byte, 100_000)...)
+			expected = append(expected, "past the end"...)
+		},
+		func() {
+			x, err = ag.Truncate(ctx, ss, *x, "b.bin", 0)
+			expected = expected[:0]
+		},
+		func() {
+			x, err = ag.Append(ctx, ss, *x, "b.bin", strings.NewReader("appended to empty"))
+			expected = append(expected, "appended to empty"...)
+		},
+	}
+	for _, op := range ops {
+		op()
+		require.NoError(t, err)
+		check()
+	}
+	info, err := ag.GetInfo(ctx, s, *x, "b.bin")
+	require.NoError(t, err)
+	require.True(t, info.Mode.IsRegular())
+
+	_, err = ag.Truncate(ctx, ss, *x, "", 10)
+	require.Error(t, err)
+}
+
+// TestSpliceReuse tests that WriteAt, Truncate and Append reuse the extents outside of the range they change.
+func TestSpliceReuse(t *testing.T) {
+	ctx := testutil.Context(t)
+	// small blobs, so that the file has many extents.
+	meanSize := 1 << 12
+	mach := NewMachine(Params{MeanBlobSizeData: &meanSize})
+	s := stores.NewMem()
+	ss := RW{s, s}
+	rng := mrand.New(mrand.NewSource(0))
+	data := make([]byte, 1<<20)
+	rng.Read(data)
+	x, err := mach.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	x, err = mach.CreateFile(ctx, ss, *x, "b.bin", bytes.NewReader(data))
+	require.NoError(t, err)
+	before := listExtents(t, &mach, s, *x, "b.bin")
+	require.Greater(t, len(before), 100)
+
+	// requireReused checks that each extent in before which is entirely in [from, to) is also in after, at the same position.
+	requireReused := func(after []fileExtent, from, to uint64) {
+		t.Helper()
+		positions := make(map[fileExtent]bool, len(after))
+		for _, fe := range after {
+			positions[fe] = true
+		}
+		var n int
+		for _, fe := range before {
+			if fe.begin >= from && fe.begin+uint64(fe.ext.Length) <= to {
+				require.True(t, positions[fe], "extent at %d was not reused", fe.begin)
+				n++
+			}
+		}
+		require.Positive(t, n)
+	}
+
+	const start, end = 300_000, 310_000
+	y, err := mach.WriteAt(ctx, ss, *x, "b.bin", start, bytes.Repeat([]byte("x"), end-start))
+	require.NoError(t, err)
+	after := listExtents(t, &mach, s, *y, "b.bin")
+	requireReused(after, 0, start)
+	// the data after the write is rechunked until the chunker finds a boundary from before.
+	last := before[len(before)-1]
+	require.Equal(t, last, after[len(after)-1])
+
+	y, err = mach.Truncate(ctx, ss, *x, "b.bin", start)
+	require.NoError(t, err)
+	requireReused(listExtents(t, &mach, s, *y, "b.bin"), 0, start)
+
+	y, err = mach.Truncate(ctx, ss, *x, "b.bin", 2*int64(len(data)))
+	require.NoError(t, err)
+	requireReused(listExtents(t, &mach, s, *y, "b.bin"), 0, uint64(len(data)))
+
+	y, err = mach.Append(ctx, ss, *x, "b.bin", strings.NewReader("appended"))
+	require.NoError(t, err)
+	requireReused(listExtents(t, &mach, s, *y, "b.bin"), 0, uint64(len(data)))
+}
+
+// fileExtent is an Extent, and where it begins in a file.
+type fileExtent struct {
+	begin uint64
+	ext   Extent
+}
+
+// listExtents returns the extents of the file at p, in order.
+func listExtents(t testing.TB, mach *Machine, s Store, root Root, p string) []fileExtent {
+	t.Helper()
+	ctx := testutil.Context(t)
+	var ret []fileExtent
+	it := mach.NewIterator(s, root, gotkv.PrefixSpan(newInfoKey(p).Prefix(nil)))
+	require.NoError(t, streams.ForEach[Entry](ctx, &it, func(ent Entry) error {
+		if ent.Key.IsInfo() {
+			return nil
+		}
+		ret = append(ret, fileExtent{begin: ent.Key.EndAt() - uint64(ent.Extent.Length), ext: ent.Extent})
+		return nil
+	}))
+	return ret
+}
@@ -16,11 +16,13 @@ import (
 )
 
 // Builder chunks large objects, stores them, and then writes extents to a gotkv instance.
+// Long runs of zeros are written as holes, which are not chunked or stored.
 type Builder struct {
 	ag     *Machine
 	ctx    context.Context
 	ms, ds stores.RW
 
+	zs      zeroSplitter
 	chunker *chunking.ContentDefined
 	kvb     *gotkv.Builder
 
@@ -42,6 +44,7 @@ func (a *Machine) NewBuilder(ctx context.Context, ms, ds stores.RW) *Builder {
 		kvb: a.gotkv.NewBuilder(ms),
 	}
 	b.chunker = a.newChunker(b.handleChunk)
+	b.zs = zeroSplitter{onData: b.writeData, onZeros: b.writeHole}
 	if ds.MaxSize() < b.chunker.MaxSize() {
 		panic(fmt.Sprint("store size too small", ds.MaxSize()))
 	}
@@ -56,6 +59,9 @@ func (b *Builder) Put(ctx context.Context, key, value []byte) error {
 	if err := b.checkKey(key); err != nil {
 		return err
 	}
+	if err := b.zs.Flush(); err != nil {
+		return err
+	}
 	b.queue = append(b.queue, operation{
 		key:      append([]byte{}, key...),
 		isInline: true,
@@ -76,6 +82,9 @@ func (b *Builder) SetPrefix(prefix []byte) error {
 	if err := b.checkKey(prefix); err != nil {
 		return err
 	}
+	if err := b.zs.Flush(); err != nil {
+		return err
+	}
 	b.queue = append(b.queue, operation{
 		key:      append([]byte{}, prefix...),
 		isInline: false,
@@ -106,8 +115,28 @@ func (b *Builder) Write(data []byte) (int, error) {
 	if prefix := b.GetPrefix(nil); prefix == nil {
 		return 0, errors.New("Write called before SetPrefix")
 	}
+	b.zs.offset = b.queue[len(b.queue)-1].bytesSent
+	return b.zs.Write(data)
+}
+
+// writeData writes data, which has been checked for zeros, to the chunker.
+func (b *Builder) writeData(data []byte) error {
 	b.queue[len(b.queue)-1].bytesSent += uint64(len(data))
-	return b.chunker.Write(data)
+	_, err := b.chunker.Write(data)
+	return err
+}
+
+// writeHole writes holes for n zeros, after the data in the chunker.
+func (b *Builder) writeHole(n uint64) error {
+	if err := b.chunker.Flush(); err != nil {
+		return err
+	}
+	for _, ext := range holeExtents(n) {
+		if err := b.putExtent(b.ctx, ext); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // CopyExtents copies multiple extents to the current object.
@@ -128,6 +157,13 @@ func (b *Builder) CopyExtent(ctx context.Context, ext *Extent, isShort bool) err
 	if prefix := b.GetPrefix(nil); prefix == nil {
 		return errors.New("CopyExtent called before SetPrefix")
 	}
+	if ext.IsHole() {
+		b.zs.offset = b.queue[len(b.queue)-1].bytesSent
+		return b.zs.WriteZeros(uint64(ext.Length))
+	}
+	if err := b.zs.Flush(); err != nil {
+		return err
+	}
 	if b.chunker.Buffered() > 0 || isShort {
 		// can't just copy the extent because we are not aligned.
 		return b.ag.getExtentF(ctx, b.ds, ext, func(data []byte) error {
@@ -135,6 +171,12 @@ func (b *Builder) CopyExtent(ctx context.Context, ext *Extent, isShort bool) err
 			return err
 		})
 	}
+	return b.putExtent(ctx, ext)
+}
+
+// putExtent adds ext to the end of the current object.
+// There must not be any data buffered in the chunker.
+func (b *Builder) putExtent(ctx context.Context, ext *Extent) error {
 	li := len(b.queue) - 1
 	if b.queue[li].bytesSent != b.queue[li].lastOffset {
 		panic("data buffered in chunker")
@@ -150,6 +192,9 @@ func (b *Builder) CopyExtent(ctx context.Context, ext *Extent, isShort bool) err
 func (b *Builder) Finish(ctx context.Context) (Root, error) {
 	if b.root.Ref.IsZero() && b.err == nil {
 		b.root, b.err = func() (Root, error) {
+			if err := b.zs.Flush(); err != nil {
+				return Root{}, err
+			}
 			if err := b.chunker.Flush(); err != nil {
 				return Root{}, err
 			}
@@ -206,13 +251,14 @@ func (b *Builder) handleChunk(data []byte) error {
 
 // flushInline flushes the inline entries from the queue and clears the queue.
 func (b *Builder) flushInline(ctx context.Context) error {
-	// Drop empty non-inline ops (empty files) that would otherwise block inline flushes.
+	// Drop empty non-inline ops (empty files), and non-inline ops which have all of their extents written,
+	// that would otherwise block inline flushes.
 	for len(b.queue) > 0 {
 		op := b.queue[0]
 		if op.isInline {
 			break
 		}
-		if op.bytesSent == 0 && op.lastOffset == 0 {
+		if op.bytesSent == 0 && op.lastOffset == 0 || op.bytesSent == op.lastOffset && len(b.queue) > 1 {
 			b.queue = b.queue[1:]
 			continue
 		}
@@ -254,6 +300,9 @@ func (b *Builder) CopyFrom(ctx context.Context, root Root, span Span) error {
 	if err := b.checkFinished(); err != nil {
 		return err
 	}
+	if err := b.zs.Flush(); err != nil {
+		return err
+	}
 	maxExtKey, maxExt, err := b.ag.MaxExtent(ctx, b.ms, root, span)
 	if err != nil {
 		return err
@@ -268,7 +317,7 @@ func (b *Builder) CopyFrom(ctx context.Context, root Root, span Span) error {
 	it := b.ag.gotkv.NewIterator(b.ms, root, span1)
 	// copy one by one until we can fast copy
 	var ent kvstreams.Entry
-	for b.chunker.Buffered() > 0 {
+	for b.chunker.Buffered() > 0 || b.zs.pending() {
 		if err := streams.NextUnit(ctx, it, &ent); err != nil {
 			if streams.IsEOS(err) {
 				break
@@ -289,6 +338,10 @@ func (b *Builder) CopyFrom(ctx context.Context, root Root, span Span) error {
 			}
 		}
 	}
+	// anything still pending belongs before the last extent.
+	if err := b.zs.Flush(); err != nil {
+		return err
+	}
 	// blind fast copy
 	if err := gotkv.CopyAll(ctx, b.kvb, it); err != nil {
 		return err
@@ -8,6 +8,7 @@ import (
 )
 
 // Extent is a reference to data using the gdat.Ref type.
+// An Extent with a zero Ref is a hole, it reads as Length zeros.
 type Extent struct {
 	// Ref points to the blob that contains the Extent data
 	Ref gdat.Ref
@@ -17,8 +18,25 @@ type Extent struct {
 	Length uint32
 }
 
+// NewHole returns an Extent with no data, which reads as length zeros.
+func NewHole(length uint32) *Extent {
+	return &Extent{Length: length}
+}
+
+// IsHole returns true if the Extent has no data, and reads as zeros.
+func (e *Extent) IsHole() bool {
+	return e.Ref.IsZero()
+}
+
 func (e *Extent) MarshalBinary() ([]byte, error) {
 	var buf []byte
+	if e.IsHole() {
+		// holes only need a length.
+		if e.Offset != 0 {
+			return nil, fmt.Errorf("hole cannot have an offset")
+		}
+		return appendUint32(buf, e.Length), nil
+	}
 	buf = gdat.AppendRef(buf, e.Ref)
 	buf = appendUint32(buf, e.Offset)
 	buf = appendUint32(buf, e.Length)
@@ -26,6 +44,10 @@ func (e *Extent) MarshalBinary() ([]byte, error) {
 }
 
 func (e *Extent) UnmarshalBinary(data []byte) error {
+	if len(data) == 4 {
+		*e = Extent{Length: binary.BigEndian.Uint32(data)}
+		return nil
+	}
 	if len(data) < 8+64 {
 		return fmt.Errorf("too short to be extent: %q", data)
 	}
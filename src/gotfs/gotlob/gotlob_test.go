@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.brendoncarroll.net/exp/streams"
 
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotkv"
@@ -137,6 +138,52 @@ func TestCopyExtents(t *testing.T) {
 	testutil.StreamsEqual(t, expected, actual)
 }
 
+func TestHoles(t *testing.T) {
+	t.Parallel()
+	ctx := testutil.Context(t)
+	ag := newMach(t)
+	ms, ds := stores.NewMem(), stores.NewMem()
+	const holeSize = 10 * MinZeroRun
+	data := make([]byte, 2*holeSize)
+	copy(data[holeSize:], "not zeros")
+
+	b := ag.NewBuilder(ctx, ms, ds)
+	require.NoError(t, b.SetPrefix([]byte("0")))
+	_, err := b.Write(data)
+	require.NoError(t, err)
+	root, err := b.Finish(ctx)
+	require.NoError(t, err)
+
+	var holes int
+	it := ag.gotkv.NewIterator(ms, root, gotkv.TotalSpan())
+	require.NoError(t, streams.ForEach[gotkv.Entry](ctx, it, func(ent gotkv.Entry) error {
+		ext, err := ParseExtent(ent.Value)
+		require.NoError(t, err)
+		if ext.IsHole() {
+			holes++
+		}
+		return nil
+	}))
+	require.Equal(t, 2, holes)
+
+	r, err := ag.NewReader(ctx, ms, ds, root, []byte("0"))
+	require.NoError(t, err)
+	actual, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, actual)
+
+	// the data is in the block after the first hole, and the second hole starts on the next block.
+	offset, err := r.Seek(0, SeekData)
+	require.NoError(t, err)
+	require.EqualValues(t, holeSize, offset)
+	offset, err = r.Seek(offset, SeekHole)
+	require.NoError(t, err)
+	require.EqualValues(t, holeSize+ZeroBlockSize, offset)
+	offset, err = r.Seek(offset, SeekData)
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), offset)
+}
+
 func newMach(t testing.TB, opts ...Option) Machine {
 	gkv := gotkv.NewMachine(gotkv.Params{MeanSize: 1 << 13, MaxSize: 1 << 20})
 	dop := gdat.NewMachine(gdat.Params{})
@@ -57,6 +57,8 @@ func NewMachine(gkvop *gotkv.Machine, dop *gdat.Machine, opts ...Option) Machine
 	return o
 }
 
+// CreateExtents chunks and stores the data from r, and returns Extents for it.
+// Long runs of zeros are returned as holes.
 func (a *Machine) CreateExtents(ctx context.Context, ds stores.RW, r io.Reader) ([]*Extent, error) {
 	var exts []*Extent
 	chunker := a.newChunker(func(data []byte) error {
@@ -69,7 +71,24 @@ func (a *Machine) CreateExtents(ctx context.Context, ds stores.RW, r io.Reader)
 		exts = append(exts, ext)
 		return nil
 	})
-	if _, err := io.Copy(chunker, r); err != nil {
+	zs := zeroSplitter{
+		onData: func(data []byte) error {
+			_, err := chunker.Write(data)
+			return err
+		},
+		onZeros: func(n uint64) error {
+			if err := chunker.Flush(); err != nil {
+				return err
+			}
+			metrics.AddInt(ctx, "data_in", int(n), units.Bytes)
+			exts = append(exts, holeExtents(n)...)
+			return nil
+		},
+	}
+	if _, err := io.Copy(&zs, r); err != nil {
+		return nil, err
+	}
+	if err := zs.Flush(); err != nil {
 		return nil, err
 	}
 	if err := chunker.Flush(); err != nil {
@@ -83,6 +102,10 @@ func (a *Machine) SizeOf(ctx context.Context, ms stores.RO, root Root, prefix []
 	if err != nil {
 		return 0, err
 	}
+	if key == nil {
+		// an empty object has no extents.
+		return 0, nil
+	}
 	_, offset, err := ParseExtentKey(key)
 	if err != nil {
 		return 0, err
@@ -108,7 +131,19 @@ func (ag *Machine) post(ctx context.Context, s stores.RW, data []byte) (*Extent,
 	return &Extent{Offset: 0, Length: uint32(len(data)), Ref: ref}, nil
 }
 
+// getExtentF calls fn with the data in ext.
+// For a hole, fn is called with zeros, possibly more than once.
 func (ag *Machine) getExtentF(ctx context.Context, ds stores.RO, ext *Extent, fn func([]byte) error) error {
+	if ext.IsHole() {
+		for n := int(ext.Length); n > 0; {
+			k := min(n, len(zeroBlock))
+			if err := fn(zeroBlock[:k]); err != nil {
+				return err
+			}
+			n -= k
+		}
+		return nil
+	}
 	return ag.gdat.GetF(ctx, ds, ext.Ref, func(data []byte) error {
 		if err := checkExtentBounds(ext, len(data)); err != nil {
 			return err
@@ -12,6 +12,16 @@ import (
 
 var _ io.ReadSeeker = &Reader{}
 
+// Additional values for whence in Reader.Seek, with the same meaning as for lseek on Linux.
+const (
+	// SeekData seeks to the first offset at or after offset which is not in a hole.
+	// If there is no more data, it seeks to the end of the object.
+	SeekData = 3
+	// SeekHole seeks to the first offset at or after offset which is in a hole.
+	// The end of the object counts as a hole.
+	SeekHole = 4
+)
+
 type Reader struct {
 	ctx    context.Context
 	a      *Machine
@@ -52,6 +62,12 @@ func (r *Reader) Seek(offset int64, whence int) (int64, error) {
 		next = r.offset + offset
 	case io.SeekEnd:
 		next = int64(size) - offset
+	case SeekData, SeekHole:
+		n, err := r.seekHole(uint64(offset), whence == SeekHole)
+		if err != nil {
+			return r.offset, err
+		}
+		next = int64(n)
 	default:
 		return r.offset, fmt.Errorf("invalid value for whence %d", whence)
 	}
@@ -118,6 +134,11 @@ func (r *Reader) readFromIterator(ctx context.Context, it *gotkv.Iterator, ds st
 	if start < extentStart {
 		return 0, fmt.Errorf("incorrect extent extentStart=%d asked for start=%d", extentStart, start)
 	}
+	if ext.IsHole() {
+		n := int(min(uint64(len(buf)), extentEnd-start))
+		clear(buf[:n])
+		return n, nil
+	}
 	var n int
 	if err := r.a.getExtentF(ctx, ds, ext, func(data []byte) error {
 		n += copy(buf, data[start-extentStart:])
@@ -127,3 +148,41 @@ func (r *Reader) readFromIterator(ctx context.Context, it *gotkv.Iterator, ds st
 	}
 	return n, err
 }
+
+// seekHole returns the first offset at or after offset which is in a hole, or is not, depending on hole.
+func (r *Reader) seekHole(offset uint64, hole bool) (uint64, error) {
+	it := r.a.gotkv.NewIterator(r.ms, r.root, gotkv.PrefixSpan(r.prefix))
+	gteq := make([]byte, 0, gotkv.MaxKeySize)
+	gteq = appendKey(gteq, r.prefix, offset)
+	if err := it.Seek(r.ctx, gteq); err != nil {
+		return 0, err
+	}
+	end := offset
+	var ent gotkv.Entry
+	for {
+		if err := streams.NextUnit(r.ctx, it, &ent); err != nil {
+			if streams.IsEOS(err) {
+				return end, nil
+			}
+			return 0, err
+		}
+		if !r.a.keyFilter(ent.Key) {
+			continue
+		}
+		_, extentEnd, err := ParseExtentKey(ent.Key)
+		if err != nil {
+			return 0, err
+		}
+		if extentEnd <= offset {
+			continue
+		}
+		ext, err := ParseExtent(ent.Value)
+		if err != nil {
+			return 0, err
+		}
+		if ext.IsHole() == hole {
+			return max(offset, extentEnd-uint64(ext.Length)), nil
+		}
+		end = extentEnd
+	}
+}
@@ -0,0 +1,159 @@
+package gotlob
+
+import (
+	"bytes"
+	"math"
+)
+
+const (
+	// ZeroBlockSize is the granularity at which runs of zeros are detected.
+	// Blocks are aligned to the start of the object.
+	ZeroBlockSize = 4096
+	// MinZeroRun is the shortest run of zeros which will be stored as a hole, instead of as data.
+	MinZeroRun = 16 * ZeroBlockSize
+	// maxHoleLength is the longest hole that fits in a single Extent.
+	maxHoleLength = (math.MaxUint32 / ZeroBlockSize) * ZeroBlockSize
+)
+
+var zeroBlock [ZeroBlockSize]byte
+
+func isZeros(data []byte) bool {
+	return bytes.Equal(data, zeroBlock[:len(data)])
+}
+
+// holeExtents returns the Extents for a hole of length n.
+func holeExtents(n uint64) []*Extent {
+	var exts []*Extent
+	for n > 0 {
+		l := min(n, maxHoleLength)
+		exts = append(exts, NewHole(uint32(l)))
+		n -= l
+	}
+	return exts
+}
+
+// zeroSplitter passes data written to it through to onData,
+// except for runs of at least MinZeroRun zeros, which are passed to onZeros instead.
+type zeroSplitter struct {
+	onData  func(data []byte) error
+	onZeros func(n uint64) error
+
+	// offset is the offset of the next byte passed to onData or onZeros.
+	// The owner can set it when nothing is pending, so that blocks line up with an object.
+	offset uint64
+	// zeros is the length of a run of whole zero blocks, which has not been passed on yet.
+	zeros uint64
+	// block is a partial block, starting at offset+zeros.
+	block []byte
+}
+
+func (zs *zeroSplitter) Write(data []byte) (int, error) {
+	n := len(data)
+	for len(data) > 0 {
+		if len(zs.block) == 0 {
+			if rem := (zs.offset + zs.zeros) % ZeroBlockSize; rem != 0 {
+				// not aligned, pass data through until the next block.
+				k := min(ZeroBlockSize-int(rem), len(data))
+				if err := zs.flushZeros(); err != nil {
+					return 0, err
+				}
+				if err := zs.writeData(data[:k]); err != nil {
+					return 0, err
+				}
+				data = data[k:]
+				continue
+			}
+			if len(data) >= ZeroBlockSize {
+				if err := zs.writeBlock(data[:ZeroBlockSize]); err != nil {
+					return 0, err
+				}
+				data = data[ZeroBlockSize:]
+				continue
+			}
+		}
+		k := min(ZeroBlockSize-len(zs.block), len(data))
+		zs.block = append(zs.block, data[:k]...)
+		data = data[k:]
+		if len(zs.block) == ZeroBlockSize {
+			if err := zs.writeBlock(zs.block); err != nil {
+				return 0, err
+			}
+			zs.block = zs.block[:0]
+		}
+	}
+	return n, nil
+}
+
+// WriteZeros is like writing n zeros, but does not need them in memory.
+func (zs *zeroSplitter) WriteZeros(n uint64) error {
+	for n > 0 {
+		if len(zs.block) == 0 && (zs.offset+zs.zeros)%ZeroBlockSize == 0 && n >= ZeroBlockSize {
+			k := n - n%ZeroBlockSize
+			zs.zeros += k
+			n -= k
+			continue
+		}
+		k := min(n, ZeroBlockSize)
+		if _, err := zs.Write(zeroBlock[:k]); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
+
+// Flush passes on everything which is pending.
+func (zs *zeroSplitter) Flush() error {
+	if err := zs.flushZeros(); err != nil {
+		return err
+	}
+	if len(zs.block) > 0 {
+		if err := zs.writeData(zs.block); err != nil {
+			return err
+		}
+		zs.block = zs.block[:0]
+	}
+	return nil
+}
+
+// pending returns true if there is anything which has not been passed on yet.
+func (zs *zeroSplitter) pending() bool {
+	return zs.zeros > 0 || len(zs.block) > 0
+}
+
+func (zs *zeroSplitter) writeBlock(block []byte) error {
+	if isZeros(block) {
+		zs.zeros += uint64(len(block))
+		return nil
+	}
+	if err := zs.flushZeros(); err != nil {
+		return err
+	}
+	return zs.writeData(block)
+}
+
+func (zs *zeroSplitter) writeData(data []byte) error {
+	zs.offset += uint64(len(data))
+	return zs.onData(data)
+}
+
+func (zs *zeroSplitter) flushZeros() error {
+	n := zs.zeros
+	if n == 0 {
+		return nil
+	}
+	zs.zeros = 0
+	if n < MinZeroRun {
+		// too short to be worth a hole.
+		for n > 0 {
+			k := min(n, ZeroBlockSize)
+			if err := zs.writeData(zeroBlock[:k]); err != nil {
+				return err
+			}
+			n -= k
+		}
+		return nil
+	}
+	zs.offset += n
+	return zs.onZeros(n)
+}
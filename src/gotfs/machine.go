@@ -195,7 +195,7 @@ func (mach *Machine) ForEach(ctx context.Context, s stores.RO, root Root, p stri
 			if err != nil {
 				return err
 			}
-			return fn(p, md)
+			return fn(key.Path(), md)
 		}
 		return nil
 	}
@@ -241,6 +241,40 @@ func (mach *Machine) Graft(ctx context.Context, ss RW, root Root, p string, bran
 	})
 }
 
+// Move moves the file or directory at from, and everything beneath it, to the path to.
+// Only the metadata is re-keyed, the extents are reused as they are, so no data is read or written.
+// The parent of to must be an existing directory, and nothing can exist at to.
+func (mach *Machine) Move(ctx context.Context, ss RW, root Root, from, to string) (*Root, error) {
+	from, to = cleanPath(from), cleanPath(to)
+	if from == "" {
+		return nil, fmt.Errorf("cannot move the root")
+	}
+	if from == to {
+		return &root, nil
+	}
+	if strings.HasPrefix(to+string(Sep), from+string(Sep)) {
+		return nil, fmt.Errorf("cannot move %q beneath itself, to %q", from, to)
+	}
+	if _, err := mach.GetInfo(ctx, ss.Metadata, root, from); err != nil {
+		return nil, err
+	}
+	if err := mach.checkNoEntry(ctx, ss.Metadata, root, to); err != nil {
+		return nil, err
+	}
+	if _, err := mach.GetDirInfo(ctx, ss.Metadata, root, parentPath(to)); err != nil {
+		return nil, err
+	}
+	branch, err := mach.Pick(ctx, ss.Metadata, root, from)
+	if err != nil {
+		return nil, err
+	}
+	root2, err := mach.RemoveAll(ctx, ss.Metadata, root, from)
+	if err != nil {
+		return nil, err
+	}
+	return mach.Graft(ctx, ss, *root2, to, *branch)
+}
+
 func (mach *Machine) addPrefix(root Root, p string) gotkv.Root {
 	prefix := pathPrefixNoTrail(nil, p)
 	if len(prefix) == 0 {
@@ -331,8 +365,10 @@ func (mach *Machine) Check(ctx context.Context, ms stores.RO, root Root, checkDa
 			if lastOffset != nil && endAt <= *lastOffset {
 				return fmt.Errorf("part offsets not monotonic")
 			}
-			if err := checkData(ext.Ref); err != nil {
-				return err
+			if !ext.IsHole() {
+				if err := checkData(ext.Ref); err != nil {
+					return err
+				}
 			}
 			lastPath = &p
 			lastOffset = &endAt
@@ -0,0 +1,146 @@
+package gotfs
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gotvc/got/src/gotkv"
+	"go.brendoncarroll.net/exp/streams"
+)
+
+// Merge performs a three-way merge of left and right, which both descend from base.
+// Changes are tracked per path; a path changed on only one side takes that side's version.
+// A path changed differently on both sides is a conflict, and left's version is kept
+// in the returned Root.
+// The conflicting paths are returned in key order.
+func (mach *Machine) Merge(ctx context.Context, ss RW, base, left, right Root) (*Root, []string, error) {
+	leftChanged, leftDeleted, err := mach.changedPaths(ctx, ss, base, left)
+	if err != nil {
+		return nil, nil, err
+	}
+	rightChanged, rightDeleted, err := mach.changedPaths(ctx, ss, base, right)
+	if err != nil {
+		return nil, nil, err
+	}
+	different, _, err := mach.changedPaths(ctx, ss, left, right)
+	if err != nil {
+		return nil, nil, err
+	}
+	inLeft := toSet(leftChanged)
+	inRight := toSet(rightChanged)
+	inDifferent := toSet(different)
+
+	var conflicts []string
+	var takeRight []string
+	for _, p := range different {
+		_, l := inLeft[p]
+		_, r := inRight[p]
+		switch {
+		case l && r:
+			conflicts = append(conflicts, p)
+		case r:
+			takeRight = append(takeRight, p)
+		}
+	}
+	// A directory deleted on one side conflicts with any change beneath it on the other side.
+	// The whole subtree is left as it is in left.
+	var deleted []string
+	for _, p := range slices.Concat(leftDeleted, rightDeleted) {
+		if _, yes := inDifferent[p]; !yes || slices.Contains(deleted, p) {
+			continue
+		}
+		if hasDescendent(different, p, func(x string) bool {
+			_, l := inLeft[x]
+			_, r := inRight[x]
+			return l != r
+		}) {
+			deleted = append(deleted, p)
+		}
+	}
+	for _, p := range deleted {
+		if !slices.Contains(conflicts, p) {
+			conflicts = append(conflicts, p)
+		}
+		takeRight = slices.DeleteFunc(takeRight, func(x string) bool {
+			return x == p || isDescendent(x, p)
+		})
+	}
+	slices.SortFunc(conflicts, comparePaths)
+
+	var segs []Segment
+	var begin []byte
+	for _, p := range takeRight {
+		span := entrySpan(p)
+		segs = append(segs,
+			Segment{Span: gotkv.Span{Begin: begin, End: span.Begin}, Contents: left.ToGotKV()},
+			Segment{Span: span, Contents: right.ToGotKV()},
+		)
+		begin = span.End
+	}
+	segs = append(segs, Segment{Span: gotkv.Span{Begin: begin, End: nil}, Contents: left.ToGotKV()})
+	out, err := mach.Splice(ctx, ss, segs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, conflicts, nil
+}
+
+// changedPaths returns every path with an entry that differs between a and b, in key order.
+// The paths which have an Info in a, but not in b are also returned as deleted.
+func (mach *Machine) changedPaths(ctx context.Context, ss RW, a, b Root) (changed, deleted []string, _ error) {
+	d := mach.NewDiffer(ss.Metadata, a, b)
+	if err := streams.ForEach(ctx, d, func(de DiffEntry) error {
+		p := de.Key.Path()
+		if len(changed) == 0 || changed[len(changed)-1] != p {
+			changed = append(changed, p)
+		}
+		if de.Key.IsInfo() && de.Left.Ok && !de.Right.Ok {
+			deleted = append(deleted, p)
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	return changed, deleted, nil
+}
+
+// entrySpan returns the span containing the Info and Extents for p, but none of its children.
+func entrySpan(p string) gotkv.Span {
+	prefix := newInfoKey(p).Prefix(nil)
+	return gotkv.Span{
+		Begin: append(slices.Clone(prefix), 0x00),
+		End:   append(slices.Clone(prefix), 0x01),
+	}
+}
+
+func comparePaths(a, b string) int {
+	return bytes.Compare(newInfoKey(a).Marshal(nil), newInfoKey(b).Marshal(nil))
+}
+
+// hasDescendent returns true if any of ps beneath p satisfies fn.
+func hasDescendent(ps []string, p string, fn func(string) bool) bool {
+	for _, x := range ps {
+		if isDescendent(x, p) && fn(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDescendent returns true if x is strictly beneath p.
+func isDescendent(x, p string) bool {
+	if p == "" {
+		return x != ""
+	}
+	return strings.HasPrefix(x, p+string(Sep))
+}
+
+func toSet(xs []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(xs))
+	for _, x := range xs {
+		m[x] = struct{}{}
+	}
+	return m
+}
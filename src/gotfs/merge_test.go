@@ -0,0 +1,94 @@
+package gotfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	ctx, mach, s := setup(t)
+	ss := RW{s, s}
+	mkfs := func(files map[string]string) Root {
+		x, err := mach.NewEmpty(ctx, s, 0o755)
+		require.NoError(t, err)
+		for p, data := range files {
+			x, err = mach.MkdirAll(ctx, s, *x, parentPath(p))
+			require.NoError(t, err)
+			x, err = mach.PutFile(ctx, ss, *x, p, strings.NewReader(data))
+			require.NoError(t, err)
+		}
+		return *x
+	}
+	tcs := []struct {
+		Base, Left, Right map[string]string
+
+		Expect    map[string]string
+		Conflicts []string
+	}{
+		{
+			Base:   map[string]string{"a.txt": "a"},
+			Left:   map[string]string{"a.txt": "a", "b.txt": "b"},
+			Right:  map[string]string{"a.txt": "a", "c.txt": "c"},
+			Expect: map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"},
+		},
+		{
+			Base:   map[string]string{"a.txt": "a", "b.txt": "b"},
+			Left:   map[string]string{"a.txt": "a2", "b.txt": "b"},
+			Right:  map[string]string{"a.txt": "a"},
+			Expect: map[string]string{"a.txt": "a2"},
+		},
+		{
+			Base:   map[string]string{"d/a.txt": "a"},
+			Left:   map[string]string{"d/a.txt": "a2"},
+			Right:  map[string]string{"d/a.txt": "a2", "e/b.txt": "b"},
+			Expect: map[string]string{"d/a.txt": "a2", "e/b.txt": "b"},
+		},
+		{
+			Base:      map[string]string{"a.txt": "a"},
+			Left:      map[string]string{"a.txt": "left"},
+			Right:     map[string]string{"a.txt": "right"},
+			Expect:    map[string]string{"a.txt": "left"},
+			Conflicts: []string{"a.txt"},
+		},
+		{
+			Base:      map[string]string{"d/a.txt": "a", "x.txt": "x"},
+			Left:      map[string]string{"x.txt": "x"},
+			Right:     map[string]string{"d/a.txt": "a", "d/b.txt": "b", "x.txt": "x2"},
+			Expect:    map[string]string{"x.txt": "x2"},
+			Conflicts: []string{"d"},
+		},
+	}
+	for i, tc := range tcs {
+		base, left, right := mkfs(tc.Base), mkfs(tc.Left), mkfs(tc.Right)
+		out, conflicts, err := mach.Merge(ctx, ss, base, left, right)
+		require.NoError(t, err, "case %d", i)
+		require.Equal(t, tc.Conflicts, conflicts, "case %d", i)
+
+		actual := map[string]string{}
+		for p := range tc.Expect {
+			data, err := mach.ReadFile(ctx, ss.RO(), *out, p, 1024)
+			require.NoError(t, err, "case %d", i)
+			actual[p] = string(data)
+		}
+		require.Equal(t, tc.Expect, actual, "case %d", i)
+		for p := range concatKeys(tc.Left, tc.Right) {
+			if _, ok := tc.Expect[p]; !ok {
+				yes, err := mach.Exists(ctx, s, *out, p)
+				require.NoError(t, err)
+				require.False(t, yes, "case %d: %q should not exist", i, p)
+			}
+		}
+	}
+}
+
+func concatKeys(ms ...map[string]string) map[string]struct{} {
+	out := map[string]struct{}{}
+	for _, m := range ms {
+		for k := range m {
+			out[k] = struct{}{}
+		}
+	}
+	return out
+}
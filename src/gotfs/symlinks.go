@@ -0,0 +1,89 @@
+package gotfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// MaxSymlinkTargetLen is the maximum length of the target of a symbolic link.
+const MaxSymlinkTargetLen = 4096
+
+// IsSymlink returns true if the Info is for a symbolic link.
+func (info *Info) IsSymlink() bool {
+	return info.Mode&fs.ModeSymlink != 0
+}
+
+// Symlink creates a metadata entry for a symbolic link at p, which points to target.
+// The target is stored as the content of the entry, it is never resolved.
+func (b *Builder) Symlink(p, target string) error {
+	p = cleanPath(p)
+	if b.IsFinished() {
+		return errBuilderIsFinished()
+	}
+	if err := checkSymlinkTarget(target); err != nil {
+		return err
+	}
+	if err := b.writeInfo(p, &Info{Mode: fs.ModeSymlink | 0o777}); err != nil {
+		return err
+	}
+	if err := b.b.SetPrefix(newInfoKey(p).Prefix(nil)); err != nil {
+		return err
+	}
+	_, err := b.b.Write([]byte(target))
+	return err
+}
+
+// NewSymlink creates a filesystem with a symbolic link to target at the root.
+func (mach *Machine) NewSymlink(ctx context.Context, ss RW, target string) (*Root, error) {
+	b := mach.NewBuilder(ctx, ss)
+	if err := b.Symlink("", target); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// PutSymlink creates or replaces the entry at p with a symbolic link to target.
+func (mach *Machine) PutSymlink(ctx context.Context, ss RW, x Root, p, target string) (*Root, error) {
+	linkRoot, err := mach.NewSymlink(ctx, ss, target)
+	if err != nil {
+		return nil, err
+	}
+	return mach.Graft(ctx, ss, x, p, *linkRoot)
+}
+
+// Readlink returns the target of the symbolic link at p.
+func (mach *Machine) Readlink(ctx context.Context, ss RO, x Root, p string) (string, error) {
+	p = cleanPath(p)
+	info, err := mach.GetInfo(ctx, ss.Metadata, x, p)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsSymlink() {
+		return "", fmt.Errorf("%s is not a symbolic link", p)
+	}
+	r, err := mach.lob.NewReader(ctx, ss.Metadata, ss.Data, x.toGotKV(), newInfoKey(p).Prefix(nil))
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxSymlinkTargetLen+1))
+	if err != nil {
+		return "", err
+	}
+	target := string(data)
+	if err := checkSymlinkTarget(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+func checkSymlinkTarget(target string) error {
+	switch {
+	case target == "":
+		return fmt.Errorf("symbolic link target cannot be empty")
+	case len(target) > MaxSymlinkTargetLen:
+		return fmt.Errorf("symbolic link target exceeds max length of %d", MaxSymlinkTargetLen)
+	}
+	return nil
+}
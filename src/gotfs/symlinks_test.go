@@ -0,0 +1,48 @@
+package gotfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymlink(t *testing.T) {
+	ctx, ag, s := setup(t)
+	ss := RW{s, s}
+	x, err := ag.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	x, err = ag.CreateFile(ctx, ss, *x, "a.txt", strings.NewReader("a"))
+	require.NoError(t, err)
+	x, err = ag.PutSymlink(ctx, ss, *x, "dir/link", "../a.txt")
+	require.NoError(t, err)
+
+	info, err := ag.GetInfo(ctx, s, *x, "dir/link")
+	require.NoError(t, err)
+	require.True(t, info.IsSymlink())
+	target, err := ag.Readlink(ctx, ss.RO(), *x, "dir/link")
+	require.NoError(t, err)
+	require.Equal(t, "../a.txt", target)
+
+	// symlinks are not regular files, and regular files are not symlinks.
+	_, err = ag.NewReader(ctx, ss.RO(), *x, "dir/link")
+	require.Error(t, err)
+	_, err = ag.Readlink(ctx, ss.RO(), *x, "a.txt")
+	require.Error(t, err)
+
+	// the builder can write files after a symlink
+	b := ag.NewBuilder(ctx, ss)
+	require.NoError(t, b.Mkdir("", 0o755))
+	require.NoError(t, b.Symlink("b", "a.txt"))
+	require.NoError(t, b.BeginFile("c.txt", 0o644))
+	_, err = b.Write([]byte("c"))
+	require.NoError(t, err)
+	y, err := b.Finish()
+	require.NoError(t, err)
+	target, err = ag.Readlink(ctx, ss.RO(), *y, "b")
+	require.NoError(t, err)
+	require.Equal(t, "a.txt", target)
+	data, err := ag.ReadFile(ctx, ss.RO(), *y, "c.txt", 10)
+	require.NoError(t, err)
+	require.Equal(t, "c", string(data))
+}
@@ -18,6 +18,9 @@ func (mach *Machine) Sync(ctx context.Context, src RO, dst WO, root Root) error
 			if err != nil {
 				return err
 			}
+			if ext.IsHole() {
+				return nil
+			}
 			return gdat.Copy(ctx, src.Data, dst.Data, ext.Ref)
 		}
 		return nil
@@ -32,6 +35,9 @@ func (mach *Machine) Populate(ctx context.Context, s stores.RO, root Root, mdSet
 			if err != nil {
 				return err
 			}
+			if ext.IsHole() {
+				return nil
+			}
 			return dataSet.Add(ctx, ext.Ref.CID)
 		}
 		return nil
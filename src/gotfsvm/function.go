@@ -225,6 +225,13 @@ func (fb *FnBuilder) MkdirAll(base Expr[gotfs.Root], p string, mode fs.FileMode)
 	return Expr[gotfs.Root]{fb.fc.append3(OpCode_MKDIRALL, base.i, pathV.i, modeV.i)}
 }
 
+// Move moves the file or directory at from in base to the path to.
+func (fb *FnBuilder) Move(base Expr[gotfs.Root], from, to string) Expr[gotfs.Root] {
+	fromV := fb.Path(from)
+	toV := fb.Path(to)
+	return Expr[gotfs.Root]{fb.fc.append3(OpCode_MOVE, base.i, fromV.i, toV.i)}
+}
+
 // I is a single instruction, it represents a node in a computation DAG.
 type I uint32
 
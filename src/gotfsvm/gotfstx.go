@@ -164,6 +164,25 @@ func (m *Machine) eval(ectx *evalCtx, expr Vertex) (Value, error) {
 			return nil, err
 		}
 		return &Value_Root{Root: *result}, nil
+	case OpCode_MOVE:
+		rootVal, err := m.evalRoot(ectx, args[0])
+		if err != nil {
+			return nil, err
+		}
+		from, err := m.evalPath(ectx, args[1])
+		if err != nil {
+			return nil, err
+		}
+		to, err := m.evalPath(ectx, args[2])
+		if err != nil {
+			return nil, err
+		}
+		ss := mkRW(ectx.Src, ectx.Dst)
+		result, err := m.gotfs.Move(ctx, ss, rootVal.Root, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return &Value_Root{Root: *result}, nil
 	case OpCode_CONCAT:
 		segs, err := m.flattenConcat(ectx, nil, expr)
 		if err != nil {
@@ -67,6 +67,11 @@ const (
 	// MkdirAll creates the directory at path and any of its ancestors if necessary.
 	// (Root, Path, FileMode) -> Root
 	OpCode_MKDIRALL
+
+	// Move moves everything at the first path to the second path, without touching the data.
+	// The parent of the second path must already exist in root.
+	// (Root, Path, Path) -> Root
+	OpCode_MOVE
 )
 
 func (o OpCode) Arity() int {
@@ -95,6 +100,8 @@ func (o OpCode) String() string {
 		return "promote"
 	case OpCode_MKDIRALL:
 		return "mkdirall"
+	case OpCode_MOVE:
+		return "move"
 	default:
 		return fmt.Sprintf("UNKNOWN(%d)", o)
 	}
@@ -68,6 +68,66 @@ func TestPutGetMany(t *testing.T) {
 	}
 }
 
+func TestCompactEdits(t *testing.T) {
+	put := func(k string) Edit {
+		return Edit{
+			Span:    SingleKeySpan([]byte(k)),
+			Entries: []Entry{{Key: []byte(k), Value: []byte(k)}},
+		}
+	}
+	del := func(begin, end string) Edit {
+		return Edit{Span: Span{Begin: []byte(begin), End: []byte(end)}}
+	}
+	spans := func(edits []Edit) (ret []Span) {
+		for _, e := range edits {
+			ret = append(ret, e.Span)
+		}
+		return ret
+	}
+
+	// edits which are in order and do not overlap are returned as is.
+	disjoint := []Edit{put("a"), put("b"), del("c", "d"), del("d", "e"), put("f")}
+	require.True(t, editsDisjoint(disjoint))
+	require.Equal(t, disjoint, compactEdits(disjoint))
+
+	// out of order edits are sorted.
+	require.False(t, editsDisjoint([]Edit{put("b"), put("a")}))
+	require.Equal(t, spans([]Edit{put("a"), put("b")}), spans(compactEdits([]Edit{put("b"), put("a")})))
+
+	// a span with no end overlaps everything after it.
+	require.False(t, editsDisjoint([]Edit{{Span: Span{Begin: []byte("a")}}, put("b")}))
+
+	// later edits override the parts of earlier edits which they overlap.
+	out := compactEdits([]Edit{del("a", "e"), put("c")})
+	require.Equal(t, []Span{
+		{Begin: []byte("a"), End: []byte("c")},
+		put("c").Span,
+		{Begin: KeyAfter([]byte("c")), End: []byte("e")},
+	}, spans(out))
+}
+
+func TestEditMany(t *testing.T) {
+	ctx, s, x := testSetup(t)
+	ag := newTestMachine(t)
+	const N = 200
+	var edits []Edit
+	for i := 0; i < N; i++ {
+		key := []byte(fmt.Sprintf("%04d-key", i))
+		edits = append(edits, Edit{
+			Span:    SingleKeySpan(key),
+			Entries: []Entry{{Key: key, Value: key}},
+		})
+	}
+	x, err := ag.Edit(ctx, s, x, edits...)
+	require.NoError(t, err)
+	for i := 0; i < N; i++ {
+		key := []byte(fmt.Sprintf("%04d-key", i))
+		actualValue, err := ag.Get(ctx, s, x, key)
+		require.NoError(t, err)
+		require.Equal(t, key, actualValue)
+	}
+}
+
 func testSetup(t *testing.T) (context.Context, stores.RW, Root) {
 	ctx := testutil.Context(t)
 	ag := newTestMachine(t)
@@ -319,6 +319,9 @@ func (a *Machine) Concat(ctx context.Context, s stores.RW, iters ...kvstreams.It
 // compactEdits produces a (potentially longer) list
 // of edits which have non-overlapping Spans
 func compactEdits(edits []Edit) []Edit {
+	if editsDisjoint(edits) {
+		return edits
+	}
 	// stable order matters: later edits override earlier ones
 	var out []Edit
 
@@ -365,6 +368,18 @@ func compactEdits(edits []Edit) []Edit {
 	return out
 }
 
+// editsDisjoint returns true if the edits are already in order, and none of their spans overlap.
+// Such edits do not need to be compacted.
+func editsDisjoint(edits []Edit) bool {
+	for i := 1; i < len(edits); i++ {
+		prev := edits[i-1].Span.End
+		if prev == nil || bytes.Compare(prev, edits[i].Span.Begin) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func minBytes(a, b []byte) []byte {
 	if bytes.Compare(a, b) < 0 {
 		return a
@@ -2,6 +2,7 @@ package gotns
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -50,6 +51,12 @@ type Root struct {
 	// BrokenSet is the set of CIDs which the Space cannot dereference.
 	// This is used to correctly Sync even with incomplete structures.
 	BrokenSet gotkv.Root
+	// Indexes maps mark names to the root of their commit graph index.
+	// It is optional, roots written before it was added will not have it.
+	Indexes gotkv.Root
+	// Logs maps mark names to the log of changes to their target.
+	// It is optional, like Indexes.
+	Logs gotkv.Root
 }
 
 func ParseRoot(data []byte) (*Root, error) {
@@ -65,7 +72,7 @@ func (r *Root) Unmarshal(data []byte) error {
 	if err != nil {
 		return err
 	}
-	bsData, _, err := sbe.ReadLP16(data)
+	bsData, data, err := sbe.ReadLP16(data)
 	if err != nil {
 		return err
 	}
@@ -75,12 +82,33 @@ func (r *Root) Unmarshal(data []byte) error {
 	if err := r.BrokenSet.Unmarshal(bsData); err != nil {
 		return err
 	}
+	if len(data) > 0 {
+		idxData, rest, err := sbe.ReadLP16(data)
+		if err != nil {
+			return err
+		}
+		if err := r.Indexes.Unmarshal(idxData); err != nil {
+			return err
+		}
+		data = rest
+	}
+	if len(data) > 0 {
+		logsData, _, err := sbe.ReadLP16(data)
+		if err != nil {
+			return err
+		}
+		if err := r.Logs.Unmarshal(logsData); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (r Root) Marshal(out []byte) []byte {
 	out = sbe.AppendLP16(out, r.Marks.Marshal(nil))
 	out = sbe.AppendLP16(out, r.BrokenSet.Marshal(nil))
+	out = sbe.AppendLP16(out, r.Indexes.Marshal(nil))
+	out = sbe.AppendLP16(out, r.Logs.Marshal(nil))
 	return out
 }
 
@@ -120,6 +148,10 @@ type Tx struct {
 	kvmach *gotkv.Machine
 
 	kvtx *gotkv.Tx
+	// idxtx holds the commit graph index for each mark.
+	idxtx *gotkv.Tx
+	// logtx holds the log of target changes for each mark.
+	logtx *gotkv.Tx
 }
 
 func (tx *Tx) loadKV(ctx context.Context) error {
@@ -135,6 +167,8 @@ func (tx *Tx) loadKV(ctx context.Context) error {
 	}
 	if len(root) == 0 {
 		tx.kvtx = tx.kvmach.NewTxEmpty(tx.tx)
+		tx.idxtx = tx.kvmach.NewTxEmpty(tx.tx)
+		tx.logtx = tx.kvmach.NewTxEmpty(tx.tx)
 	} else {
 		r, err := ParseRoot(root)
 		if err != nil {
@@ -142,6 +176,8 @@ func (tx *Tx) loadKV(ctx context.Context) error {
 		}
 		kvr := r.Marks
 		tx.kvtx = tx.kvmach.NewTx(tx.tx, kvr)
+		tx.idxtx = tx.kvmach.NewTx(tx.tx, r.Indexes)
+		tx.logtx = tx.kvmach.NewTx(tx.tx, r.Logs)
 	}
 	return nil
 }
@@ -163,6 +199,8 @@ func (tx *Tx) Get(ctx context.Context, name string) (*MarkState, error) {
 	return &ms, nil
 }
 
+// Put sets the state of the mark at name.
+// It is an error to change the target or tag annotations of a tag which already has a target.
 func (tx *Tx) Put(ctx context.Context, name string, b MarkState) error {
 	if err := tx.loadKV(ctx); err != nil {
 		return err
@@ -170,6 +208,13 @@ func (tx *Tx) Put(ctx context.Context, name string, b MarkState) error {
 	if err := gotcore.CheckName(name); err != nil {
 		return err
 	}
+	if prev, err := tx.Get(ctx, name); err != nil {
+		return err
+	} else if prev != nil {
+		if err := gotcore.CheckTagChange(prev.Info, prev.Target, b.Info, b.Target); err != nil {
+			return err
+		}
+	}
 	if !b.Target.IsZero() {
 		if yes, err := bcsdk.ExistsUnit(ctx, tx.tx, b.Target.CID); err != nil {
 			return err
@@ -180,13 +225,150 @@ func (tx *Tx) Put(ctx context.Context, name string, b MarkState) error {
 	return tx.kvtx.Put(ctx, []byte(name), b.Marshal(nil))
 }
 
+// Delete deletes the mark at name, along with its index and log.
+// It is an error to delete a tag which has a target, use ForceDelete for that.
 func (tx *Tx) Delete(ctx context.Context, name string) error {
+	if prev, err := tx.Get(ctx, name); err != nil {
+		return err
+	} else if prev != nil && prev.Info.IsTag() && !prev.Target.IsZero() {
+		return fmt.Errorf("%w: cannot delete tag %q without force", gotcore.ErrImmutable, name)
+	}
+	return tx.ForceDelete(ctx, name)
+}
+
+// ForceDelete deletes the mark at name, along with its index and log, even if it is a tag.
+func (tx *Tx) ForceDelete(ctx context.Context, name string) error {
 	if err := tx.loadKV(ctx); err != nil {
 		return err
 	}
+	if err := tx.idxtx.Delete(ctx, []byte(name)); err != nil {
+		return err
+	}
+	if err := tx.deleteLog(ctx, name); err != nil {
+		return err
+	}
 	return tx.kvtx.Delete(ctx, []byte(name))
 }
 
+// GetIndex returns the root of the commit graph index for the mark at name.
+// If there is no index, then the zero value is returned.
+func (tx *Tx) GetIndex(ctx context.Context, name string) (gotkv.Root, error) {
+	if err := tx.loadKV(ctx); err != nil {
+		return gotkv.Root{}, err
+	}
+	var val []byte
+	if found, err := tx.idxtx.Get(ctx, []byte(name), &val); err != nil {
+		return gotkv.Root{}, err
+	} else if !found {
+		return gotkv.Root{}, nil
+	}
+	var root gotkv.Root
+	if err := root.Unmarshal(val); err != nil {
+		return gotkv.Root{}, err
+	}
+	return root, nil
+}
+
+// PutIndex sets the root of the commit graph index for the mark at name.
+func (tx *Tx) PutIndex(ctx context.Context, name string, root gotkv.Root) error {
+	if err := tx.loadKV(ctx); err != nil {
+		return err
+	}
+	return tx.idxtx.Put(ctx, []byte(name), root.Marshal(nil))
+}
+
+// MaxLogLen is the maximum number of entries kept in the log for each mark.
+const MaxLogLen = 100
+
+// GetLog returns the log of target changes for the mark at name, most recent first.
+func (tx *Tx) GetLog(ctx context.Context, name string) ([]gotcore.LogEntry, error) {
+	if err := tx.loadKV(ctx); err != nil {
+		return nil, err
+	}
+	n, err := tx.getLogLen(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var ents []gotcore.LogEntry
+	var val []byte
+	for seq := n; seq > 0 && seq+MaxLogLen > n; seq-- {
+		if found, err := tx.logtx.Get(ctx, logEntryKey(name, seq), &val); err != nil {
+			return nil, err
+		} else if !found {
+			break
+		}
+		var ent gotcore.LogEntry
+		if err := ent.Unmarshal(val); err != nil {
+			return nil, err
+		}
+		ents = append(ents, ent)
+	}
+	return ents, nil
+}
+
+// AppendLog adds ent to the log for the mark at name.
+// Each entry is stored under its own key, so only the new entry is written,
+// and the oldest entry is deleted to keep the log at MaxLogLen.
+func (tx *Tx) AppendLog(ctx context.Context, name string, ent gotcore.LogEntry) error {
+	if err := tx.loadKV(ctx); err != nil {
+		return err
+	}
+	n, err := tx.getLogLen(ctx, name)
+	if err != nil {
+		return err
+	}
+	n++
+	if err := tx.logtx.Put(ctx, logEntryKey(name, n), ent.Marshal(nil)); err != nil {
+		return err
+	}
+	if n > MaxLogLen {
+		if err := tx.logtx.Delete(ctx, logEntryKey(name, n-MaxLogLen)); err != nil {
+			return err
+		}
+	}
+	return tx.logtx.Put(ctx, logLenKey(name), binary.BigEndian.AppendUint64(nil, n))
+}
+
+// deleteLog deletes all of the entries in the log for the mark at name.
+func (tx *Tx) deleteLog(ctx context.Context, name string) error {
+	n, err := tx.getLogLen(ctx, name)
+	if err != nil {
+		return err
+	}
+	for seq := n; seq > 0 && seq+MaxLogLen > n; seq-- {
+		if err := tx.logtx.Delete(ctx, logEntryKey(name, seq)); err != nil {
+			return err
+		}
+	}
+	return tx.logtx.Delete(ctx, logLenKey(name))
+}
+
+// getLogLen returns the number of entries which have ever been appended to the log for the mark at name.
+// This is also the sequence number of the most recent entry.
+func (tx *Tx) getLogLen(ctx context.Context, name string) (uint64, error) {
+	var val []byte
+	if found, err := tx.logtx.Get(ctx, logLenKey(name), &val); err != nil {
+		return 0, err
+	} else if !found {
+		return 0, nil
+	}
+	if len(val) != 8 {
+		return 0, fmt.Errorf("gotns: log length for %q has wrong size %d", name, len(val))
+	}
+	return binary.BigEndian.Uint64(val), nil
+}
+
+// logLenKey is the key in the logs for the length of the log for the mark at name.
+func logLenKey(name string) []byte {
+	return sbe.AppendLP16(nil, []byte(name))
+}
+
+// logEntryKey is the key in the logs for the entry with sequence number seq in the log for the mark at name.
+// The keys for a mark's entries all start with logLenKey, and sort by sequence number.
+func logEntryKey(name string, seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(logLenKey(name), seq)
+}
+
 func (tx *Tx) AllNames(ctx context.Context) iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
 		if err := tx.loadKV(ctx); err != nil {
@@ -231,6 +413,8 @@ func (tx *Tx) Abort(ctx context.Context) error {
 	}
 	tx.tx = nil
 	tx.kvtx = nil
+	tx.idxtx = nil
+	tx.logtx = nil
 	return nil
 }
 
@@ -249,7 +433,15 @@ func (tx *Tx) Commit(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	r := Root{Marks: kvroot, BrokenSet: bsroot}
+	idxroot, err := tx.idxtx.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	logsroot, err := tx.logtx.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	r := Root{Marks: kvroot, BrokenSet: bsroot, Indexes: idxroot, Logs: logsroot}
 	if err := saveRoot(ctx, tx.tx, r); err != nil {
 		return err
 	}
@@ -258,6 +450,8 @@ func (tx *Tx) Commit(ctx context.Context) error {
 	}
 	tx.tx = nil
 	tx.kvtx = nil
+	tx.idxtx = nil
+	tx.logtx = nil
 	return nil
 }
 
@@ -77,6 +77,11 @@ func (s *SpaceTx) Delete(ctx context.Context, name string) error {
 	return s.tx.Delete(ctx, name)
 }
 
+// ForceDelete implements gotcore.Space.
+func (s *SpaceTx) ForceDelete(ctx context.Context, name string) error {
+	return s.tx.ForceDelete(ctx, name)
+}
+
 // Inspect implements gotcore.Space.
 func (s *SpaceTx) Inspect(ctx context.Context, name string) (*gotcore.Info, error) {
 	b, err := s.tx.Get(ctx, name)
@@ -134,6 +139,38 @@ func (s *SpaceTx) SetTarget(ctx context.Context, name string, ref gdat.Ref) erro
 	if err != nil {
 		return err
 	}
+	if mstate == nil {
+		return gotcore.ErrNotExist
+	}
+	prev := mstate.Target
 	mstate.Target = ref
-	return s.tx.Put(ctx, name, *mstate)
+	if err := s.tx.Put(ctx, name, *mstate); err != nil {
+		return err
+	}
+	if prev.Equals(&ref) {
+		return nil
+	}
+	return s.tx.AppendLog(ctx, name, gotcore.NewLogEntry(ctx, prev, ref))
+}
+
+func (s *SpaceTx) GetIndex(ctx context.Context, name string) (gotkv.Root, error) {
+	return s.tx.GetIndex(ctx, name)
+}
+
+func (s *SpaceTx) SetIndex(ctx context.Context, name string, root gotkv.Root) error {
+	if mstate, err := s.tx.Get(ctx, name); err != nil {
+		return err
+	} else if mstate == nil {
+		return gotcore.ErrNotExist
+	}
+	return s.tx.PutIndex(ctx, name, root)
+}
+
+func (s *SpaceTx) GetLog(ctx context.Context, name string) ([]gotcore.LogEntry, error) {
+	if mstate, err := s.tx.Get(ctx, name); err != nil {
+		return nil, err
+	} else if mstate == nil {
+		return nil, gotcore.ErrNotExist
+	}
+	return s.tx.GetLog(ctx, name)
 }
@@ -9,22 +9,87 @@ import (
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotkv"
 	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/testutil"
 	"github.com/gotvc/got/src/internal/volumes"
+	"github.com/stretchr/testify/require"
+	"go.brendoncarroll.net/tai64"
 )
 
 func TestSpace(t *testing.T) {
 	gotcore.TestSpace(t, func(t testing.TB) gotcore.Space {
-		spec := SpaceVolumeSpec()
-		bc, volh := schematests.Setup(t, map[blobcache.SchemaName]schema.Constructor{
-			"": schema.NoneConstructor,
-		}, *spec.Local)
-		vol := &volumes.Blobcache{Service: bc, Handle: volh}
-		dmach := gdat.NewMachine(gdat.Params{})
-		kvmach := gotkv.NewMachine(gotkv.Params{MeanSize: 1 << 13, MaxSize: 1 << 18})
-		return &Space{
-			Volume: vol,
-			DMach:  dmach,
-			KVMach: &kvmach,
+		return newTestSpace(t)
+	})
+}
+
+func TestLog(t *testing.T) {
+	ctx := testutil.Context(t)
+	space := newTestSpace(t)
+	const n = MaxLogLen + 10
+	doTx := func(fn func(tx *Tx) error) {
+		t.Helper()
+		tx, err := BeginTx(ctx, space.DMach, space.KVMach, space.Volume, true)
+		require.NoError(t, err)
+		require.NoError(t, fn(tx))
+		require.NoError(t, tx.Commit(ctx))
+	}
+	checkLog := func(name string, last, count int) {
+		t.Helper()
+		doTx(func(tx *Tx) error {
+			ents, err := tx.GetLog(ctx, name)
+			require.NoError(t, err)
+			require.Len(t, ents, count)
+			for i, ent := range ents {
+				require.Equal(t, tai64.TAI64(last-i), ent.At)
+			}
+			return nil
+		})
+	}
+	// each transaction appends to the log without reading all of it.
+	for i := 1; i <= n; i++ {
+		doTx(func(tx *Tx) error {
+			return tx.AppendLog(ctx, "a", gotcore.LogEntry{At: tai64.TAI64(i)})
+		})
+	}
+	checkLog("a", n, MaxLogLen)
+	// many appends in a single transaction.
+	doTx(func(tx *Tx) error {
+		for i := 1; i <= n; i++ {
+			if err := tx.AppendLog(ctx, "b", gotcore.LogEntry{At: tai64.TAI64(i)}); err != nil {
+				return err
+			}
 		}
+		return nil
 	})
+	checkLog("b", n, MaxLogLen)
+	// a mark name which is a prefix of another does not share its log.
+	doTx(func(tx *Tx) error {
+		return tx.AppendLog(ctx, "ab", gotcore.LogEntry{At: 1})
+	})
+	checkLog("a", n, MaxLogLen)
+	checkLog("ab", 1, 1)
+
+	doTx(func(tx *Tx) error {
+		return tx.Delete(ctx, "a")
+	})
+	checkLog("a", 0, 0)
+	checkLog("b", n, MaxLogLen)
+	doTx(func(tx *Tx) error {
+		return tx.AppendLog(ctx, "a", gotcore.LogEntry{At: 1})
+	})
+	checkLog("a", 1, 1)
+}
+
+func newTestSpace(t testing.TB) *Space {
+	spec := SpaceVolumeSpec()
+	bc, volh := schematests.Setup(t, map[blobcache.SchemaName]schema.Constructor{
+		"": schema.NoneConstructor,
+	}, *spec.Local)
+	vol := &volumes.Blobcache{Service: bc, Handle: volh}
+	dmach := gdat.NewMachine(gdat.Params{})
+	kvmach := gotkv.NewMachine(gotkv.Params{MeanSize: 1 << 13, MaxSize: 1 << 18})
+	return &Space{
+		Volume: vol,
+		DMach:  dmach,
+		KVMach: &kvmach,
+	}
 }
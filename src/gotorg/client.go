@@ -115,6 +115,8 @@ func (c *Client) GetAlias(ctx context.Context, volh blobcache.Handle, name strin
 }
 
 // CreateAlias creates a new alias with a new volume at the specified name.
+// aux is stored with the volume, and should be the JSON encoded gotcore.Info of the mark in the volume.
+// If the Info is for a tag, the alias cannot later be changed to refer to another volume.
 func (c *Client) CreateAlias(ctx context.Context, nsh blobcache.Handle, name string, aux []byte) error {
 	return c.doTx(ctx, nsh, c.ActAs, func(tx *bcsdk.Tx, txn *Txn) error {
 		x, err := loadState(ctx, tx)
@@ -142,6 +144,7 @@ func (c *Client) CreateAlias(ctx context.Context, nsh blobcache.Handle, name str
 			Rights:        ltok.Rights,
 			TokenSecret:   ltok.Secret,
 			HashOfSecrets: [][32]byte{hos},
+			Aux:           aux,
 		}); err != nil {
 			return err
 		}
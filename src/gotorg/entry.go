@@ -2,7 +2,10 @@ package gotorg
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
+	"blobcache.io/blobcache/src/blobcache"
 	"go.brendoncarroll.net/exp/streams"
 
 	"github.com/gotvc/got/src/gotkv"
@@ -33,7 +36,11 @@ func (m *Machine) GetAlias(ctx context.Context, s stores.RO, state State, name s
 }
 
 // PutAlias inserts or overwrites an entry in the branches table.
+// An alias which refers to a tag cannot be changed to refer to a different volume.
 func (m *Machine) PutAlias(ctx context.Context, s stores.RW, state State, entry gotorgop.VolumeAlias, secret *gotorgop.Secret) (*State, error) {
+	if err := m.checkAliasChange(ctx, s, state, entry.Name, entry.Volume); err != nil {
+		return nil, err
+	}
 	mut1 := putAlias(entry)
 	aliasState, err := m.gotkv.Edit(ctx, s, state.VolumeNames, mut1)
 	if err != nil {
@@ -57,6 +64,34 @@ func (m *Machine) DeleteAlias(ctx context.Context, s stores.RW, State State, nam
 	return &State, nil
 }
 
+// checkAliasChange returns gotcore.ErrImmutable if the alias at name would be changed to refer to nextVol,
+// and the volume it currently refers to holds a tag.
+// The gotcore.Info for a volume is read from its Aux data, volumes without it are assumed not to be tags.
+func (m *Machine) checkAliasChange(ctx context.Context, s stores.RO, state State, name string, nextVol blobcache.OID) error {
+	alias, err := m.GetAlias(ctx, s, state, name)
+	if err != nil {
+		return err
+	}
+	if alias == nil || alias.Volume == nextVol {
+		return nil
+	}
+	vent, err := m.GetVolume(ctx, s, state, alias.Volume)
+	if err != nil {
+		return err
+	}
+	if vent == nil || len(vent.Aux) == 0 {
+		return nil
+	}
+	var info gotcore.Info
+	if err := json.Unmarshal(vent.Aux, &info); err != nil {
+		return fmt.Errorf("parsing info for volume %v: %w", alias.Volume, err)
+	}
+	if info.IsTag() {
+		return fmt.Errorf("%w: cannot change the volume for %q", gotcore.ErrImmutable, name)
+	}
+	return nil
+}
+
 func putAlias(entry gotorgop.VolumeAlias) gotkv.Edit {
 	k := entry.Key(nil)
 	return gotkv.Edit{
@@ -301,6 +301,23 @@ func (m *Machine) ValidateState(ctx context.Context, src stores.RO, x State) err
 // Prev is assumed to be a known good, valid state.
 func (m *Machine) ValidateChange(ctx context.Context, src stores.RO, prev, next State, delta Delta) error {
 	// TODO: first validate auth operations, ensure that all the differences are signed.
+	return m.checkAliasChanges(ctx, src, prev, gotorgop.ChangeSet(delta))
+}
+
+// checkAliasChanges ensures that none of the ops in cs move an alias which refers to a tag.
+func (m *Machine) checkAliasChanges(ctx context.Context, src stores.RO, prev State, cs gotorgop.ChangeSet) error {
+	for _, op := range cs.Ops {
+		switch op := op.(type) {
+		case *gotorgop.ChangeSet:
+			if err := m.checkAliasChanges(ctx, src, prev, *op); err != nil {
+				return err
+			}
+		case *gotorgop.PutBranchEntry:
+			if err := m.checkAliasChange(ctx, src, prev, op.Name, op.Volume); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package gotorg
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/gotvc/got/src/gotorg/internal/gotorgop"
+	"github.com/gotvc/got/src/internal/gotcore"
 	"github.com/gotvc/got/src/internal/stores"
 	"github.com/gotvc/got/src/internal/testutil"
 )
@@ -56,6 +58,33 @@ func TestCreateBranch(t *testing.T) {
 	t.Log(vol)
 }
 
+func TestTagAlias(t *testing.T) {
+	ctx := testutil.Context(t)
+	bc := newTestService(t)
+	sigPub, sigPriv := newTestSigner(t)
+	kemPub, kemPriv := newTestKEM(t)
+	nsh := blobcache.Handle{}
+	priv := IdenPrivate{SigPrivateKey: sigPriv, KEMPrivateKey: kemPriv}
+	gnsc := Client{Blobcache: bc, Machine: New(), ActAs: priv}
+	require.NoError(t, gnsc.EnsureInit(ctx, nsh, []IdentityUnit{gotorgop.NewIDUnit(sigPub, kemPub)}))
+
+	tagInfo, err := json.Marshal(gotcore.Info{
+		Annotations: []gotcore.Annotation{{Key: gotcore.AnnotationTag, Value: "true"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, gnsc.CreateAlias(ctx, nsh, "v1.0", tagInfo))
+	require.NoError(t, gnsc.CreateAlias(ctx, nsh, "master", nil))
+	master, err := gnsc.GetAlias(ctx, nsh, "master")
+	require.NoError(t, err)
+
+	// the alias for a tag cannot be pointed at another volume.
+	err = gnsc.PutAlias(ctx, nsh, VolumeAlias{Name: "v1.0", Volume: master.Volume}, nil)
+	require.ErrorIs(t, err, gotcore.ErrImmutable)
+	tag, err := gnsc.GetAlias(ctx, nsh, "v1.0")
+	require.NoError(t, err)
+	require.NotEqual(t, master.Volume, tag.Volume)
+}
+
 func TestPutGetIDUnit(t *testing.T) {
 	ctx := testutil.Context(t)
 	sigPub, sigPriv := newTestSigner(t)
@@ -42,8 +42,11 @@ func (m *Machine) AddVolume(ctx context.Context, s stores.RW, state State, entry
 // If the volume is not found, nil is returned.
 func (m *Machine) GetVolume(ctx context.Context, s stores.RO, state State, volOID blobcache.OID) (*VolumeEntry, error) {
 	val, err := m.gotkv.Get(ctx, s, state.Volumes, volOID[:])
-	if gotkv.IsErrKeyNotFound(err) {
-		return nil, nil
+	if err != nil {
+		if gotkv.IsErrKeyNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
 	return gotorgop.ParseVolumeEntry(volOID[:], val)
 }
@@ -28,6 +28,49 @@ func (r *Repo) History(ctx context.Context, se CommitExpr, fn func(ref Ref, s Co
 	})
 }
 
+// ResolveCommit returns a Ref to the Commit at se.
+func (r *Repo) ResolveCommit(ctx context.Context, se CommitExpr) (Ref, error) {
+	var ref Ref
+	err := r.ViewCommit(ctx, se, func(vctx *gotcore.ViewCtx) error {
+		ref = vctx.Target
+		return nil
+	})
+	return ref, err
+}
+
+// Bisect picks the next Commit to test, in a search of the history of the mark at fqm for the first bad Commit.
+// See gotcore.Bisect.
+func (r *Repo) Bisect(ctx context.Context, fqm FQM, bad Ref, good, skip []Ref) (*gotcore.BisectStep, error) {
+	var step *gotcore.BisectStep
+	err := r.ViewMark(ctx, fqm, func(mtx *gotcore.MarkTx) error {
+		idx, err := mtx.Index(ctx)
+		if err != nil {
+			return err
+		}
+		step, err = gotcore.Bisect(ctx, mtx.GotVC(), mtx.VCRO(), idx, bad, good, skip)
+		return err
+	})
+	return step, err
+}
+
+// PathHistory calls fn for each commit in the history of se which changes the path p, or anything beneath it.
+func (r *Repo) PathHistory(ctx context.Context, se CommitExpr, p string, fn func(ref Ref, s Commit) error) error {
+	return r.ViewCommit(ctx, se, func(vctx *gotcore.ViewCtx) error {
+		return gotcore.PathHistory(ctx, vctx.VC, vctx.FS, vctx.Stores, vctx.Target, p, fn)
+	})
+}
+
+// Blame attributes each line of the text file at p, in the Commit at se, to the Commit which last changed it.
+func (r *Repo) Blame(ctx context.Context, se CommitExpr, p string) ([]gotcore.BlameLine, error) {
+	var lines []gotcore.BlameLine
+	err := r.ViewCommit(ctx, se, func(vctx *gotcore.ViewCtx) error {
+		var err error
+		lines, err = gotcore.Blame(ctx, vctx.VC, vctx.FS, vctx.Stores, vctx.Target, p)
+		return err
+	})
+	return lines, err
+}
+
 func (r *Repo) DebugFS(ctx context.Context, se gotcore.CommitExpr, w io.Writer) error {
 	return r.ViewCommit(ctx, se, func(vctx *gotcore.ViewCtx) error {
 		return gotfs.Dump(ctx, vctx.Stores.FS.Metadata, vctx.Root.Payload.Snap, w)
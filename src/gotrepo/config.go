@@ -6,6 +6,7 @@ import (
 	"os"
 	"regexp"
 
+	"blobcache.io/blobcache/src/blobcache"
 	"github.com/gotvc/got/src/internal/gotcfg"
 	"go.inet256.org/inet256/src/inet256"
 )
@@ -20,6 +21,9 @@ type Config struct {
 	Pull   []PullConfig         `json:"pull"`
 	Push   []PushConfig         `json:"push"`
 	Merge  []MergeConfig        `json:"merge"`
+	// Orgs are the URLs of gotorg Volumes.
+	// Signatures by identities which are not in the repo are checked against the identity units in these Volumes.
+	Orgs []blobcache.URL `json:"orgs,omitempty"`
 }
 
 func (c *Config) Validate() error {
@@ -2,6 +2,7 @@ package gotrepo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
@@ -65,11 +66,17 @@ func (r *Repo) Stat(ctx context.Context, se gotcore.CommitExpr, p string) (*gotf
 }
 
 // CheckAll runs integrity checks on all marks in the local Space.
+// The signatures on all the Commits in each mark's history are also checked.
+// Unsigned Commits, and Commits signed by identities which are not in the repo, are not considered errors.
 func (r *Repo) CheckAll(ctx context.Context) error {
 	sp, err := r.GetSpace(ctx, "")
 	if err != nil {
 		return err
 	}
+	verify, err := r.CommitVerifier(ctx)
+	if err != nil {
+		return err
+	}
 	return sp.Do(ctx, false, func(st gotcore.SpaceTx) error {
 		for name, err := range st.All(ctx) {
 			if err != nil {
@@ -82,7 +89,7 @@ func (r *Repo) CheckAll(ctx context.Context) error {
 			}
 
 			if err := gotcore.ViewCommit(ctx, st, se, func(vctx *gotcore.ViewCtx) error {
-				return vctx.VC.Check(ctx, vctx.Stores.VC, *vctx.Root, func(payload gotcore.Payload) error {
+				if err := vctx.VC.Check(ctx, vctx.Stores.VC, *vctx.Root, func(payload gotcore.Payload) error {
 					return vctx.FS.Check(ctx, vctx.Stores.FS.Metadata, payload.Snap, func(ref gdat.Ref) error {
 						ok, err := bcsdk.ExistsUnit(ctx, vctx.Stores.FS.Data, ref.CID)
 						if err != nil {
@@ -93,6 +100,18 @@ func (r *Repo) CheckAll(ctx context.Context) error {
 						}
 						return nil
 					})
+				}); err != nil {
+					return err
+				}
+				return gotcore.History(ctx, vctx.VC, vctx.Stores.VC, vctx.Target, func(ref gdat.Ref, comm gotcore.Commit) error {
+					switch err := verify(ctx, comm); {
+					case err == nil, errors.Is(err, gotcore.ErrUnsigned):
+					case errors.Is(err, gotcore.ErrUnknownSigner):
+						logctx.Warnf(ctx, "commit %v: %v", ref.CID, err)
+					default:
+						return fmt.Errorf("commit %v: %w", ref.CID, err)
+					}
+					return nil
 				})
 			}); err != nil {
 				return err
@@ -111,3 +130,127 @@ func (r *Repo) DiffFS(ctx context.Context, left, right gotcore.CommitExpr, fn fu
 		})
 	})
 }
+
+// ViewFSPair calls fn with the filesystems in left and right.
+// The stores passed to fn can read from both filesystems.
+func (r *Repo) ViewFSPair(ctx context.Context, left, right gotcore.CommitExpr, fn func(fsmach *gotfs.Machine, ss gotfs.RO, left, right gotfs.Root) error) error {
+	return r.ViewCommit(ctx, left, func(lctx *gotcore.ViewCtx) error {
+		return r.ViewCommit(ctx, right, func(rctx *gotcore.ViewCtx) error {
+			ss := gotfs.RO{
+				Data:     stores.Union{lctx.FSRO().Data, rctx.FSRO().Data},
+				Metadata: stores.Union{lctx.FSRO().Metadata, rctx.FSRO().Metadata},
+			}
+			return fn(rctx.FS, ss, lctx.Root.Payload.Snap, rctx.Root.Payload.Snap)
+		})
+	})
+}
+
+// Changes returns the changes to each path between the filesystems in left and right,
+// with removed and added files paired up as renames and copies.
+func (r *Repo) Changes(ctx context.Context, left, right gotcore.CommitExpr, opts gotfs.ChangeOptions) ([]gotfs.Change, error) {
+	var changes []gotfs.Change
+	err := r.ViewFSPair(ctx, left, right, func(fsmach *gotfs.Machine, ss gotfs.RO, left, right gotfs.Root) error {
+		var err error
+		changes, err = fsmach.Changes(ctx, ss, left, right, opts)
+		return err
+	})
+	return changes, err
+}
+
+// ViewMarkFS calls fn with the filesystem in the Commit that the mark at fqm points to.
+// If the mark is empty, fn is called with an empty filesystem.
+func (r *Repo) ViewMarkFS(ctx context.Context, fqm FQM, fn func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error) error {
+	return r.ViewMark(ctx, fqm, func(mtx *gotcore.MarkTx) error {
+		fsmach := mtx.GotFS()
+		var root gotfs.Root
+		if ok, err := mtx.LoadFS(ctx, &root); err != nil {
+			return err
+		} else if !ok {
+			s := stores.NewMem()
+			empty, err := fsmach.NewEmpty(ctx, s, 0o755)
+			if err != nil {
+				return err
+			}
+			return fn(fsmach, gotfs.RO{Data: s, Metadata: s}, *empty)
+		}
+		return fn(fsmach, mtx.FSRO(), root)
+	})
+}
+
+// FSSnapshot is the filesystem in the Commit that a mark pointed to when it was opened with OpenMarkFS.
+// It can be read from until it is closed, even if the mark changes.
+type FSSnapshot struct {
+	FS     *gotfs.Machine
+	Stores gotfs.RO
+	Root   gotfs.Root
+
+	release chan struct{}
+	errc    chan error
+}
+
+// Close releases the transaction that the snapshot is read from.
+func (s *FSSnapshot) Close() error {
+	close(s.release)
+	return <-s.errc
+}
+
+// OpenMarkFS returns a snapshot of the filesystem in the Commit that the mark at fqm points to.
+// The transaction which the snapshot is read from is held open until it is closed.
+// If the mark is empty, the snapshot is of an empty filesystem.
+func (r *Repo) OpenMarkFS(ctx context.Context, fqm FQM) (*FSSnapshot, error) {
+	snapc := make(chan *FSSnapshot)
+	release := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.ViewMarkFS(ctx, fqm, func(fsmach *gotfs.Machine, ss gotfs.RO, root gotfs.Root) error {
+			snapc <- &FSSnapshot{FS: fsmach, Stores: ss, Root: root, release: release, errc: errc}
+			<-release
+			return nil
+		})
+	}()
+	select {
+	case snap := <-snapc:
+		return snap, nil
+	case err := <-errc:
+		return nil, err
+	}
+}
+
+// ModifyFS applies fn to the filesystem in the Commit that the mark at fqm points to,
+// and then points the mark at a new Commit containing the result, with the message returned by fn.
+// The new Commit is signed by signer.
+// If the mark is empty, fn is called with an empty filesystem, and the new Commit has no parents.
+func (r *Repo) ModifyFS(ctx context.Context, fqm FQM, signer *Signer, fn func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, string, error)) error {
+	return r.Modify(ctx, fqm, func(mctx gotcore.ModifyCtx) (*Commit, error) {
+		ss := mctx.Stores.FS
+		var bases []Commit
+		var root gotfs.Root
+		if mctx.Target.IsZero() {
+			empty, err := mctx.FS.NewEmpty(ctx, ss.Metadata, 0o755)
+			if err != nil {
+				return nil, err
+			}
+			root = *empty
+		} else {
+			bases = []Commit{*mctx.Commit}
+			root = mctx.Commit.Payload.Snap
+		}
+		next, msg, err := fn(&mctx.FS, ss, root)
+		if err != nil {
+			return nil, err
+		}
+		comm, err := gotcore.CreateCommit(ctx, &mctx.VC, mctx.Stores.VC, gotcore.CommitParams{
+			Committer: signer.ID(),
+			Base:      bases,
+			Snap:      *next,
+			Notes: gotcore.CommitNotes{
+				Message: msg,
+			},
+			Signer: signer,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &comm, nil
+	})
+}
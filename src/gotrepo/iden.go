@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"slices"
 
+	"blobcache.io/blobcache/src/bcsdk"
+	"blobcache.io/blobcache/src/blobcache"
 	"github.com/gotvc/got/src/gotorg"
 	"github.com/gotvc/got/src/gotrepo/internal/reposchema"
 	"github.com/gotvc/got/src/internal/gotcfg"
+	"github.com/gotvc/got/src/internal/gotcore"
 	"go.brendoncarroll.net/stdctx/logctx"
 	"go.inet256.org/inet256/src/inet256"
 	"go.uber.org/zap"
@@ -168,3 +172,86 @@ func (s *idenStore) List(ctx context.Context) (ret []inet256.ID, _ error) {
 	}
 	return ret, nil
 }
+
+type Signer = gotcore.Signer
+
+// GetSigner returns a Signer for the identity with the given name.
+func (r *Repo) GetSigner(ctx context.Context, name string) (*Signer, error) {
+	idp, err := r.getPrivate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{PKI: gotorg.PKI(), PrivateKey: idp.SigPrivateKey}, nil
+}
+
+// withActor returns a context which attributes changes to mark targets to the repo's default identity,
+// unless an actor has already been set with gotcore.WithLogInfo.
+// If the repo has no default identity, then ctx is returned as is.
+func (r *Repo) withActor(ctx context.Context) (context.Context, error) {
+	if _, exists := r.config.Identities[DefaultIden]; !exists {
+		return ctx, nil
+	}
+	idp, err := r.getPrivate(ctx, DefaultIden)
+	if err != nil {
+		return nil, err
+	}
+	return gotcore.WithLogInfo(ctx, idp.GetID(), ""), nil
+}
+
+// CommitVerifier returns a function which checks Commit signatures
+// against the identity units of all the identities in the repo, and in the repo's orgs.
+// See gotcore.VerifyCommit for the errors it returns.
+func (r *Repo) CommitVerifier(ctx context.Context) (func(ctx context.Context, comm Commit) error, error) {
+	getVerifier, err := r.getVerifierFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pki := gotorg.PKI()
+	return func(ctx context.Context, comm Commit) error {
+		return gotcore.VerifyCommit(ctx, pki, getVerifier, comm)
+	}, nil
+}
+
+// getVerifierFunc returns a gotcore.GetVerifierFunc which looks up
+// public keys among the identities in the repo, and then among the identity units in each of Config.Orgs.
+// Keys found in the orgs, and IDs which are not found anywhere, are remembered, so each ID is only looked up once.
+func (r *Repo) getVerifierFunc(ctx context.Context) (gotcore.GetVerifierFunc, error) {
+	idens, err := r.Identities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pubKeys := make(map[inet256.ID]inet256.PublicKey, len(idens))
+	for _, idu := range idens {
+		pubKeys[idu.ID] = idu.SigPublicKey
+	}
+	orgc := gotorg.Client{Blobcache: r.bc, Machine: gotorg.New()}
+	orgURLs := slices.Clone(r.config.Orgs)
+	var orgs []blobcache.Handle
+	return func(ctx context.Context, id inet256.ID) (inet256.PublicKey, error) {
+		if pubKey, exists := pubKeys[id]; exists {
+			return pubKey, nil
+		}
+		if orgs == nil {
+			orgs = make([]blobcache.Handle, 0, len(orgURLs))
+			for _, u := range orgURLs {
+				volh, err := bcsdk.OpenURL(ctx, r.bc, u)
+				if err != nil {
+					return nil, fmt.Errorf("opening org %v: %w", u, err)
+				}
+				orgs = append(orgs, *volh)
+			}
+		}
+		for _, volh := range orgs {
+			idu, err := orgc.GetIDUnit(ctx, volh, id)
+			if err != nil {
+				return nil, err
+			}
+			if idu != nil {
+				pubKeys[id] = idu.SigPublicKey
+				return idu.SigPublicKey, nil
+			}
+		}
+		pubKeys[id] = nil
+		return nil, nil
+	}, nil
+}
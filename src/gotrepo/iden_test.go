@@ -0,0 +1,52 @@
+package gotrepo
+
+import (
+	"testing"
+
+	"blobcache.io/blobcache/src/blobcache"
+	"github.com/gotvc/got/src/gotorg"
+	"github.com/gotvc/got/src/internal/gotbc"
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitVerifierOrgs(t *testing.T) {
+	t.Parallel()
+	ctx := testutil.Context(t)
+	bc := gotbc.NewTest(t)
+	repo := NewTestRepo(t, bc)
+
+	// other is not an identity in the repo, it only has an identity unit in the org.
+	other := gotorg.GenerateIden()
+	orgc := gotorg.Client{Blobcache: bc, Machine: gotorg.New(), ActAs: other}
+	orgh, err := bc.CreateVolume(ctx, nil, gotorg.DefaultVolumeSpec(false))
+	require.NoError(t, err)
+	require.NoError(t, orgc.EnsureInit(ctx, *orgh, []gotorg.IdentityUnit{other.Public()}))
+
+	mach := gotcore.NewMachine(gotcore.DSConfig{})
+	s := stores.NewMem()
+	snap, err := mach.FS.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	comm, err := gotcore.CreateCommit(ctx, &mach.VC, s, gotcore.CommitParams{
+		Committer: other.GetID(),
+		Snap:      *snap,
+		Signer:    &Signer{PKI: gotorg.PKI(), PrivateKey: other.SigPrivateKey},
+	})
+	require.NoError(t, err)
+
+	verify, err := repo.CommitVerifier(ctx)
+	require.NoError(t, err)
+	require.ErrorIs(t, verify(ctx, comm), gotcore.ErrUnknownSigner)
+
+	ep, err := bc.Endpoint(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.Configure(ctx, func(x Config) (Config, error) {
+		x.Orgs = append(x.Orgs, blobcache.URL{Node: ep.Node, IPPort: &ep.IPPort, OID: orgh.OID})
+		return x, nil
+	}))
+	verify, err = repo.CommitVerifier(ctx)
+	require.NoError(t, err)
+	require.NoError(t, verify(ctx, comm))
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotorg"
 	"github.com/gotvc/got/src/internal/gotcore"
 )
 
@@ -67,12 +68,16 @@ func (r *Repo) InspectMark(ctx context.Context, fqname FQM) (*gotcore.Info, erro
 // DeleteBranch deletes a mark
 // The target of the mark may be garbage collected if nothing else
 // references it.
-func (r *Repo) DeleteMark(ctx context.Context, fqname FQM) error {
+// Tags are only deleted if force is true.
+func (r *Repo) DeleteMark(ctx context.Context, fqname FQM, force bool) error {
 	space, err := r.GetSpace(ctx, fqname.Space)
 	if err != nil {
 		return err
 	}
 	return space.Do(ctx, true, func(st gotcore.SpaceTx) error {
+		if force {
+			return st.ForceDelete(ctx, fqname.Name)
+		}
 		return st.Delete(ctx, fqname.Name)
 	})
 }
@@ -131,6 +136,21 @@ func (r *Repo) MarkLoad(ctx context.Context, fqm FQM) (Ref, error) {
 	return ref, nil
 }
 
+// MarkLog returns the changes to the target of the mark, most recent first.
+func (r *Repo) MarkLog(ctx context.Context, fqm FQM) ([]gotcore.LogEntry, error) {
+	space, err := r.GetSpace(ctx, fqm.Space)
+	if err != nil {
+		return nil, err
+	}
+	var ents []gotcore.LogEntry
+	err = space.Do(ctx, false, func(st gotcore.SpaceTx) error {
+		var err error
+		ents, err = st.GetLog(ctx, fqm.Name)
+		return err
+	})
+	return ents, err
+}
+
 // MarkLoadCommit loads the Commit that the mark points to.
 // If the mark is empty then the Ref will be zeroed.
 func (r *Repo) MarkLoadCommit(ctx context.Context, fqm FQM) (Ref, Commit, error) {
@@ -152,12 +172,23 @@ func (r *Repo) MarkLoadCommit(ctx context.Context, fqm FQM) (Ref, Commit, error)
 	return ref, comm, nil
 }
 
+// MoveMark renames a mark within a space.
+// Tags cannot be moved.
 func (r *Repo) MoveMark(ctx context.Context, spaceName, from, to string) error {
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return err
+	}
 	space, err := r.GetSpace(ctx, spaceName)
 	if err != nil {
 		return err
 	}
 	return space.Do(ctx, true, func(st gotcore.SpaceTx) error {
+		if info, err := st.Inspect(ctx, from); err != nil {
+			return err
+		} else if info.IsTag() {
+			return fmt.Errorf("%w: cannot move tag %q", gotcore.ErrImmutable, from)
+		}
 		if err := gotcore.CloneMark(ctx, st, from, to); err != nil {
 			return err
 		}
@@ -168,6 +199,10 @@ func (r *Repo) MoveMark(ctx context.Context, spaceName, from, to string) error {
 // CloneMark creates a new branch called next and sets its head to match base's
 // TODO: currently marks can only be cloned within the same space.
 func (r *Repo) CloneMark(ctx context.Context, base, next FQM) error {
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return err
+	}
 	if base.Space == next.Space {
 		space, err := r.GetSpace(ctx, base.Space)
 		if err != nil {
@@ -195,3 +230,59 @@ func (r *Repo) Modify(ctx context.Context, fqm FQM, fn func(mc gotcore.ModifyCtx
 		return mtx.Modify(ctx, fn)
 	})
 }
+
+// CreateTag creates an immutable tag at fqm, pointing at the Commit that target resolves to.
+// The tag and target must be in the same space.
+// The tag has the same Config as the mark that target is relative to, so it can be synced with that mark.
+// If target is an exact Ref, the tag gets the default Config.
+// msg is optional, and the tag is only signed if signer is non-nil.
+func (r *Repo) CreateTag(ctx context.Context, fqm FQM, target CommitExpr, msg string, signer *Signer) (*MarkInfo, error) {
+	if err := gotcore.CheckName(fqm.Name); err != nil {
+		return nil, err
+	}
+	if target.GetSpace() != fqm.Space {
+		return nil, fmt.Errorf("tags can only point at commits in the same space")
+	}
+	space, err := r.GetSpace(ctx, fqm.Space)
+	if err != nil {
+		return nil, err
+	}
+	var info *gotcore.Info
+	err = space.Do(ctx, true, func(st gotcore.SpaceTx) error {
+		ref, err := target.Resolve(ctx, st)
+		if err != nil {
+			return err
+		}
+		cfg := gotcore.DefaultConfig(false)
+		if mark, ok := gotcore.MarkOf(target); ok {
+			minfo, err := st.Inspect(ctx, mark.Name)
+			if err != nil {
+				return err
+			}
+			cfg = minfo.Config
+		}
+		info, err = gotcore.CreateTag(ctx, st, fqm.Name, gotcore.TagParams{
+			Config:  cfg,
+			Target:  ref,
+			Message: msg,
+			Signer:  signer,
+		})
+		return err
+	})
+	return info, err
+}
+
+// VerifyTag checks the signature on the tag at fqm, against the identities in the repo.
+func (r *Repo) VerifyTag(ctx context.Context, fqm FQM) error {
+	getVerifier, err := r.getVerifierFunc(ctx)
+	if err != nil {
+		return err
+	}
+	return r.ViewMark(ctx, fqm, func(mt *gotcore.MarkTx) error {
+		target, err := mt.Load(ctx)
+		if err != nil {
+			return err
+		}
+		return gotcore.VerifyTag(ctx, gotorg.PKI(), getVerifier, fqm.Name, mt.Info(), target)
+	})
+}
@@ -0,0 +1,76 @@
+package gotrepo
+
+import (
+	"context"
+
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/stores"
+)
+
+type MergeParams = gotcore.MergeParams
+
+// Merge merges the commit at se into the mark at dst.
+// If the histories have diverged, a new commit is created with both heads as parents.
+// If the merge has conflicts, then gotcore.ErrMergeConflict is returned and dst is not changed.
+func (r *Repo) Merge(ctx context.Context, dst FQM, se CommitExpr, params MergeParams) error {
+	if params.Notes.Message == "" {
+		params.Notes.Message = "merge " + gotcore.FormatCommitExpr(se)
+	}
+	return r.ModifyFrom(ctx, dst, se, func(mctx gotcore.ModifyCtx, ref Ref) (*Commit, error) {
+		if mctx.Target.IsZero() {
+			comm, err := mctx.VC.GetVertex(ctx, mctx.Stores.VC, ref)
+			if err != nil {
+				return nil, err
+			}
+			return &comm, nil
+		}
+		return gotcore.Merge(ctx, mctx.Machine, mctx.Stores, mctx.Index, mctx.Target, ref, params)
+	})
+}
+
+// ModifyFrom calls fn to modify the target of the mark at dst.
+// The commit at se, and everything reachable from it, is synced into the mark's stores
+// before fn is called with its Ref.
+func (r *Repo) ModifyFrom(ctx context.Context, dst FQM, se CommitExpr, fn func(mctx gotcore.ModifyCtx, ref Ref) (*Commit, error)) error {
+	srcSpace, err := r.GetSpace(ctx, se.GetSpace())
+	if err != nil {
+		return err
+	}
+	dstSpace, err := r.GetSpace(ctx, dst.Space)
+	if err != nil {
+		return err
+	}
+	// Even if these are the same space, 1 read-only and 1 modify should work.
+	return dstSpace.Do(ctx, true, func(dstTx gotcore.SpaceTx) error {
+		return srcSpace.Do(ctx, false, func(srcTx gotcore.SpaceTx) error {
+			return gotcore.ViewCommit(ctx, srcTx, se, func(vctx *gotcore.ViewCtx) error {
+				mtx, err := gotcore.NewMarkTx(ctx, dstTx, dst.Name)
+				if err != nil {
+					return err
+				}
+				return mtx.Modify(ctx, func(mctx gotcore.ModifyCtx) (*gotcore.Commit, error) {
+					if err := mctx.SyncRef(ctx, vctx.Stores, vctx.Target); err != nil {
+						return nil, err
+					}
+					return fn(mctx, vctx.Target)
+				})
+			})
+		})
+	})
+}
+
+// MergeBase returns the best common ancestors of the commits at a and b.
+func (r *Repo) MergeBase(ctx context.Context, a, b CommitExpr) ([]Ref, error) {
+	var ret []Ref
+	if err := r.ViewCommit(ctx, a, func(actx *gotcore.ViewCtx) error {
+		return r.ViewCommit(ctx, b, func(bctx *gotcore.ViewCtx) error {
+			u := stores.Union{actx.Stores.VC, bctx.Stores.VC}
+			var err error
+			ret, err = actx.VC.MergeBase(ctx, u, actx.Index, actx.Target, bctx.Target)
+			return err
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,66 @@
+package gotrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gotvc/got/src/internal/gotcore"
+)
+
+type ReplayParams = gotcore.ReplayParams
+
+// CherryPick applies the change made by the commit at se to the mark at dst, as a new commit.
+func (r *Repo) CherryPick(ctx context.Context, dst FQM, se CommitExpr, params ReplayParams) error {
+	return r.ModifyFrom(ctx, dst, se, func(mctx gotcore.ModifyCtx, ref Ref) (*Commit, error) {
+		if mctx.Target.IsZero() {
+			return nil, fmt.Errorf("cannot cherry-pick onto empty mark %q", dst.Name)
+		}
+		return gotcore.CherryPick(ctx, mctx.Machine, mctx.Stores, mctx.Target, ref, params)
+	})
+}
+
+// Revert undoes the change made by the commit at se, with a new commit on the mark at dst.
+func (r *Repo) Revert(ctx context.Context, dst FQM, se CommitExpr, params ReplayParams) error {
+	return r.ModifyFrom(ctx, dst, se, func(mctx gotcore.ModifyCtx, ref Ref) (*Commit, error) {
+		if mctx.Target.IsZero() {
+			return nil, fmt.Errorf("cannot revert onto empty mark %q", dst.Name)
+		}
+		return gotcore.Revert(ctx, mctx.Machine, mctx.Stores, mctx.Target, ref, params)
+	})
+}
+
+// Rebase replays the commits on the mark at dst, which are not in the history of the commit at onto,
+// on top of onto, and then sets the mark to the last of them.
+// If a commit cannot be replayed cleanly, then gotcore.ErrMergeConflict is returned and dst is not changed.
+func (r *Repo) Rebase(ctx context.Context, dst FQM, onto CommitExpr, params ReplayParams) error {
+	return r.ModifyFrom(ctx, dst, onto, func(mctx gotcore.ModifyCtx, ref Ref) (*Commit, error) {
+		if mctx.Target.IsZero() {
+			comm, err := mctx.VC.GetVertex(ctx, mctx.Stores.VC, ref)
+			if err != nil {
+				return nil, err
+			}
+			return &comm, nil
+		}
+		return gotcore.Rebase(ctx, mctx.Machine, mctx.Stores, mctx.Index, mctx.Target, ref, params)
+	})
+}
+
+// Squash replaces the last n commits on the mark at dst with a single commit.
+func (r *Repo) Squash(ctx context.Context, dst FQM, n int, params ReplayParams) error {
+	return r.Modify(ctx, dst, func(mctx gotcore.ModifyCtx) (*Commit, error) {
+		if mctx.Target.IsZero() {
+			return nil, fmt.Errorf("cannot squash empty mark %q", dst.Name)
+		}
+		return gotcore.Squash(ctx, mctx.Machine, mctx.Stores, mctx.Target, n, params)
+	})
+}
+
+// SquashUntil replaces all the commits on the mark at dst, after the commit at until, with a single commit.
+func (r *Repo) SquashUntil(ctx context.Context, dst FQM, until CommitExpr, params ReplayParams) error {
+	return r.ModifyFrom(ctx, dst, until, func(mctx gotcore.ModifyCtx, ref Ref) (*Commit, error) {
+		if mctx.Target.IsZero() {
+			return nil, fmt.Errorf("cannot squash empty mark %q", dst.Name)
+		}
+		return gotcore.SquashUntil(ctx, mctx.Machine, mctx.Stores, mctx.Target, ref, params)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"blobcache.io/blobcache/src/blobcache"
+	"github.com/gotvc/got/src/gotfs"
 	"github.com/gotvc/got/src/internal/gotbc"
+	"github.com/gotvc/got/src/internal/gotcore"
 	"github.com/gotvc/got/src/internal/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -60,3 +62,29 @@ func TestRenameSpace(t *testing.T) {
 	require.Equal(t, "bar", cfg.Pull[0].From)
 	require.Equal(t, "bar", cfg.Push[0].To)
 }
+
+func TestCreateTagConfig(t *testing.T) {
+	ctx := testutil.Context(t)
+	t.Parallel()
+	bc := gotbc.NewTest(t)
+	rootVol := blobcache.OID{}
+	volh, err := bc.OpenFiat(ctx, rootVol, blobcache.Action_ALL)
+	require.NoError(t, err)
+	require.NoError(t, Init(ctx, bc, *volh, DefaultConfig()))
+	repo, err := Open(ctx, bc, rootVol, nil)
+	require.NoError(t, err)
+
+	master := FQM{Name: "master"}
+	minfo, err := repo.CreateMark(ctx, master, gotcore.DefaultConfig(false), nil)
+	require.NoError(t, err)
+	signer, err := repo.GetSigner(ctx, DefaultIden)
+	require.NoError(t, err)
+	require.NoError(t, repo.ModifyFS(ctx, master, signer, func(fsmach *gotfs.Machine, ss gotfs.RW, root gotfs.Root) (*gotfs.Root, string, error) {
+		return &root, "first commit", nil
+	}))
+
+	// the tag must share the mark's salt, or it could not be synced with the mark.
+	tinfo, err := repo.CreateTag(ctx, FQM{Name: "v1.0"}, &gotcore.CommitExpr_Mark{Name: "master"}, "", nil)
+	require.NoError(t, err)
+	require.Equal(t, minfo.Config, tinfo.Config)
+}
@@ -12,6 +12,10 @@ import (
 
 // SyncUnit syncs 2 marks by name.
 func (r *Repo) SyncUnit(ctx context.Context, src, dst FQM, force bool) error {
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return err
+	}
 	srcSpace, err := r.GetSpace(ctx, src.Space)
 	if err != nil {
 		return err
@@ -55,6 +59,10 @@ type SyncSpacesTask struct {
 
 // SyncSpaces executes a SyncSpaceTask
 func (r *Repo) SyncSpaces(ctx context.Context, task SyncSpacesTask) ([]gotcore.SyncResult, error) {
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return nil, err
+	}
 	srcSpace, err := r.GetSpace(ctx, task.Src)
 	if err != nil {
 		return nil, err
@@ -136,6 +144,10 @@ func (r *Repo) Pull(ctx context.Context, onDone func(SyncResult)) error {
 			Dst: "",
 		})
 	}
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return err
+	}
 	jc := gotjob.New(ctx)
 	return r.doSyncTasks(&jc, tasks, onDone)
 }
@@ -165,6 +177,10 @@ func (r *Repo) Push(ctx context.Context, onDone func(SyncResult)) error {
 			Dst: fcfg.To,
 		})
 	}
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return err
+	}
 	jc := gotjob.New(ctx)
 	return r.doSyncTasks(&jc, tasks, onDone)
 }
@@ -238,6 +254,10 @@ func (r *Repo) MergeAll(ctx context.Context, filter func(string) bool, onDone fu
 	if filter == nil {
 		filter = func(s string) bool { return true }
 	}
+	ctx, err := r.withActor(ctx)
+	if err != nil {
+		return err
+	}
 	cfg := r.Config()
 	for _, mcfg := range cfg.Merge {
 		if !filter(mcfg.Space) {
@@ -0,0 +1,173 @@
+package gotwc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/internal/gotcore"
+)
+
+// BisectState is the state of a search through the history of a mark, for the first bad Commit.
+type BisectState struct {
+	// Mark is the mark whose history is being searched.
+	Mark string `json:"mark"`
+	// Base is the Base from before the bisection started.
+	// It is restored by BisectReset.
+	Base []gdat.Ref `json:"base"`
+
+	Bad  *gdat.Ref  `json:"bad,omitempty"`
+	Good []gdat.Ref `json:"good"`
+	Skip []gdat.Ref `json:"skip"`
+}
+
+// BisectStart starts a bisection of the history of the head mark.
+// The search begins once a bad Commit and at least one good Commit have been marked.
+// Until BisectReset is called, the Commits being tested are checked out instead of the head mark,
+// and nothing can be committed.
+func (wc *WC) BisectStart(ctx context.Context) error {
+	cfg, err := LoadConfig(wc.root)
+	if err != nil {
+		return err
+	}
+	if cfg.Bisect != nil {
+		return fmt.Errorf("bisect already in progress on %q, reset it first", cfg.Bisect.Mark)
+	}
+	if cfg.SaveTo == "" {
+		return fmt.Errorf("cannot bisect without a head mark")
+	}
+	if emptyStage, err := wc.StageIsEmpty(ctx); err != nil {
+		return err
+	} else if !emptyStage {
+		return fmt.Errorf("cannot bisect, staging area must be empty (it's not)")
+	}
+	return EditConfig(wc.root, func(x Config) Config {
+		x.Bisect = &BisectState{
+			Mark: x.SaveTo,
+			Base: x.Base,
+		}
+		return x
+	})
+}
+
+// GetBisect returns the state of the bisection in progress, or nil if there is none.
+func (wc *WC) GetBisect() (*BisectState, error) {
+	cfg, err := LoadConfig(wc.root)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Bisect, nil
+}
+
+// BisectGood marks the Commit at se as good, and checks out the next Commit to test.
+// If se is nil, the Commit which is checked out is marked.
+// The returned step is nil if a bad Commit or a good Commit still needs to be marked.
+func (wc *WC) BisectGood(ctx context.Context, se gotcore.CommitExpr) (*gotcore.BisectStep, error) {
+	return wc.bisectMark(ctx, se, func(bs *BisectState, ref gdat.Ref) {
+		bs.Good = append(bs.Good, ref)
+	})
+}
+
+// BisectBad marks the Commit at se as bad, and checks out the next Commit to test.
+// If se is nil, the Commit which is checked out is marked.
+// The returned step is nil if a good Commit still needs to be marked.
+func (wc *WC) BisectBad(ctx context.Context, se gotcore.CommitExpr) (*gotcore.BisectStep, error) {
+	return wc.bisectMark(ctx, se, func(bs *BisectState, ref gdat.Ref) {
+		bs.Bad = &ref
+	})
+}
+
+// BisectSkip marks the Commit at se as untestable, and checks out the next Commit to test.
+// If se is nil, the Commit which is checked out is marked.
+func (wc *WC) BisectSkip(ctx context.Context, se gotcore.CommitExpr) (*gotcore.BisectStep, error) {
+	return wc.bisectMark(ctx, se, func(bs *BisectState, ref gdat.Ref) {
+		bs.Skip = append(bs.Skip, ref)
+	})
+}
+
+// BisectReset ends the bisection, and checks out the Commit from before it started.
+func (wc *WC) BisectReset(ctx context.Context) error {
+	cfg, err := LoadConfig(wc.root)
+	if err != nil {
+		return err
+	}
+	bs := cfg.Bisect
+	if bs == nil {
+		return fmt.Errorf("no bisect in progress")
+	}
+	if err := EditConfig(wc.root, func(x Config) Config {
+		x.Base = bs.Base
+		x.Bisect = nil
+		return x
+	}); err != nil {
+		return err
+	}
+	return wc.Export(ctx)
+}
+
+func (wc *WC) bisectMark(ctx context.Context, se gotcore.CommitExpr, fn func(bs *BisectState, ref gdat.Ref)) (*gotcore.BisectStep, error) {
+	cfg, err := LoadConfig(wc.root)
+	if err != nil {
+		return nil, err
+	}
+	bs := cfg.Bisect
+	if bs == nil {
+		return nil, fmt.Errorf("no bisect in progress, start one first")
+	}
+	var ref gdat.Ref
+	if se != nil {
+		if ref, err = wc.repo.ResolveCommit(ctx, se); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(cfg.Base) != 1 {
+			return nil, fmt.Errorf("cannot bisect, working copy has %d base commits", len(cfg.Base))
+		}
+		ref = cfg.Base[0]
+	}
+	fn(bs, ref)
+	if err := EditConfig(wc.root, func(x Config) Config {
+		x.Bisect = bs
+		return x
+	}); err != nil {
+		return nil, err
+	}
+	return wc.bisectStep(ctx, bs)
+}
+
+// bisectStep picks the next Commit to test, and checks it out.
+func (wc *WC) bisectStep(ctx context.Context, bs *BisectState) (*gotcore.BisectStep, error) {
+	if bs.Bad == nil || len(bs.Good) == 0 {
+		return nil, nil
+	}
+	step, err := wc.repo.Bisect(ctx, gotrepo.FQM{Name: bs.Mark}, *bs.Bad, bs.Good, bs.Skip)
+	if err != nil {
+		return nil, err
+	}
+	if step.Done() {
+		return step, nil
+	}
+	if err := EditConfig(wc.root, func(x Config) Config {
+		x.Base = []gdat.Ref{step.Next}
+		return x
+	}); err != nil {
+		return nil, err
+	}
+	if err := wc.export(ctx, &step.Next); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// checkNotBisecting returns an error if there is a bisection in progress.
+func (wc *WC) checkNotBisecting() error {
+	bs, err := wc.GetBisect()
+	if err != nil {
+		return err
+	}
+	if bs != nil {
+		return fmt.Errorf("bisect in progress on %q, reset it first", bs.Mark)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"blobcache.io/blobcache/src/blobcache"
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/gotwc/internal/porting"
 	"github.com/gotvc/got/src/internal/gotbc"
 	"github.com/gotvc/got/src/internal/gotcfg"
 )
@@ -30,8 +31,16 @@ type Config struct {
 	ActAs string `json:"act_as"`
 	// Tracking is a list of tracked prefixes
 	Tracking []string `json:"tracking"`
+	// Bisect is the state of the bisection in progress, if there is one.
+	Bisect *BisectState `json:"bisect,omitempty"`
+	// Attrs selects the file metadata, other than the mode, which is recorded on import
+	// and restored on export.
+	Attrs AttrPolicy `json:"attrs"`
 }
 
+// AttrPolicy selects which file metadata is imported into, and exported from, gotfs.Info.Attrs.
+type AttrPolicy = porting.AttrPolicy
+
 type BlobcacheSpec = gotbc.Config
 
 func DefaultConfig() Config {
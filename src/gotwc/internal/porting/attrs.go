@@ -0,0 +1,120 @@
+package porting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotvc/got/src/gotfs"
+	"go.brendoncarroll.net/state/posixfs"
+)
+
+// AttrPolicy selects the metadata, other than the mode, which is imported into gotfs.Info.Attrs
+// and restored from it on export.
+// The zero value imports nothing.
+//
+// A file is only imported again when its modification time, mode or size changes,
+// so changing only the owner or extended attributes of a file will go unnoticed.
+type AttrPolicy struct {
+	// Xattrs imports the extended attributes, under gotfs.AttrXattrPrefix.
+	Xattrs bool `json:"xattrs,omitempty"`
+	// ModTime imports the modification time, as gotfs.AttrModTime.
+	ModTime bool `json:"mtime,omitempty"`
+	// Owner imports the user and group ids, as gotfs.AttrUID and gotfs.AttrGID.
+	Owner bool `json:"owner,omitempty"`
+}
+
+// IsZero returns true if the policy does not import any metadata.
+func (ap AttrPolicy) IsZero() bool {
+	return ap == AttrPolicy{}
+}
+
+// AttrFS is a LinkFS which can also read and write the metadata selected by an AttrPolicy.
+type AttrFS interface {
+	LinkFS
+	Chtimes(p string, atime, mtime time.Time) error
+	Lchown(p string, uid, gid int) error
+	// Xattrs returns all of the extended attributes of the file at p.
+	Xattrs(p string) (map[string][]byte, error)
+	SetXattr(p, name string, value []byte) error
+}
+
+// readAttrs returns the Attrs selected by ap, for the file in fsx at p.
+func readAttrs(fsx posixfs.FS, p string, ap AttrPolicy) (map[string][]byte, error) {
+	if ap.IsZero() {
+		return nil, nil
+	}
+	finfo, err := Lstat(fsx, p)
+	if err != nil {
+		return nil, err
+	}
+	var info gotfs.Info
+	if ap.ModTime {
+		info.SetModTime(finfo.ModTime())
+	}
+	if ap.Owner {
+		uid, gid, ok := fileOwner(finfo)
+		if !ok {
+			return nil, fmt.Errorf("cannot import owner of %q, not available on this platform", p)
+		}
+		info.SetOwner(uid, gid)
+	}
+	// extended attributes cannot be set on symbolic links on most platforms.
+	if ap.Xattrs && !isSymlink(finfo.Mode()) {
+		afs, ok := fsx.(AttrFS)
+		if !ok {
+			return nil, fmt.Errorf("cannot import extended attributes of %q, filesystem does not support them", p)
+		}
+		xattrs, err := afs.Xattrs(p)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range xattrs {
+			info.SetXattr(name, value)
+		}
+	}
+	return info.Attrs, nil
+}
+
+// writeAttrs restores the Attrs selected by ap, from info to the file in fsx at p.
+// The modification time is set last, since setting anything else could change it.
+func writeAttrs(fsx posixfs.FS, p string, info *gotfs.Info, ap AttrPolicy) error {
+	if ap.IsZero() || len(info.Attrs) == 0 {
+		return nil
+	}
+	afs, ok := fsx.(AttrFS)
+	if !ok {
+		return fmt.Errorf("cannot export attributes of %q, filesystem does not support them", p)
+	}
+	// symbolic links only get their owner, the rest would be applied to their targets.
+	link := info.IsSymlink()
+	if ap.Xattrs && !link {
+		for name, value := range info.Xattrs() {
+			if err := afs.SetXattr(p, name, value); err != nil {
+				return err
+			}
+		}
+	}
+	if ap.Owner {
+		uid, gid, ok, err := info.Owner()
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := afs.Lchown(p, int(uid), int(gid)); err != nil {
+				return err
+			}
+		}
+	}
+	if ap.ModTime && !link {
+		mtime, ok, err := info.ModTime()
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := afs.Chtimes(p, time.Time{}, mtime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
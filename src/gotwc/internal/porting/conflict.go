@@ -0,0 +1,134 @@
+package porting
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/gotvc/got/src/gotfs"
+	"go.brendoncarroll.net/state/posixfs"
+	"go.brendoncarroll.net/stdctx/logctx"
+)
+
+const (
+	// MaxConflictMarkerSize is the largest file which will be written with conflict markers.
+	// Larger files are exported as .ours and .theirs siblings.
+	MaxConflictMarkerSize = 1 << 22
+
+	SuffixOurs   = ".ours"
+	SuffixTheirs = ".theirs"
+)
+
+// ExportConflict writes a conflicted path to the filesystem.
+// ours and theirs are the filesystems at p on either side of the conflict, nil if the path did not exist on that side.
+// If both sides are small text files, a single file with conflict markers is written at p.
+// Otherwise, the regular files are written to siblings of p, with the SuffixOurs and SuffixTheirs suffixes.
+// None of the written files are recorded in the database, so they show up as dirty until they are resolved.
+func (pr *Exporter) ExportConflict(ctx context.Context, ss gotfs.RO, p string, ours, theirs *gotfs.Root) error {
+	oursData, oursOk, err := pr.readConflictSide(ctx, ss, ours)
+	if err != nil {
+		return err
+	}
+	theirsData, theirsOk, err := pr.readConflictSide(ctx, ss, theirs)
+	if err != nil {
+		return err
+	}
+	if oursOk && theirsOk && isText(oursData) && isText(theirsData) {
+		if err := posixfs.PutFile(ctx, pr.fsx, p, 0o644, bytes.NewReader(ConflictMarkers(oursData, theirsData))); err != nil {
+			return err
+		}
+		return pr.db.Delete(ctx, p)
+	}
+	for _, side := range []struct {
+		Root   *gotfs.Root
+		Suffix string
+	}{
+		{ours, SuffixOurs},
+		{theirs, SuffixTheirs},
+	} {
+		if side.Root == nil {
+			continue
+		}
+		info, err := pr.gotfs.GetInfo(ctx, ss.Metadata, *side.Root, "")
+		if err != nil {
+			return err
+		}
+		if !info.Mode.IsRegular() {
+			logctx.Warnf(ctx, "conflict at %q: cannot export %v to %q", p, info.Mode, p+side.Suffix)
+			continue
+		}
+		r, err := pr.gotfs.NewReader(ctx, ss, *side.Root, "")
+		if err != nil {
+			return err
+		}
+		if err := posixfs.PutFile(ctx, pr.fsx, p+side.Suffix, info.Mode, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readConflictSide returns the contents of x if it is a regular file small enough for conflict markers.
+func (pr *Exporter) readConflictSide(ctx context.Context, ss gotfs.RO, x *gotfs.Root) ([]byte, bool, error) {
+	if x == nil {
+		return nil, false, nil
+	}
+	info, err := pr.gotfs.GetInfo(ctx, ss.Metadata, *x, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if !info.Mode.IsRegular() {
+		return nil, false, nil
+	}
+	size, err := pr.gotfs.SizeOfFile(ctx, ss.Metadata, *x, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if size > MaxConflictMarkerSize {
+		return nil, false, nil
+	}
+	data, err := pr.gotfs.ReadFile(ctx, ss, *x, "", MaxConflictMarkerSize)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// ConflictMarkers returns a file containing both ours and theirs, with the differing lines
+// surrounded by conflict markers.
+// Lines which are the same at the start and end of both files are only included once.
+func ConflictMarkers(ours, theirs []byte) []byte {
+	ol := bytes.SplitAfter(ours, []byte("\n"))
+	tl := bytes.SplitAfter(theirs, []byte("\n"))
+	var prefix int
+	for prefix < len(ol) && prefix < len(tl) && bytes.Equal(ol[prefix], tl[prefix]) {
+		prefix++
+	}
+	var suffix int
+	for suffix < len(ol)-prefix && suffix < len(tl)-prefix && bytes.Equal(ol[len(ol)-1-suffix], tl[len(tl)-1-suffix]) {
+		suffix++
+	}
+	var out []byte
+	writeLines := func(lines [][]byte) {
+		for _, line := range lines {
+			out = append(out, line...)
+		}
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			out = append(out, '\n')
+		}
+	}
+	writeLines(ol[:prefix])
+	out = append(out, "<<<<<<< ours\n"...)
+	writeLines(ol[prefix : len(ol)-suffix])
+	out = append(out, "=======\n"...)
+	writeLines(tl[prefix : len(tl)-suffix])
+	out = append(out, ">>>>>>> theirs\n"...)
+	for _, line := range ol[len(ol)-suffix:] {
+		out = append(out, line...)
+	}
+	return out
+}
+
+// isText returns true if data does not contain any NULL bytes.
+func isText(data []byte) bool {
+	return bytes.IndexByte(data, 0) < 0
+}
@@ -0,0 +1,34 @@
+package porting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflictMarkers(t *testing.T) {
+	tcs := []struct {
+		Ours, Theirs string
+		Out          string
+	}{
+		{
+			Ours:   "a\nb\nc\n",
+			Theirs: "a\nx\nc\n",
+			Out:    "a\n<<<<<<< ours\nb\n=======\nx\n>>>>>>> theirs\nc\n",
+		},
+		{
+			Ours:   "ours",
+			Theirs: "theirs",
+			Out:    "<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n",
+		},
+		{
+			Ours:   "a\nb\n",
+			Theirs: "a\n",
+			Out:    "a\n<<<<<<< ours\nb\n=======\n>>>>>>> theirs\n",
+		},
+	}
+	for _, tc := range tcs {
+		out := ConflictMarkers([]byte(tc.Ours), []byte(tc.Theirs))
+		require.Equal(t, tc.Out, string(out))
+	}
+}
@@ -18,14 +18,17 @@ type Exporter struct {
 	db     *DB
 	fsx    posixfs.FS
 	filter func(p string) bool
+	attrs  AttrPolicy
 }
 
-func NewExporter(gotfs *gotfs.Machine, db *DB, fsx posixfs.FS, filter func(p string) bool) *Exporter {
+// NewExporter returns an Exporter which restores the metadata selected by attrs, along with the content.
+func NewExporter(gotfs *gotfs.Machine, db *DB, fsx posixfs.FS, filter func(p string) bool, attrs AttrPolicy) *Exporter {
 	return &Exporter{
 		gotfs:  gotfs,
 		db:     db,
 		fsx:    fsx,
 		filter: filter,
+		attrs:  attrs,
 	}
 }
 
@@ -36,9 +39,12 @@ func (pr *Exporter) ExportPath(ctx context.Context, ss gotfs.RO, root gotfs.Root
 		return err
 	}
 	ms, ds := ss.Metadata, ss.Data
-	if gfinfo.Mode.IsDir() {
+	switch {
+	case gfinfo.Mode.IsDir():
 		return pr.exportDir(ctx, ms, ds, root, p, gfinfo)
-	} else {
+	case gfinfo.IsSymlink():
+		return pr.exportSymlink(ctx, ss, root, p, gfinfo)
+	default:
 		return pr.exportFile(ctx, ms, ds, root, p, gfinfo)
 	}
 }
@@ -68,7 +74,10 @@ func (pr *Exporter) Clobber(ctx context.Context, ss gotfs.RO, root gotfs.Root, p
 	if err != nil {
 		return err
 	}
-	if err := posixfs.PutFile(ctx, pr.fsx, p, md.Mode, r); err != nil {
+	if err := putFile(pr.fsx, p, md.Mode, r); err != nil {
+		return err
+	}
+	if err := writeAttrs(pr.fsx, p, md, pr.attrs); err != nil {
 		return err
 	}
 	finfo, err := stat(pr.fsx, p)
@@ -84,7 +93,7 @@ func (pr *Exporter) Clobber(ctx context.Context, ss gotfs.RO, root gotfs.Root, p
 
 // exportDir exports a known dir in root
 func (pr *Exporter) exportDir(ctx context.Context, ms, ds stores.RO, root gotfs.Root, p string, ginfo *gotfs.Info) error {
-	finfo, err := pr.fsx.Stat(p)
+	finfo, err := Lstat(pr.fsx, p)
 	switch {
 	case err != nil && !posixfs.IsErrNotExist(err):
 		// something went wrong, return
@@ -129,10 +138,14 @@ func (pr *Exporter) exportDir(ctx context.Context, ms, ds stores.RO, root gotfs.
 	}
 	// list all the entries that should exist, and recursively call ExportPath
 	if err := pr.gotfs.ReadDir(ctx, ms, root, p, func(e gotfs.DirEnt) error {
-		return pr.ExportPath(ctx, gotfs.RO{Metadata: ms, Data: ds}, root, e.Name)
+		return pr.ExportPath(ctx, gotfs.RO{Metadata: ms, Data: ds}, root, path.Join(p, e.Name))
 	}); err != nil {
 		return err
 	}
+	// attributes are restored after the children, which would change the modification time.
+	if err := writeAttrs(pr.fsx, p, ginfo, pr.attrs); err != nil {
+		return err
+	}
 	// record directory state after exporting children
 	dirInfo, err := stat(pr.fsx, p)
 	if err != nil {
@@ -149,30 +162,8 @@ func (pr *Exporter) exportDir(ctx context.Context, ms, ds stores.RO, root gotfs.
 
 // exportFile exports a known file in root
 func (pr *Exporter) exportFile(ctx context.Context, ms, ds stores.RO, root gotfs.Root, p string, ginfo *gotfs.Info) error {
-	// check if a file exists
-	finfo, err := stat(pr.fsx, p)
-	if err != nil && !posixfs.IsErrNotExist(err) {
+	if err := pr.clearPath(ctx, p); err != nil {
 		return err
-	} else if err == nil {
-		var dbinfo FileInfo
-		if found, err := pr.db.GetInfo(ctx, p, &dbinfo); err != nil {
-			return err
-		} else if !found {
-			return ErrWouldClobber{
-				Op:   "write",
-				Path: p,
-			}
-		} else if HasChanged(&dbinfo, finfo) {
-			return ErrWouldClobber{
-				Op:   "write",
-				Path: p,
-			}
-		}
-	}
-	if finfo != nil && finfo.Mode.IsDir() {
-		if err := pr.deleteDir(ctx, p); err != nil {
-			return err
-		}
 	}
 	gfinfo, err := pr.gotfs.GetFileInfo(ctx, ms, root, p)
 	if err != nil {
@@ -182,10 +173,13 @@ func (pr *Exporter) exportFile(ctx context.Context, ms, ds stores.RO, root gotfs
 	if err != nil {
 		return err
 	}
-	if err := posixfs.PutFile(ctx, pr.fsx, p, gfinfo.Mode, r); err != nil {
+	if err := putFile(pr.fsx, p, gfinfo.Mode, r); err != nil {
+		return err
+	}
+	if err := writeAttrs(pr.fsx, p, ginfo, pr.attrs); err != nil {
 		return err
 	}
-	finfo, err = stat(pr.fsx, p)
+	finfo, err := stat(pr.fsx, p)
 	if err != nil {
 		return err
 	}
@@ -193,6 +187,60 @@ func (pr *Exporter) exportFile(ctx context.Context, ms, ds stores.RO, root gotfs
 	return pr.db.PutInfo(ctx, *finfo)
 }
 
+// exportSymlink exports a known symbolic link in root.
+func (pr *Exporter) exportSymlink(ctx context.Context, ss gotfs.RO, root gotfs.Root, p string, ginfo *gotfs.Info) error {
+	if err := pr.clearPath(ctx, p); err != nil {
+		return err
+	}
+	target, err := pr.gotfs.Readlink(ctx, ss, root, p)
+	if err != nil {
+		return err
+	}
+	if err := pr.fsx.Symlink(target, p); err != nil {
+		return err
+	}
+	if err := writeAttrs(pr.fsx, p, ginfo, pr.attrs); err != nil {
+		return err
+	}
+	finfo, err := stat(pr.fsx, p)
+	if err != nil {
+		return err
+	}
+	finfo.ByGot = true
+	return pr.db.PutInfo(ctx, *finfo)
+}
+
+// clearPath removes whatever is in the filesystem at p, to make way for a file or symbolic link.
+// Nothing is removed which has not been imported, or has changed since it was imported.
+func (pr *Exporter) clearPath(ctx context.Context, p string) error {
+	finfo, err := stat(pr.fsx, p)
+	if err != nil {
+		if posixfs.IsErrNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var dbinfo FileInfo
+	if found, err := pr.db.GetInfo(ctx, p, &dbinfo); err != nil {
+		return err
+	} else if !found {
+		return ErrWouldClobber{
+			Op:   "write",
+			Path: p,
+		}
+	} else if HasChanged(&dbinfo, finfo) {
+		return ErrWouldClobber{
+			Op:   "write",
+			Path: p,
+		}
+	}
+	if finfo.Mode.IsDir() {
+		return pr.deleteDir(ctx, p)
+	}
+	// files are removed as well, since writing to a symbolic link would write to its target.
+	return pr.fsx.Remove(p)
+}
+
 func (pr *Exporter) deleteFile(ctx context.Context, p string) error {
 	var dbinfo FileInfo
 	if found, err := pr.db.GetInfo(ctx, p, &dbinfo); err != nil {
@@ -11,11 +11,12 @@ import (
 )
 
 // NewFSInfoIter iterates over all the tracked paths in the filesystem.
+// Symbolic links are not followed, if fsys is a LinkFS.
 func NewFSInfoIter(fsys posixfs.FS, base string) streams.Iterator[FileInfo] {
 	seq := func(yield func(FileInfo, error) bool) {
 		var walk func(string) bool
 		walk = func(p string) bool {
-			finfo, err := fsys.Stat(p)
+			finfo, err := Lstat(fsys, p)
 			if err != nil {
 				return false
 			}
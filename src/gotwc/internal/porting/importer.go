@@ -25,12 +25,15 @@ type Importer struct {
 	gotfs  *gotfs.Machine
 	db     *DB
 	ms, ds stores.RW
+	attrs  AttrPolicy
 }
 
-func NewImporter(fsmach *gotfs.Machine, db *DB, ss [2]stores.RW) *Importer {
+// NewImporter returns an Importer which imports the metadata selected by attrs, along with the content.
+func NewImporter(fsmach *gotfs.Machine, db *DB, ss [2]stores.RW, attrs AttrPolicy) *Importer {
 	return &Importer{
 		gotfs: fsmach,
 		db:    db,
+		attrs: attrs,
 
 		ms: ss[1],
 		ds: ss[0],
@@ -39,8 +42,9 @@ func NewImporter(fsmach *gotfs.Machine, db *DB, ss [2]stores.RW) *Importer {
 
 // ImportPath returns gotfs instance containing the content in fsx at p.
 // The content will be at the root of the filesystem.
+// Symbolic links are imported as they are, without following them, if fsx is a LinkFS.
 func (pr *Importer) ImportPath(ctx context.Context, fsx posixfs.FS, p string) (*gotfs.Root, error) {
-	finfo, err := fsx.Stat(p)
+	finfo, err := Lstat(fsx, p)
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +88,9 @@ func (pr *Importer) importDir(ctx context.Context, fsx posixfs.FS, p string, fin
 	if err != nil {
 		return nil, err
 	}
+	if root, err = pr.putAttrs(ctx, fsx, p, root); err != nil {
+		return nil, err
+	}
 	if p != "" {
 		// for directories we don't add the root, just the mode and modified at.
 		if err := pr.db.PutInfo(ctx, FileInfo{
@@ -102,9 +109,15 @@ func (pr *Importer) ImportFile(ctx context.Context, fsx posixfs.FS, p string) (*
 	return pr.importFile(ctx, fsx, p)
 }
 
-// ImportFile returns a gotfs.Root with the content from the file in fsx at p.
+// ImportFile returns a gotfs.Root with the content from the file, or symbolic link, in fsx at p.
 func (pr *Importer) importFile(ctx context.Context, fsx posixfs.FS, p string) (*gotfs.Root, error) {
 	finfo, err := stat(fsx, p)
+	if err != nil {
+		return nil, err
+	}
+	if isSymlink(finfo.Mode) {
+		return pr.importSymlink(ctx, fsx, finfo)
+	}
 	if !finfo.Mode.IsRegular() {
 		return nil, fmt.Errorf("ImportFile called for non-regular file at path %q", p)
 	}
@@ -114,7 +127,7 @@ func (pr *Importer) importFile(ctx context.Context, fsx posixfs.FS, p string) (*
 	} else if ok && !HasChanged(&ent, finfo) {
 		logctx.Infof(ctx, "using cache entry for path %q. skipped import", p)
 		var root gotfs.Root
-		if yes, err := pr.db.GetFSRoot(ctx, p, &root); err != nil {
+		if yes, err := pr.db.GetFSRoot(ctx, p, pr.attrs, &root); err != nil {
 			return nil, err
 		} else if yes {
 			return &root, nil
@@ -143,19 +156,61 @@ func (pr *Importer) importFile(ctx context.Context, fsx posixfs.FS, p string) (*
 			return nil, err
 		}
 	}
+	if root, err = pr.putAttrs(ctx, fsx, p, root); err != nil {
+		return nil, err
+	}
 	// need update
 	finfo.ByGot = false
 	if err := pr.db.PutInfo(ctx, *finfo); err != nil {
 		return nil, err
 	}
-	if err := pr.db.PutFSRoot(ctx, p, finfo.ModifiedAt, *root); err != nil {
+	if err := pr.db.PutFSRoot(ctx, p, finfo.ModifiedAt, pr.attrs, *root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// importSymlink returns a gotfs.Root with the symbolic link in fsx at finfo.Path.
+func (pr *Importer) importSymlink(ctx context.Context, fsx posixfs.FS, finfo *FileInfo) (*gotfs.Root, error) {
+	target, err := readlink(fsx, finfo.Path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := pr.gotfs.NewSymlink(ctx, gotfs.RW{Data: pr.ds, Metadata: pr.ms}, target)
+	if err != nil {
+		return nil, err
+	}
+	if root, err = pr.putAttrs(ctx, fsx, finfo.Path, root); err != nil {
+		return nil, err
+	}
+	finfo.ByGot = false
+	if err := pr.db.PutInfo(ctx, *finfo); err != nil {
 		return nil, err
 	}
 	return root, nil
 }
 
+// putAttrs reads the metadata selected by the AttrPolicy from fsx at p,
+// and adds it to the Info at the root of x.
+func (pr *Importer) putAttrs(ctx context.Context, fsx posixfs.FS, p string, x *gotfs.Root) (*gotfs.Root, error) {
+	attrs, err := readAttrs(fsx, p, pr.attrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs) == 0 {
+		return x, nil
+	}
+	info, err := pr.gotfs.GetInfo(ctx, pr.ms, *x, "")
+	if err != nil {
+		return nil, err
+	}
+	info.Attrs = attrs
+	return pr.gotfs.PutInfo(ctx, pr.ms, *x, "", info)
+}
+
+// stat returns the FileInfo for p, without following a symbolic link at p.
 func stat(fsys posixfs.FS, p string) (*FileInfo, error) {
-	finfo, err := fsys.Stat(p)
+	finfo, err := Lstat(fsys, p)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,237 @@
+package porting
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.brendoncarroll.net/state/posixfs"
+)
+
+// LinkFS is a posixfs.FS which can also read symbolic links, without following them.
+// The Importer and Exporter only preserve symbolic links in filesystems which implement LinkFS;
+// in any other filesystem they are followed.
+type LinkFS interface {
+	posixfs.FS
+	Lstat(p string) (posixfs.FileInfo, error)
+	Readlink(p string) (string, error)
+}
+
+// Lstat is like fsx.Stat, except that it does not follow a symbolic link at p, if fsx is a LinkFS.
+func Lstat(fsx posixfs.FS, p string) (posixfs.FileInfo, error) {
+	if lfs, ok := fsx.(LinkFS); ok {
+		return lfs.Lstat(p)
+	}
+	return fsx.Stat(p)
+}
+
+func readlink(fsx posixfs.FS, p string) (string, error) {
+	lfs, ok := fsx.(LinkFS)
+	if !ok {
+		return "", fmt.Errorf("cannot read symbolic link %q, filesystem does not support them", p)
+	}
+	return lfs.Readlink(p)
+}
+
+func isSymlink(mode fs.FileMode) bool {
+	return mode&fs.ModeSymlink != 0
+}
+
+// NewRootFS returns an AttrFS for the directory at root.
+// Symbolic links are created as they are, so they can point outside of root,
+// but they are never followed out of root by the AttrFS itself.
+func NewRootFS(root *os.Root) AttrFS {
+	return rootFS{root: root}
+}
+
+type rootFS struct {
+	root *os.Root
+}
+
+func (r rootFS) OpenFile(p string, flag int, perm os.FileMode) (posixfs.File, error) {
+	f, err := r.root.OpenFile(rootPath(p), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return rootFile{f}, nil
+}
+
+func (r rootFS) Mkdir(p string, perm os.FileMode) error {
+	return r.root.Mkdir(rootPath(p), perm)
+}
+
+func (r rootFS) Rmdir(p string) error {
+	return r.root.RemoveAll(rootPath(p))
+}
+
+func (r rootFS) Remove(p string) error {
+	return r.root.Remove(rootPath(p))
+}
+
+func (r rootFS) Rename(oldPath, newPath string) error {
+	return r.root.Rename(rootPath(oldPath), rootPath(newPath))
+}
+
+func (r rootFS) Stat(p string) (posixfs.FileInfo, error) {
+	return r.root.Stat(rootPath(p))
+}
+
+func (r rootFS) Lstat(p string) (posixfs.FileInfo, error) {
+	return r.root.Lstat(rootPath(p))
+}
+
+func (r rootFS) Readlink(p string) (string, error) {
+	return r.root.Readlink(rootPath(p))
+}
+
+// Symlink creates a symbolic link at link, which points to target.
+func (r rootFS) Symlink(target, link string) error {
+	return r.root.Symlink(target, rootPath(link))
+}
+
+func (r rootFS) Chtimes(p string, atime, mtime time.Time) error {
+	return r.root.Chtimes(rootPath(p), atime, mtime)
+}
+
+func (r rootFS) Lchown(p string, uid, gid int) error {
+	return r.root.Lchown(rootPath(p), uid, gid)
+}
+
+func (r rootFS) Xattrs(p string) (map[string][]byte, error) {
+	f, err := r.root.Open(rootPath(p))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readXattrs(f)
+}
+
+func (r rootFS) SetXattr(p, name string, value []byte) error {
+	f, err := r.root.Open(rootPath(p))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeXattr(f, name, value)
+}
+
+func rootPath(p string) string {
+	if p == "" {
+		return "."
+	}
+	return filepath.FromSlash(p)
+}
+
+type rootFile struct {
+	*os.File
+}
+
+func (f rootFile) ReadDir(n int) ([]posixfs.DirEnt, error) {
+	dirEnts, err := f.File.ReadDir(n)
+	if err != nil {
+		return nil, err
+	}
+	ents := make([]posixfs.DirEnt, len(dirEnts))
+	for i := range dirEnts {
+		finfo, err := dirEnts[i].Info()
+		if err != nil {
+			return nil, err
+		}
+		ents[i] = posixfs.DirEnt{
+			Name: dirEnts[i].Name(),
+			Mode: finfo.Mode(),
+		}
+	}
+	return ents, nil
+}
+
+// NewFiltered is like posixfs.NewFiltered, but it returns an AttrFS.
+// The attribute methods return errors.ErrUnsupported if x is not an AttrFS.
+func NewFiltered(x LinkFS, predicate func(string) bool) AttrFS {
+	return filteredFS{
+		FS:        posixfs.NewFiltered(x, predicate),
+		x:         x,
+		predicate: predicate,
+	}
+}
+
+type filteredFS struct {
+	posixfs.FS
+	x         LinkFS
+	predicate func(string) bool
+}
+
+func (fs filteredFS) Lstat(p string) (posixfs.FileInfo, error) {
+	if err := fs.checkPath(p); err != nil {
+		return nil, err
+	}
+	return fs.x.Lstat(p)
+}
+
+func (fs filteredFS) Readlink(p string) (string, error) {
+	if err := fs.checkPath(p); err != nil {
+		return "", err
+	}
+	return fs.x.Readlink(p)
+}
+
+// Symlink only checks the path of the link, the target is not a path in the filesystem.
+func (fs filteredFS) Symlink(target, link string) error {
+	if err := fs.checkPath(link); err != nil {
+		return err
+	}
+	return fs.x.Symlink(target, link)
+}
+
+func (fs filteredFS) Chtimes(p string, atime, mtime time.Time) error {
+	afs, err := fs.attrFS(p)
+	if err != nil {
+		return err
+	}
+	return afs.Chtimes(p, atime, mtime)
+}
+
+func (fs filteredFS) Lchown(p string, uid, gid int) error {
+	afs, err := fs.attrFS(p)
+	if err != nil {
+		return err
+	}
+	return afs.Lchown(p, uid, gid)
+}
+
+func (fs filteredFS) Xattrs(p string) (map[string][]byte, error) {
+	afs, err := fs.attrFS(p)
+	if err != nil {
+		return nil, err
+	}
+	return afs.Xattrs(p)
+}
+
+func (fs filteredFS) SetXattr(p, name string, value []byte) error {
+	afs, err := fs.attrFS(p)
+	if err != nil {
+		return err
+	}
+	return afs.SetXattr(p, name, value)
+}
+
+func (fs filteredFS) attrFS(p string) (AttrFS, error) {
+	if err := fs.checkPath(p); err != nil {
+		return nil, err
+	}
+	afs, ok := fs.x.(AttrFS)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return afs, nil
+}
+
+func (fs filteredFS) checkPath(p string) error {
+	if fs.predicate(p) {
+		return nil
+	}
+	return fmt.Errorf("path %q has been filtered", p)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package porting
+
+import "io/fs"
+
+func fileOwner(finfo fs.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
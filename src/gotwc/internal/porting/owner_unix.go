@@ -0,0 +1,16 @@
+//go:build unix
+
+package porting
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+func fileOwner(finfo fs.FileInfo) (uid, gid uint32, ok bool) {
+	st, ok := finfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return st.Uid, st.Gid, true
+}
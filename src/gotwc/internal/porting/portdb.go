@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"strings"
 
 	"github.com/gotvc/got/src/gotfs"
 	"github.com/gotvc/got/src/gotwc/internal/sqlutil"
+	"github.com/gotvc/got/src/internal/stores"
 	"go.brendoncarroll.net/exp/streams"
 	"go.brendoncarroll.net/tai64"
 	"zombiezen.com/go/sqlite"
@@ -39,8 +41,8 @@ func (db *DB) PutInfo(ctx context.Context, ent FileInfo) error {
 		return fmt.Errorf("import/export DB does not allow the root to be stored")
 	}
 	p := ent.Path
-	// replacing the info should also delete the root if it exists.
-	if err := sqlutil.Exec(db.conn, `DELETE FROM fsroots WHERE path = ? AND param_hash = ?`, p, db.paramHash[:]); err != nil {
+	// replacing the info should also delete the roots if they exist, for any AttrPolicy.
+	if err := sqlutil.Exec(db.conn, `DELETE FROM fsroots WHERE path = ?`, p); err != nil {
 		return err
 	}
 	return sqlutil.Exec(db.conn, `INSERT OR REPLACE INTO dirstate (path, mode, modtime, size, by_got) VALUES (?, ?, ?, ?, ?)`, p, uint32(ent.Mode), ent.ModifiedAt.Marshal(), ent.Size, ent.ByGot)
@@ -65,7 +67,40 @@ func (db *DB) Delete(ctx context.Context, p string) error {
 	return nil
 }
 
-func (db *DB) PutFSRoot(ctx context.Context, p string, modt tai64.TAI64N, fsroot gotfs.Root) error {
+// Move moves all information associated with the path from, and the paths beneath it, to the path to.
+func (db *DB) Move(ctx context.Context, from, to string) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("import/export DB does not allow the root to be moved")
+	}
+	// "0" is the byte after "/", so this selects everything beneath from.
+	var infos []FileInfo
+	for info, err := range sqlutil.Select(db.conn, scanInfo, `SELECT path, modtime, mode, size, by_got FROM dirstate
+		WHERE path = ? OR (path > ? AND path < ?)
+		ORDER BY path`, from, from+"/", from+"0") {
+		if err != nil {
+			return err
+		}
+		infos = append(infos, info)
+	}
+	for _, info := range infos {
+		prev := info.Path
+		info.Path = to + strings.TrimPrefix(prev, from)
+		if err := db.PutInfo(ctx, info); err != nil {
+			return err
+		}
+		if err := sqlutil.Exec(db.conn, `UPDATE fsroots SET path = ? WHERE path = ?`, info.Path, prev); err != nil {
+			return err
+		}
+		if err := db.Delete(ctx, prev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutFSRoot caches fsroot as the result of importing the file at p, with the metadata selected by ap.
+// modt must match the modification time in the info for p.
+func (db *DB) PutFSRoot(ctx context.Context, p string, modt tai64.TAI64N, ap AttrPolicy, fsroot gotfs.Root) error {
 	var info FileInfo
 	if ok, err := db.GetInfo(ctx, p, &info); err != nil {
 		return err
@@ -75,16 +110,36 @@ func (db *DB) PutFSRoot(ctx context.Context, p string, modt tai64.TAI64N, fsroot
 	if info.ModifiedAt != modt {
 		return fmt.Errorf("modtime does not match")
 	}
-	return sqlutil.Exec(db.conn, `UPDATE fsroots
-		SET fsroot = ?
-		WHERE path = ? AND param_hash = ?
-	`, fsroot.Marshal(nil), p, db.paramHash[:])
+	key := db.rootKey(ap)
+	return sqlutil.Exec(db.conn, `INSERT OR REPLACE INTO fsroots (param_hash, path, fsroot)
+		VALUES (?, ?, ?)
+	`, key[:], p, fsroot.Marshal(nil))
 }
 
-func (db *DB) GetFSRoot(ctx context.Context, p string, dst *gotfs.Root) (bool, error) {
+// GetFSRoot looks up the root cached by PutFSRoot for the file at p, imported with the metadata selected by ap.
+func (db *DB) GetFSRoot(ctx context.Context, p string, ap AttrPolicy, dst *gotfs.Root) (bool, error) {
+	key := db.rootKey(ap)
 	return sqlutil.GetOne(db.conn, dst, scanFSRoot, `SELECT fsroot FROM fsroots
 		WHERE path = ? AND param_hash = ?
-	`, p, db.paramHash[:])
+	`, p, key[:])
+}
+
+// rootKey returns the key that roots imported with ap are cached under.
+// The AttrPolicy changes which attributes end up in the root, so it is part of the key.
+// Roots imported without any attributes are cached under the paramHash alone.
+func (db *DB) rootKey(ap AttrPolicy) [32]byte {
+	if ap.IsZero() {
+		return db.paramHash
+	}
+	data := append([]byte{}, db.paramHash[:]...)
+	for _, yes := range []bool{ap.Xattrs, ap.ModTime, ap.Owner} {
+		if yes {
+			data = append(data, 1)
+		} else {
+			data = append(data, 0)
+		}
+	}
+	return stores.Hash(data)
 }
 
 // scanInfo expects:
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotfs"
@@ -189,7 +190,7 @@ func TestExport(t *testing.T) {
 			}
 			root := makeGotFS(t, &mach, s, tt.InGot)
 
-			exp := NewExporter(&mach, db, fsys, func(string) bool { return true })
+			exp := NewExporter(&mach, db, fsys, func(string) bool { return true }, AttrPolicy{})
 			err := exp.ExportPath(ctx, ss, root, tt.ExportPath)
 			if tt.Err == nil {
 				require.NoError(t, err)
@@ -248,7 +249,7 @@ func TestImportPath(t *testing.T) {
 			mach := gotcore.GotFS(cfg)
 			conn, paramHash := newTestDB(t, ctx, cfg)
 			db := NewDB(conn, paramHash)
-			imp := NewImporter(&mach, db, [2]stores.RW{dst, dst})
+			imp := NewImporter(&mach, db, [2]stores.RW{dst, dst}, AttrPolicy{})
 
 			// prepare files on disk
 			dir := testutil.OpenRoot(t, t.TempDir())
@@ -275,6 +276,208 @@ func TestImportPath(t *testing.T) {
 	}
 }
 
+// TestSymlinks tests that symbolic links are imported and exported without following them.
+func TestSymlinks(t *testing.T) {
+	ctx := testutil.Context(t)
+	cfg := gotcore.DefaultConfig(false)
+	mach := gotcore.GotFS(cfg)
+	s := stores.NewMem()
+
+	src := testutil.OpenRoot(t, t.TempDir())
+	writeToFS(t, src, []FileEntry{
+		{Path: "dir", Mode: 0o755 | fs.ModeDir},
+		{Path: "dir/a.txt", Mode: 0o644, Data: "a"},
+	})
+	require.NoError(t, src.Symlink("a.txt", "dir/link"))
+	require.NoError(t, src.Symlink("missing", "dangling"))
+	require.NoError(t, src.Symlink("dir", "dirlink"))
+
+	conn, paramHash := newTestDB(t, ctx, cfg)
+	imp := NewImporter(&mach, NewDB(conn, paramHash), [2]stores.RW{s, s}, AttrPolicy{})
+	root, err := imp.ImportPath(ctx, NewRootFS(src), "")
+	require.NoError(t, err)
+	targets := map[string]string{
+		"dir/link": "a.txt",
+		"dangling": "missing",
+		"dirlink":  "dir",
+	}
+	for p, expected := range targets {
+		target, err := mach.Readlink(ctx, gotfs.RO{s, s}, *root, p)
+		require.NoError(t, err, p)
+		require.Equal(t, expected, target, p)
+	}
+
+	dst := testutil.OpenRoot(t, t.TempDir())
+	conn, paramHash = newTestDB(t, ctx, cfg)
+	exp := NewExporter(&mach, NewDB(conn, paramHash), NewRootFS(dst), func(string) bool { return true }, AttrPolicy{})
+	require.NoError(t, exp.ExportPath(ctx, gotfs.RO{s, s}, *root, ""))
+	for p, expected := range targets {
+		target, err := dst.Readlink(p)
+		require.NoError(t, err, p)
+		require.Equal(t, expected, target, p)
+	}
+	data, err := dst.ReadFile("dir/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "a", string(data))
+}
+
+// TestExportSparse tests that a file with holes is exported with the right size and contents.
+func TestExportSparse(t *testing.T) {
+	ctx := testutil.Context(t)
+	cfg := gotcore.DefaultConfig(false)
+	mach := gotcore.GotFS(cfg)
+	s := stores.NewMem()
+	ss := gotfs.RW{s, s}
+
+	const size = 3 << 20
+	expected := make([]byte, size)
+	copy(expected, "head")
+	copy(expected[2<<20:], "middle")
+	root, err := mach.NewEmpty(ctx, s, 0o755)
+	require.NoError(t, err)
+	root, err = mach.PutFile(ctx, ss, *root, "sparse.bin", strings.NewReader("head"))
+	require.NoError(t, err)
+	// a hole in the middle, and another at the end.
+	root, err = mach.WriteAt(ctx, ss, *root, "sparse.bin", 2<<20, []byte("middle"))
+	require.NoError(t, err)
+	root, err = mach.Truncate(ctx, ss, *root, "sparse.bin", size)
+	require.NoError(t, err)
+
+	dst := testutil.OpenRoot(t, t.TempDir())
+	conn, paramHash := newTestDB(t, ctx, cfg)
+	exp := NewExporter(&mach, NewDB(conn, paramHash), NewRootFS(dst), func(string) bool { return true }, AttrPolicy{})
+	require.NoError(t, exp.ExportPath(ctx, gotfs.RO{s, s}, *root, ""))
+	finfo, err := dst.Stat("sparse.bin")
+	require.NoError(t, err)
+	require.EqualValues(t, size, finfo.Size())
+	actual, err := dst.ReadFile("sparse.bin")
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+// TestAttrs tests that the metadata selected by an AttrPolicy survives a round trip.
+func TestAttrs(t *testing.T) {
+	ctx := testutil.Context(t)
+	cfg := gotcore.DefaultConfig(false)
+	mach := gotcore.GotFS(cfg)
+	s := stores.NewMem()
+	ap := AttrPolicy{ModTime: true, Owner: true, Xattrs: true}
+
+	src := testutil.OpenRoot(t, t.TempDir())
+	writeToFS(t, src, []FileEntry{
+		{Path: "dir", Mode: 0o755 | fs.ModeDir},
+		{Path: "dir/a.txt", Mode: 0o644, Data: "a"},
+	})
+	srcFS := NewRootFS(src)
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	require.NoError(t, src.Chtimes("dir/a.txt", mtime, mtime))
+	require.NoError(t, src.Chtimes("dir", mtime, mtime))
+	if err := srcFS.SetXattr("dir/a.txt", "user.got.test", []byte("value")); err != nil {
+		t.Log("extended attributes not supported here:", err)
+		ap.Xattrs = false
+	}
+
+	conn, paramHash := newTestDB(t, ctx, cfg)
+	imp := NewImporter(&mach, NewDB(conn, paramHash), [2]stores.RW{s, s}, ap)
+	root, err := imp.ImportPath(ctx, srcFS, "")
+	require.NoError(t, err)
+	info, err := mach.GetInfo(ctx, s, *root, "dir/a.txt")
+	require.NoError(t, err)
+	actual, ok, err := info.ModTime()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, mtime.Equal(actual))
+	_, _, ok, err = info.Owner()
+	require.NoError(t, err)
+	require.True(t, ok)
+	if ap.Xattrs {
+		require.Equal(t, "value", string(info.Xattrs()["user.got.test"]))
+	}
+
+	dst := testutil.OpenRoot(t, t.TempDir())
+	conn, paramHash = newTestDB(t, ctx, cfg)
+	dstFS := NewRootFS(dst)
+	exp := NewExporter(&mach, NewDB(conn, paramHash), dstFS, func(string) bool { return true }, ap)
+	require.NoError(t, exp.ExportPath(ctx, gotfs.RO{s, s}, *root, ""))
+	for _, p := range []string{"dir", "dir/a.txt"} {
+		finfo, err := dst.Stat(p)
+		require.NoError(t, err)
+		require.True(t, mtime.Equal(finfo.ModTime()), p)
+	}
+	if ap.Xattrs {
+		xattrs, err := dstFS.Xattrs("dir/a.txt")
+		require.NoError(t, err)
+		require.Equal(t, "value", string(xattrs["user.got.test"]))
+	}
+
+	// without a policy, nothing is imported.
+	conn, paramHash = newTestDB(t, ctx, cfg)
+	imp = NewImporter(&mach, NewDB(conn, paramHash), [2]stores.RW{s, s}, AttrPolicy{})
+	root, err = imp.ImportPath(ctx, srcFS, "")
+	require.NoError(t, err)
+	info, err = mach.GetInfo(ctx, s, *root, "dir/a.txt")
+	require.NoError(t, err)
+	require.Empty(t, info.Attrs)
+}
+
+// TestAttrsCache tests that roots cached by the importer are not reused after the AttrPolicy changes.
+func TestAttrsCache(t *testing.T) {
+	ctx := testutil.Context(t)
+	cfg := gotcore.DefaultConfig(false)
+	mach := gotcore.GotFS(cfg)
+	s := stores.NewMem()
+	ap := AttrPolicy{ModTime: true}
+
+	src := testutil.OpenRoot(t, t.TempDir())
+	writeToFS(t, src, []FileEntry{
+		{Path: "a.txt", Mode: 0o644, Data: "a"},
+	})
+	srcFS := NewRootFS(src)
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	require.NoError(t, src.Chtimes("a.txt", mtime, mtime))
+
+	conn, paramHash := newTestDB(t, ctx, cfg)
+	db := NewDB(conn, paramHash)
+	importFile := func(ap AttrPolicy) gotfs.Root {
+		t.Helper()
+		imp := NewImporter(&mach, db, [2]stores.RW{s, s}, ap)
+		root, err := imp.ImportFile(ctx, srcFS, "a.txt")
+		require.NoError(t, err)
+		var cached gotfs.Root
+		ok, err := db.GetFSRoot(ctx, "a.txt", ap, &cached)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, *root, cached)
+		return *root
+	}
+	hasModTime := func(root gotfs.Root) bool {
+		t.Helper()
+		info, err := mach.GetInfo(ctx, s, root, "")
+		require.NoError(t, err)
+		_, ok, err := info.ModTime()
+		require.NoError(t, err)
+		return ok
+	}
+
+	withAttrs := importFile(ap)
+	require.True(t, hasModTime(withAttrs))
+	withoutAttrs := importFile(AttrPolicy{})
+	require.False(t, hasModTime(withoutAttrs))
+	require.Equal(t, withAttrs, importFile(ap))
+	require.Equal(t, withoutAttrs, importFile(AttrPolicy{}))
+
+	// changing the file drops the cached roots for every policy.
+	writeToFS(t, src, []FileEntry{
+		{Path: "a.txt", Mode: 0o644, Data: "b"},
+	})
+	require.NoError(t, src.Chtimes("a.txt", mtime.Add(time.Hour), mtime.Add(time.Hour)))
+	require.NotEqual(t, withoutAttrs, importFile(AttrPolicy{}))
+	var cached gotfs.Root
+	ok, err := db.GetFSRoot(ctx, "a.txt", ap, &cached)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
 func newTestDB(t testing.TB, ctx context.Context, cfg gotcore.DSConfig) (*sqlutil.Conn, [32]byte) {
 	t.Helper()
 	pool := sqlutil.NewTestPool(t)
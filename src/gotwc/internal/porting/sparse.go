@@ -0,0 +1,68 @@
+package porting
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotfs/gotlob"
+	"go.brendoncarroll.net/state/posixfs"
+)
+
+// putFile writes the file read from r to fsx at p.
+// Holes in r are skipped over instead of written, so they become holes in the file,
+// on filesystems which support sparse files.
+func putFile(fsx posixfs.FS, p string, mode fs.FileMode, r *gotfs.Reader) error {
+	f, err := fsx.OpenFile(p, posixfs.O_TRUNC|posixfs.O_WRONLY|posixfs.O_CREATE, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	for offset < size {
+		start, err := r.Seek(offset, gotlob.SeekData)
+		if err != nil {
+			return err
+		}
+		if start >= size {
+			break
+		}
+		end, err := r.Seek(start, gotlob.SeekHole)
+		if err != nil {
+			return err
+		}
+		if _, err := r.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(f, r, end-start); err != nil {
+			return err
+		}
+		offset = end
+	}
+	if offset < size {
+		// the file ends with a hole, it has to be extended to the full size.
+		if err := extendFile(f, size); err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// extendFile extends f to size, which must be larger than what has been written so far.
+func extendFile(f posixfs.File, size int64) error {
+	if tf, ok := f.(interface{ Truncate(int64) error }); ok {
+		return tf.Truncate(size)
+	}
+	if _, err := f.Seek(size-1, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := f.Write([]byte{0})
+	return err
+}
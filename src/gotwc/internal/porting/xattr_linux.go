@@ -0,0 +1,64 @@
+//go:build linux
+
+package porting
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+func readXattrs(f *os.File) (map[string][]byte, error) {
+	fd := int(f.Fd())
+	names, err := getXattrBuf(func(buf []byte) (int, error) {
+		return unix.Flistxattr(fd, buf)
+	})
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ret := make(map[string][]byte)
+	for _, name := range strings.Split(string(names), "\x00") {
+		if name == "" {
+			continue
+		}
+		value, err := getXattrBuf(func(buf []byte) (int, error) {
+			return unix.Fgetxattr(fd, name, buf)
+		})
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = value
+	}
+	return ret, nil
+}
+
+func writeXattr(f *os.File, name string, value []byte) error {
+	return unix.Fsetxattr(int(f.Fd()), name, value, 0)
+}
+
+// getXattrBuf calls fn once to get the size of the result, and again to fill it.
+// If the result grows in between, it tries again.
+func getXattrBuf(fn func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		n, err := fn(nil)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return []byte{}, nil
+		}
+		buf := make([]byte, n)
+		n, err = fn(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
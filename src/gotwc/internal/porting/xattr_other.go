@@ -0,0 +1,18 @@
+//go:build !linux
+
+package porting
+
+import (
+	"errors"
+	"os"
+)
+
+var errXattrsUnsupported = errors.New("extended attributes are not supported on this platform")
+
+func readXattrs(f *os.File) (map[string][]byte, error) {
+	return nil, errXattrsUnsupported
+}
+
+func writeXattr(f *os.File, name string, value []byte) error {
+	return errXattrsUnsupported
+}
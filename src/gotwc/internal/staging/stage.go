@@ -19,16 +19,38 @@ import (
 )
 
 type Operation struct {
-	Delete *DeleteOp `json:"del,omitempty"`
-	Put    *PutOp    `json:"put,omitempty"`
+	Delete   *DeleteOp   `json:"del,omitempty"`
+	Put      *PutOp      `json:"put,omitempty"`
+	Conflict *ConflictOp `json:"conflict,omitempty"`
+	Move     *MoveOp     `json:"mv,omitempty"`
 }
 
 // DeleteOp deletes a path and everything beneath it
-type DeleteOp struct{}
+type DeleteOp struct {
+	// MovedTo is set if the path is deleted because it was moved.
+	// There is a MoveOp at MovedTo.
+	MovedTo string `json:"to,omitempty"`
+}
+
+// MoveOp moves a path, and everything beneath it, from another path in the base filesystem.
+// There is always a DeleteOp at From, with MovedTo set to the path of the MoveOp.
+type MoveOp struct {
+	From string `json:"from"`
+}
 
 // PutOp replaces a path with a filesystem.
 type PutOp = gotfs.Root
 
+// ConflictOp records a path which was changed differently on both sides of a merge.
+// Each side is the filesystem at the path, in the same form as a PutOp.
+// A side is nil if the path did not exist on that side.
+// A ConflictOp must be replaced, by putting or deleting the path, before the stage can be committed.
+type ConflictOp struct {
+	Base   *gotfs.Root `json:"base,omitempty"`
+	Ours   *gotfs.Root `json:"ours,omitempty"`
+	Theirs *gotfs.Root `json:"theirs,omitempty"`
+}
+
 type Entry struct {
 	Path string    `json:"p"`
 	Op   Operation `json:"op"`
@@ -150,6 +172,11 @@ func (tx *Tx) PutRoot(ctx context.Context, p string, root gotfs.Root) error {
 	return tx.put(ctx, p, op)
 }
 
+// PutConflict marks the path p as conflicted.
+func (tx *Tx) PutConflict(ctx context.Context, p string, op ConflictOp) error {
+	return tx.put(ctx, p, Operation{Conflict: &op})
+}
+
 // PutInfo creates a root, which can be used to overwrite just the info.
 func PutInfo(ctx context.Context, fsmach *gotfs.Machine, ms stores.RW, p string, info gotfs.Info) (*gotfs.Root, error) {
 	p = cleanPath(p)
@@ -179,11 +206,61 @@ func (tx *Tx) Delete(ctx context.Context, p string) error {
 	return tx.kvtx.Put(ctx, []byte(p), val)
 }
 
+// Move moves the path from to the path to.
+// A DeleteOp is put at from, and a MoveOp at to.
+// Move fails if anything is already staged at, above, or beneath either path,
+// since those entries would not be moved along with it.
+func (tx *Tx) Move(ctx context.Context, from, to string) error {
+	if err := tx.setup(ctx); err != nil {
+		return err
+	}
+	from, to = cleanPath(from), cleanPath(to)
+	if from == "" || to == "" {
+		return fmt.Errorf("cannot move the root")
+	}
+	if from == to || strings.HasPrefix(to+"/", from+"/") || strings.HasPrefix(from+"/", to+"/") {
+		return fmt.Errorf("cannot move %q to %q", from, to)
+	}
+	for _, p := range []string{from, to} {
+		var op Operation
+		if found, err := tx.Get(ctx, p, &op); err != nil {
+			return err
+		} else if found {
+			return fmt.Errorf("cannot move %q to %q. there is already an entry for %q", from, to, p)
+		}
+		if err := tx.CheckConflict(ctx, p); err != nil {
+			return fmt.Errorf("cannot move %q to %q: %w", from, to, err)
+		}
+	}
+	if err := tx.put(ctx, from, Operation{Delete: &DeleteOp{MovedTo: to}}); err != nil {
+		return err
+	}
+	return tx.put(ctx, to, Operation{Move: &MoveOp{From: from}})
+}
+
 func (tx *Tx) Discard(ctx context.Context, p string) error {
 	if err := tx.setup(ctx); err != nil {
 		return err
 	}
 	p = cleanPath(p)
+	// the 2 halves of a move are discarded together.
+	var op Operation
+	if found, err := tx.Get(ctx, p, &op); err != nil {
+		return err
+	} else if found {
+		var other string
+		switch {
+		case op.Move != nil:
+			other = op.Move.From
+		case op.Delete != nil && op.Delete.MovedTo != "":
+			other = op.Delete.MovedTo
+		}
+		if other != "" {
+			if err := tx.kvtx.Delete(ctx, []byte(other)); err != nil {
+				return err
+			}
+		}
+	}
 	if err := tx.kvtx.Delete(ctx, []byte(p)); err != nil {
 		return err
 	}
@@ -213,6 +290,7 @@ func (tx *Tx) Get(ctx context.Context, p string, dst *Operation) (bool, error) {
 	if err := json.Unmarshal(val, &op); err != nil {
 		return false, err
 	}
+	*dst = op
 	return true, nil
 }
 
@@ -288,6 +366,16 @@ func (tx *Tx) IsEmpty(ctx context.Context) (bool, error) {
 	}
 }
 
+// ForEachConflict calls fn for each path with an unresolved ConflictOp.
+func (tx *Tx) ForEachConflict(ctx context.Context, fn func(p string, op ConflictOp) error) error {
+	return tx.ForEach(ctx, func(ent Entry) error {
+		if ent.Op.Conflict == nil {
+			return nil
+		}
+		return fn(ent.Path, *ent.Op.Conflict)
+	})
+}
+
 func (tx *Tx) CreateFunction(ctx context.Context, fsag *gotfs.Machine, ss gotfs.RW) (gotfsvm.Function, error) {
 	it, err := tx.Iterate(ctx, gotkv.TotalSpan())
 	if err != nil {
@@ -301,6 +389,11 @@ func (tx *Tx) CreateFunction(ctx context.Context, fsag *gotfs.Machine, ss gotfs.
 			fileOp := ent.Op
 			p := ent.Path
 			switch {
+			case fileOp.Move != nil:
+				// moves are applied to the base, before any of the segments.
+				// the DeleteOp at the source has nothing left to delete.
+				baseExpr = fb.MkdirAll(baseExpr, path.Dir(p), 0o755)
+				baseExpr = fb.Move(baseExpr, fileOp.Move.From, p)
 			case fileOp.Put != nil:
 				baseExpr = fb.MkdirAll(baseExpr, path.Dir(p), 0o755)
 				segs = append(segs, fsag.ShiftOut(gotfs.Root(*fileOp.Put).Segment(), p))
@@ -308,6 +401,8 @@ func (tx *Tx) CreateFunction(ctx context.Context, fsag *gotfs.Machine, ss gotfs.
 				segs = append(segs, gotfs.Segment{
 					Span: gotfs.SpanForPath(p),
 				})
+			case fileOp.Conflict != nil:
+				return fmt.Errorf("unresolved conflict for path %q", p)
 			default:
 				logctx.Warnf(ctx, "empty op for path %q", p)
 				return nil
@@ -0,0 +1,210 @@
+package gotwc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.brendoncarroll.net/exp/streams"
+	"go.brendoncarroll.net/state/posixfs"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/gotwc/internal/staging"
+	"github.com/gotvc/got/src/internal/gotcore"
+	"github.com/gotvc/got/src/internal/stores"
+)
+
+// Merge merges the Commit at se into the head mark, and then exports the result.
+// The staging area must be empty.
+//
+// If the merge has conflicts, the head mark is not changed.
+// Instead, the merged changes are staged, the conflicting paths are recorded in the staging area,
+// and both are exported to the working copy.
+// gotcore.ErrMergeConflict is returned, and the merge is completed by resolving the conflicts and committing.
+func (wc *WC) Merge(ctx context.Context, se gotcore.CommitExpr, params CommitParams) error {
+	if err := wc.checkNotBisecting(); err != nil {
+		return err
+	}
+	if emptyStage, err := wc.StageIsEmpty(ctx); err != nil {
+		return err
+	} else if !emptyStage {
+		return fmt.Errorf("cannot merge, staging area must be empty (it's not)")
+	}
+	var signer *gotcore.Signer
+	if params.Committer.IsZero() {
+		var err error
+		if signer, err = wc.getSigner(ctx); err != nil {
+			return err
+		}
+		params.Committer = signer.ID()
+	}
+	ctx = gotcore.WithLogInfo(ctx, params.Committer, "merge")
+	if params.Message == "" {
+		params.Message = "merge " + gotcore.FormatCommitExpr(se)
+	}
+	saveTo, err := wc.GetSaveTo()
+	if err != nil {
+		return err
+	}
+	fqm := gotrepo.FQM{Name: saveTo}
+	var conflicts []string
+	var bases []gdat.Ref
+	if err := wc.modifyStaging(ctx, func(sctx stagingCtx) error {
+		return wc.repo.ModifyFrom(ctx, fqm, se, func(mctx gotcore.ModifyCtx, ref gdat.Ref) (*gotcore.Commit, error) {
+			if mctx.Target.IsZero() {
+				comm, err := mctx.VC.GetVertex(ctx, mctx.Stores.VC, ref)
+				if err != nil {
+					return nil, err
+				}
+				return &comm, nil
+			}
+			scratch := sctx.Store
+			s := gotcore.RW{
+				FS: gotfs.RW{
+					Data:     stores.NewOverlay(mctx.Stores.FS.Data, scratch),
+					Metadata: stores.NewOverlay(mctx.Stores.FS.Metadata, scratch),
+				},
+				VC: stores.NewOverlay(mctx.Stores.VC, scratch),
+			}
+			res, err := gotcore.PrepareMerge(ctx, mctx.Machine, s, mctx.Index, mctx.Target, ref)
+			if err != nil {
+				return nil, err
+			}
+			if res.FastForward != nil {
+				return res.FastForward, nil
+			}
+			if len(res.Conflicts) > 0 {
+				if err := stageMerge(ctx, sctx, s.FS, res); err != nil {
+					return nil, err
+				}
+				conflicts = res.Conflicts
+				bases = []gdat.Ref{mctx.Target, ref}
+				// the mark is left as it is, theirs has been synced into the space, so it will be available to commit.
+				return mctx.Commit, nil
+			}
+			next, err := gotcore.CreateCommit(ctx, &mctx.VC, s.VC, gotcore.CommitParams{
+				Committer:   params.Committer,
+				CommittedAt: params.CommittedAt,
+				Base:        []gotcore.Commit{res.Ours, res.Theirs},
+				Snap:        res.Snap,
+				Notes: gotcore.CommitNotes{
+					Authors:    params.Authors,
+					AuthoredAt: params.AuthoredAt,
+					Message:    params.Message,
+				},
+				Signer: signer,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := mctx.Sync(ctx, s.RO(), next); err != nil {
+				return nil, err
+			}
+			return &next, nil
+		})
+	}); err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		if err := EditConfig(wc.root, func(x Config) Config {
+			x.Base = bases
+			return x
+		}); err != nil {
+			return err
+		}
+		return gotcore.ErrMergeConflict{Paths: conflicts}
+	}
+	ref, err := wc.repo.MarkLoad(ctx, fqm)
+	if err != nil {
+		return err
+	}
+	if err := EditConfig(wc.root, func(x Config) Config {
+		x.Base = []gdat.Ref{ref}
+		return x
+	}); err != nil {
+		return err
+	}
+	return wc.Export(ctx)
+}
+
+// stageMerge stages the difference between Ours and the merged filesystem in res,
+// records a conflict for each of the conflicting paths, and then exports everything to the working copy.
+func stageMerge(ctx context.Context, sctx stagingCtx, ss gotfs.RW, res *gotcore.MergeResult) error {
+	fsmach := sctx.GotFS
+	stage := sctx.Stage
+	ours := res.Ours.Payload.Snap
+	isConflict := make(map[string]struct{}, len(res.Conflicts))
+	for _, p := range res.Conflicts {
+		isConflict[p] = struct{}{}
+	}
+	var staged []string
+	isStaged := func(p string) bool {
+		for _, x := range staged {
+			if p == x || strings.HasPrefix(p, x+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	it := fsmach.NewDiffer(ss.Metadata, ours, res.Snap)
+	if err := streams.ForEach(ctx, it, func(dent gotfs.DiffEntry) error {
+		p := dent.Key.Path()
+		if _, yes := isConflict[p]; yes || isStaged(p) {
+			return nil
+		}
+		info, err := fsmach.GetInfo(ctx, ss.Metadata, res.Snap, p)
+		if posixfs.IsErrNotExist(err) {
+			staged = append(staged, p)
+			return stage.Delete(ctx, p)
+		} else if err != nil {
+			return err
+		}
+		if info.Mode.IsDir() {
+			if _, err := fsmach.GetInfo(ctx, ss.Metadata, ours, p); err == nil {
+				// the directory exists on both sides, the changes beneath it will be staged individually.
+				return nil
+			} else if !posixfs.IsErrNotExist(err) {
+				return err
+			}
+		}
+		root, err := fsmach.Pick(ctx, ss.Metadata, res.Snap, p)
+		if err != nil {
+			return err
+		}
+		staged = append(staged, p)
+		return stage.PutRoot(ctx, p, *root)
+	}); err != nil {
+		return err
+	}
+	if err := sctx.Exporter.ExportPath(ctx, ss.RO(), res.Snap, ""); err != nil {
+		return err
+	}
+	for _, p := range res.Conflicts {
+		var op staging.ConflictOp
+		for _, side := range []struct {
+			dst  **gotfs.Root
+			root gotfs.Root
+		}{
+			{&op.Base, res.Base},
+			{&op.Ours, ours},
+			{&op.Theirs, res.Theirs.Payload.Snap},
+		} {
+			root, err := fsmach.Pick(ctx, ss.Metadata, side.root, p)
+			if posixfs.IsErrNotExist(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			*side.dst = root
+		}
+		if err := stage.PutConflict(ctx, p, op); err != nil {
+			return err
+		}
+		if err := sctx.Exporter.ExportConflict(ctx, ss.RO(), p, op.Ours, op.Theirs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
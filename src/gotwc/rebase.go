@@ -0,0 +1,89 @@
+package gotwc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotrepo"
+	"github.com/gotvc/got/src/internal/gotcore"
+)
+
+// CherryPick applies the change made by the Commit at se to the head mark, and then exports the result.
+// The staging area must be empty.
+func (wc *WC) CherryPick(ctx context.Context, se gotcore.CommitExpr) error {
+	return wc.rewriteHead(ctx, "cherry-pick", func(ctx context.Context, fqm gotrepo.FQM, params gotrepo.ReplayParams) error {
+		return wc.repo.CherryPick(ctx, fqm, se, params)
+	})
+}
+
+// Revert undoes the change made by the Commit at se with a new Commit on the head mark, and then exports the result.
+// The staging area must be empty.
+func (wc *WC) Revert(ctx context.Context, se gotcore.CommitExpr) error {
+	return wc.rewriteHead(ctx, "revert", func(ctx context.Context, fqm gotrepo.FQM, params gotrepo.ReplayParams) error {
+		return wc.repo.Revert(ctx, fqm, se, params)
+	})
+}
+
+// Rebase replays the Commits on the head mark, which are not in the history of the Commit at onto,
+// on top of onto, and then exports the result.
+// The staging area must be empty.
+func (wc *WC) Rebase(ctx context.Context, onto gotcore.CommitExpr) error {
+	return wc.rewriteHead(ctx, "rebase", func(ctx context.Context, fqm gotrepo.FQM, params gotrepo.ReplayParams) error {
+		return wc.repo.Rebase(ctx, fqm, onto, params)
+	})
+}
+
+// rewriteHead calls fn to rewrite the history of the head mark, and then updates the base and exports the new head.
+// op names the operation in the head mark's log.
+func (wc *WC) rewriteHead(ctx context.Context, op string, fn func(context.Context, gotrepo.FQM, gotrepo.ReplayParams) error) error {
+	if err := wc.checkNotBisecting(); err != nil {
+		return err
+	}
+	if emptyStage, err := wc.StageIsEmpty(ctx); err != nil {
+		return err
+	} else if !emptyStage {
+		return fmt.Errorf("cannot rewrite history, staging area must be empty (it's not)")
+	}
+	signer, err := wc.getSigner(ctx)
+	if err != nil {
+		return err
+	}
+	saveTo, err := wc.GetSaveTo()
+	if err != nil {
+		return err
+	}
+	fqm := gotrepo.FQM{Name: saveTo}
+	ctx = gotcore.WithLogInfo(ctx, signer.ID(), op)
+	if err := fn(ctx, fqm, gotrepo.ReplayParams{Committer: signer.ID(), Signer: signer}); err != nil {
+		return err
+	}
+	ref, err := wc.repo.MarkLoad(ctx, fqm)
+	if err != nil {
+		return err
+	}
+	if err := EditConfig(wc.root, func(x Config) Config {
+		x.Base = []gdat.Ref{ref}
+		return x
+	}); err != nil {
+		return err
+	}
+	return wc.Export(ctx)
+}
+
+// Squash replaces the last n Commits on the head mark with a single Commit, and then exports the result.
+// The staging area must be empty.
+func (wc *WC) Squash(ctx context.Context, n int) error {
+	return wc.rewriteHead(ctx, "squash", func(ctx context.Context, fqm gotrepo.FQM, params gotrepo.ReplayParams) error {
+		return wc.repo.Squash(ctx, fqm, n, params)
+	})
+}
+
+// SquashUntil replaces all the Commits on the head mark after the Commit at until with a single Commit,
+// and then exports the result.
+// The staging area must be empty.
+func (wc *WC) SquashUntil(ctx context.Context, until gotcore.CommitExpr) error {
+	return wc.rewriteHead(ctx, "squash", func(ctx context.Context, fqm gotrepo.FQM, params gotrepo.ReplayParams) error {
+		return wc.repo.SquashUntil(ctx, fqm, until, params)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"path"
 	"slices"
+	"strings"
 
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotrepo"
@@ -65,6 +67,10 @@ func (wc *WC) modifyStaging(ctx context.Context, fn func(sctx stagingCtx) error)
 		if err != nil {
 			return err
 		}
+		attrs, err := wc.getAttrPolicy()
+		if err != nil {
+			return err
+		}
 		cfg := info.Config
 		paramHash := cfg.Hash()
 		fsmach := gotcore.GotFS(cfg)
@@ -76,8 +82,8 @@ func (wc *WC) modifyStaging(ctx context.Context, fn func(sctx stagingCtx) error)
 		stagingStore := stagetx.Store()
 		storePair := [2]stores.RW{stagingStore, stagingStore}
 		dirState := porting.NewDB(conn, paramHash)
-		imp := porting.NewImporter(&fsmach, dirState, storePair)
-		exp := porting.NewExporter(&fsmach, dirState, fsys, filter)
+		imp := porting.NewImporter(&fsmach, dirState, storePair, attrs)
+		exp := porting.NewExporter(&fsmach, dirState, fsys, filter, attrs)
 		vcmach := gotcore.GotVC(cfg)
 		if err := fn(stagingCtx{
 			Stage:    stagetx,
@@ -115,9 +121,13 @@ func (wc *WC) viewStaging(ctx context.Context, fn func(sctx stagingCtx) error) e
 		if err != nil {
 			return err
 		}
+		attrs, err := wc.getAttrPolicy()
+		if err != nil {
+			return err
+		}
 		portdb := porting.NewDB(conn, paramHash)
 		fsmach := gotcore.GotFS(info.Config)
-		exp := porting.NewExporter(&fsmach, portdb, filtFS, filter)
+		exp := porting.NewExporter(&fsmach, portdb, filtFS, filter, attrs)
 		stagingStore, err := wc.repo.BeginStagingTx(ctx, wc.id, false)
 		if err != nil {
 			return err
@@ -178,7 +188,7 @@ func (wc *WC) Add(ctx context.Context, paths ...string) error {
 			}); err != nil {
 				return err
 			}
-			if finfo, err := sctx.FS.Stat(target); err != nil && !posixfs.IsErrNotExist(err) {
+			if finfo, err := porting.Lstat(sctx.FS, target); err != nil && !posixfs.IsErrNotExist(err) {
 				return err
 			} else if err == nil && finfo.IsDir() {
 				if err := sctx.DB.PutInfo(ctx, FileInfo{
@@ -233,7 +243,7 @@ func (wc *WC) Rm(ctx context.Context, paths ...string) error {
 			stage := sctx.Stage
 
 			for _, target := range paths {
-				if _, err := sctx.FS.Stat(target); err != nil && !posixfs.IsErrNotExist(err) {
+				if _, err := porting.Lstat(sctx.FS, target); err != nil && !posixfs.IsErrNotExist(err) {
 					return err
 				} else if err == nil {
 					return fmt.Errorf("cannot stage rm, file exists at path %s", target)
@@ -254,6 +264,44 @@ func (wc *WC) Rm(ctx context.Context, paths ...string) error {
 	})
 }
 
+// Mv moves a path known to version control, and everything beneath it, to the path to.
+// The move is staged as it is, so nothing needs to be imported again.
+// If from still exists in the working directory, it is renamed to to.
+func (wc *WC) Mv(ctx context.Context, from, to string) error {
+	return wc.modifyStaging(ctx, func(sctx stagingCtx) error {
+		return wc.viewSnap(ctx, func(vctx *gotcore.ViewCtx) error {
+			if vctx.Root == nil {
+				return fmt.Errorf("path %q not found", from)
+			}
+			ms, snap := vctx.FSRO().Metadata, vctx.Root.Payload.Snap
+			if _, err := vctx.FS.GetInfo(ctx, ms, snap, from); err != nil {
+				return fmt.Errorf("cannot move %q: %w", from, err)
+			}
+			if yes, err := vctx.FS.Exists(ctx, ms, snap, to); err != nil {
+				return err
+			} else if yes {
+				return fmt.Errorf("cannot move %q, path %q already exists", from, to)
+			}
+			if err := sctx.Stage.Move(ctx, from, to); err != nil {
+				return err
+			}
+			if _, err := porting.Lstat(sctx.FS, from); err != nil && !posixfs.IsErrNotExist(err) {
+				return err
+			} else if err == nil {
+				if _, err := porting.Lstat(sctx.FS, to); err == nil {
+					return fmt.Errorf("cannot move %q, file exists at path %s", from, to)
+				} else if !posixfs.IsErrNotExist(err) {
+					return err
+				}
+				if err := sctx.FS.Rename(from, to); err != nil {
+					return err
+				}
+			}
+			return sctx.DB.Move(ctx, from, to)
+		})
+	})
+}
+
 // Discard removes any staged changes for a path
 func (wc *WC) Discard(ctx context.Context, paths ...string) error {
 	return wc.modifyStaging(ctx, func(sctx stagingCtx) error {
@@ -292,18 +340,30 @@ type CommitParams struct {
 	Committer inet256.ID
 }
 
+// getSigner returns a Signer for the identity that the working copy is acting as.
+func (wc *WC) getSigner(ctx context.Context) (*gotcore.Signer, error) {
+	actAs, err := wc.GetActAs()
+	if err != nil {
+		return nil, err
+	}
+	return wc.repo.GetSigner(ctx, actAs)
+}
+
+// Commit creates a new Commit from the staging area, and saves it to the head mark.
+// If params.Committer is zero, then the Commit is signed by the identity that the working copy is acting as.
 func (wc *WC) Commit(ctx context.Context, params CommitParams) error {
+	if err := wc.checkNotBisecting(); err != nil {
+		return err
+	}
+	var signer *gotcore.Signer
 	if params.Committer.IsZero() {
-		actAs, err := wc.GetActAs()
-		if err != nil {
-			return err
-		}
-		idu, err := wc.repo.GetIdentity(ctx, actAs)
-		if err != nil {
+		var err error
+		if signer, err = wc.getSigner(ctx); err != nil {
 			return err
 		}
-		params.Committer = idu.ID
+		params.Committer = signer.ID()
 	}
+	ctx = gotcore.WithLogInfo(ctx, params.Committer, "commit")
 	return wc.modifyStaging(ctx, func(sctx stagingCtx) error {
 		if yes, err := sctx.Stage.IsEmpty(ctx); err != nil {
 			return err
@@ -311,6 +371,16 @@ func (wc *WC) Commit(ctx context.Context, params CommitParams) error {
 			logctx.Warnf(ctx, "nothing to commit")
 			return nil
 		}
+		var conflicts []string
+		if err := sctx.Stage.ForEachConflict(ctx, func(p string, _ staging.ConflictOp) error {
+			conflicts = append(conflicts, p)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("cannot commit, %d unresolved conflict(s): %s", len(conflicts), strings.Join(conflicts, ", "))
+		}
 		ctx, cf := metrics.Child(ctx, "applying changes")
 		defer cf()
 		scratch := sctx.Store
@@ -363,6 +433,7 @@ func (wc *WC) Commit(ctx context.Context, params CommitParams) error {
 					AuthoredAt: params.AuthoredAt,
 					Message:    params.Message,
 				},
+				Signer: signer,
 			})
 			if err != nil {
 				return nil, err
@@ -400,6 +471,11 @@ type FileOperation struct {
 
 	Create *staging.PutOp
 	Modify *staging.PutOp
+
+	Conflict *staging.ConflictOp
+	// Move is set if the path was moved from another path.
+	// The other path will have a Delete, with MovedTo set.
+	Move *staging.MoveOp
 }
 
 func (wc *WC) ForEachStaging(ctx context.Context, fn func(p string, op FileOperation) error) error {
@@ -425,6 +501,10 @@ func (wc *WC) ForEachStaging(ctx context.Context, fn func(p string, op FileOpera
 				switch {
 				case sop.Delete != nil:
 					op.Delete = sop.Delete
+				case sop.Conflict != nil:
+					op.Conflict = sop.Conflict
+				case sop.Move != nil:
+					op.Move = sop.Move
 				case sop.Put != nil:
 					md, err := sctx.GotFS.GetInfo(ctx, s, root, ent.Path)
 					if err != nil && !posixfs.IsErrNotExist(err) {
@@ -442,6 +522,91 @@ func (wc *WC) ForEachStaging(ctx context.Context, fn func(p string, op FileOpera
 	})
 }
 
+// StagedRenames pairs the files deleted from the staging area with the files created in it,
+// and returns the pairs which are similar enough to be renames or copies.
+// If opts.Copies is set, created files can also be copies of modified files.
+// Deleted and put directories are expanded into the files beneath them, so moving a directory pairs up each file in it.
+// Paths moved with Mv are not included, they are reported by ForEachStaging.
+func (wc *WC) StagedRenames(ctx context.Context, opts gotfs.ChangeOptions) ([]gotfs.Change, error) {
+	var changes []gotfs.Change
+	err := wc.viewStaging(ctx, func(sctx stagingCtx) error {
+		return wc.viewMark(ctx, func(mt *gotcore.MarkTx) error {
+			var head gotfs.Root
+			hasHead, err := mt.LoadFS(ctx, &head)
+			if err != nil {
+				return err
+			}
+			ss := gotfs.RO{
+				Data:     stores.Union{mt.FSRO().Data, sctx.Store},
+				Metadata: stores.Union{mt.FSRO().Metadata, sctx.Store},
+			}
+			inHead := func(p string) (bool, error) {
+				if !hasHead {
+					return false, nil
+				}
+				return sctx.GotFS.Exists(ctx, ss.Metadata, head, p)
+			}
+			cands := gotfs.RenameCandidates{
+				Removed:  make(map[string]gotfs.FileRef),
+				Modified: make(map[string]gotfs.FileRef),
+				Added:    make(map[string]gotfs.FileRef),
+			}
+			// headFiles calls fn with each file beneath p in head.
+			headFiles := func(p string, fn func(p string) error) error {
+				if !hasHead {
+					return nil
+				}
+				return sctx.GotFS.ForEachLeaf(ctx, ss.Metadata, head, p, func(p string, _ *gotfs.Info) error {
+					return fn(p)
+				})
+			}
+			// directories are expanded into the files beneath them.
+			if err := sctx.Stage.ForEach(ctx, func(ent staging.Entry) error {
+				sop := ent.Op
+				switch {
+				case sop.Delete != nil && sop.Delete.MovedTo == "":
+					// moves are already paired up.
+					return headFiles(ent.Path, func(p string) error {
+						cands.Removed[p] = gotfs.FileRef{Root: head, Path: p}
+						return nil
+					})
+				case sop.Put != nil:
+					put := *sop.Put
+					if err := sctx.GotFS.ForEachLeaf(ctx, ss.Metadata, put, "", func(rel string, _ *gotfs.Info) error {
+						p := path.Join(ent.Path, rel)
+						if yes, err := inHead(p); err != nil {
+							return err
+						} else if yes {
+							cands.Modified[p] = gotfs.FileRef{Root: head, Path: p}
+						} else {
+							cands.Added[p] = gotfs.FileRef{Root: put, Path: rel}
+						}
+						return nil
+					}); err != nil {
+						return err
+					}
+					// putting a directory removes the files which are not in it.
+					return headFiles(ent.Path, func(p string) error {
+						rel := strings.TrimPrefix(strings.TrimPrefix(p, ent.Path), "/")
+						if yes, err := sctx.GotFS.Exists(ctx, ss.Metadata, put, rel); err != nil {
+							return err
+						} else if !yes {
+							cands.Removed[p] = gotfs.FileRef{Root: head, Path: p}
+						}
+						return nil
+					})
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			changes, err = sctx.GotFS.FindRenames(ctx, ss, cands, opts)
+			return err
+		})
+	})
+	return changes, err
+}
+
 // DirtyFile is a file that has changed in the working copy.
 type DirtyFile struct {
 	Path string
@@ -1,10 +1,15 @@
 package gotwc
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gotvc/got/src/gotfs"
 	"github.com/gotvc/got/src/internal/testutil"
 	"github.com/stretchr/testify/require"
+	"go.brendoncarroll.net/state/posixfs"
 )
 
 func TestStaging(t *testing.T) {
@@ -14,6 +19,62 @@ func TestStaging(t *testing.T) {
 	require.Len(t, ops, 0)
 }
 
+// TestStagedRenamesDir tests that moving a directory, outside of Mv, pairs up each file beneath it.
+func TestStagedRenamesDir(t *testing.T) {
+	t.Parallel()
+	ctx := testutil.Context(t)
+	wc := newTestWC(t, true)
+	fsx := posixfs.NewDirFS(wc.Dir())
+	require.NoError(t, posixfs.MkdirAll(fsx, "a/sub", 0o755))
+	require.NoError(t, posixfs.PutFile(ctx, fsx, "a/x.txt", 0o644, strings.NewReader("the content of x\n")))
+	require.NoError(t, posixfs.PutFile(ctx, fsx, "a/sub/y.txt", 0o644, strings.NewReader("the content of y\n")))
+	require.NoError(t, wc.Put(ctx, "a"))
+	require.NoError(t, wc.Commit(ctx, CommitParams{}))
+
+	require.NoError(t, os.Rename(filepath.Join(wc.Dir(), "a"), filepath.Join(wc.Dir(), "b")))
+	require.NoError(t, wc.Put(ctx, "a", "b"))
+	changes, err := wc.StagedRenames(ctx, gotfs.ChangeOptions{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []gotfs.Change{
+		{Type: gotfs.Change_Rename, From: "a/x.txt", To: "b/x.txt", Similarity: 100},
+		{Type: gotfs.Change_Rename, From: "a/sub/y.txt", To: "b/sub/y.txt", Similarity: 100},
+	}, changes)
+}
+
+// TestMvStagedChild tests that a directory cannot be moved while something beneath it is staged.
+func TestMvStagedChild(t *testing.T) {
+	t.Parallel()
+	ctx := testutil.Context(t)
+	wc := newTestWC(t, true)
+	fsx := posixfs.NewDirFS(wc.Dir())
+	require.NoError(t, posixfs.MkdirAll(fsx, "a", 0o755))
+	require.NoError(t, posixfs.PutFile(ctx, fsx, "a/x.txt", 0o644, strings.NewReader("x\n")))
+	require.NoError(t, wc.Put(ctx, "a"))
+	require.NoError(t, wc.Commit(ctx, CommitParams{}))
+
+	require.NoError(t, posixfs.PutFile(ctx, fsx, "a/x.txt", 0o644, strings.NewReader("edited\n")))
+	require.NoError(t, wc.Put(ctx, "a/x.txt"))
+	require.Error(t, wc.Mv(ctx, "a", "b"))
+	// nothing was moved, and the child is still staged where it was.
+	_, err := os.Stat(filepath.Join(wc.Dir(), "a/x.txt"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(wc.Dir(), "b"))
+	require.True(t, os.IsNotExist(err))
+	var paths []string
+	require.NoError(t, wc.ForEachStaging(ctx, func(p string, op FileOperation) error {
+		paths = append(paths, p)
+		return nil
+	}))
+	require.Equal(t, []string{"a/x.txt"}, paths)
+
+	// once the child is committed, the move goes through.
+	require.NoError(t, wc.Commit(ctx, CommitParams{}))
+	require.NoError(t, wc.Mv(ctx, "a", "b"))
+	require.NoError(t, wc.Commit(ctx, CommitParams{}))
+	checkNotExists(t, wc, "a")
+	checkExists(t, wc, "b/x.txt")
+}
+
 func listStaging(t testing.TB, x *WC) (ret []FileOperation) {
 	ctx := testutil.Context(t)
 	err := x.ForEachStaging(ctx, func(p string, op FileOperation) error {
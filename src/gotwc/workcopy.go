@@ -135,7 +135,7 @@ func New(repo *gotrepo.Repo, root *os.Root) (*WC, error) {
 		repo: repo,
 		id:   cfg.ID,
 
-		fsys: posixfs.NewDirFS(p),
+		fsys: porting.NewRootFS(root),
 		db:   db,
 	}, nil
 }
@@ -152,7 +152,7 @@ type WC struct {
 
 	// TODO: eventually we should move away from this interface, but
 	// the existing importers and exporters use it.
-	fsys    posixfs.FS
+	fsys    porting.LinkFS
 	db      *sqlutil.Pool
 	closers []func() error
 }
@@ -245,6 +245,9 @@ func (wc *WC) GetBase() ([]gdat.Ref, error) {
 // If one branch is a fork of another, or they have a common ancestor somewhere,
 // the it is very likely that they have the same content parameters.
 func (wc *WC) SetHead(ctx context.Context, name string) error {
+	if err := wc.checkNotBisecting(); err != nil {
+		return err
+	}
 	desiredInfo, err := wc.repo.InspectMark(ctx, gotrepo.FQM{Name: name})
 	if err != nil {
 		return err
@@ -346,6 +349,12 @@ func (wc *WC) StageIsEmpty(ctx context.Context) (bool, error) {
 // Export overwrites data in the filesystem with data from the Commit at HEAD.
 // Only tracked paths are overwritten.
 func (wc *WC) Export(ctx context.Context) error {
+	return wc.export(ctx, nil)
+}
+
+// export overwrites data in the filesystem with data from the Commit at ref,
+// or the Commit at HEAD if ref is nil.
+func (wc *WC) export(ctx context.Context, ref *gdat.Ref) error {
 	if emptyStage, err := wc.StageIsEmpty(ctx); err != nil {
 		return err
 	} else if !emptyStage {
@@ -368,14 +377,24 @@ func (wc *WC) Export(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		attrs, err := wc.getAttrPolicy()
+		if err != nil {
+			return err
+		}
 		var root gotfs.Root
-		if ok, err := mtx.LoadFS(ctx, &root); err != nil {
+		if ref != nil {
+			comm, err := mtx.GotVC().GetVertex(ctx, mtx.VCRO(), *ref)
+			if err != nil {
+				return err
+			}
+			root = comm.Payload.Snap
+		} else if ok, err := mtx.LoadFS(ctx, &root); err != nil {
 			return err
 		} else if !ok {
 			logctx.Warnf(ctx, "mark does not have a commit, nothing to export")
 			return nil
 		}
-		exp := porting.NewExporter(mtx.GotFS(), portDB, fsys, filter)
+		exp := porting.NewExporter(mtx.GotFS(), portDB, fsys, filter, attrs)
 		ss := mtx.FSRO()
 		return exp.ExportPath(ctx, ss, root, "")
 	})
@@ -397,8 +416,12 @@ func (wc *WC) Clobber(ctx context.Context, p string) error {
 		if err != nil {
 			return err
 		}
+		attrs, err := wc.getAttrPolicy()
+		if err != nil {
+			return err
+		}
 		portDB := porting.NewDB(conn, paramHash)
-		exp := porting.NewExporter(mtx.GotFS(), portDB, fsys, filter)
+		exp := porting.NewExporter(mtx.GotFS(), portDB, fsys, filter, attrs)
 		ss := mtx.FSRO()
 		var root gotfs.Root
 		if ok, err := mtx.LoadFS(ctx, &root); err != nil {
@@ -427,6 +450,11 @@ func (wc *WC) Fork(ctx context.Context, next string) error {
 	if err != nil {
 		return err
 	}
+	signer, err := wc.getSigner(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = gotcore.WithLogInfo(ctx, signer.ID(), "fork")
 	if err := wc.repo.CloneMark(ctx, gotrepo.FQM{Name: head}, gotrepo.FQM{Name: next}); err != nil {
 		return err
 	}
@@ -498,7 +526,16 @@ func (wc *WC) getFilteredFS(ctx context.Context) (posixfs.FS, func(string) bool,
 		return nil, nil, err
 	}
 	filter := wc.filter(spans)
-	return posixfs.NewFiltered(wc.fsys, filter), filter, nil
+	return porting.NewFiltered(wc.fsys, filter), filter, nil
+}
+
+// getAttrPolicy returns the AttrPolicy for importing and exporting.
+func (wc *WC) getAttrPolicy() (AttrPolicy, error) {
+	cfg, err := LoadConfig(wc.root)
+	if err != nil {
+		return AttrPolicy{}, err
+	}
+	return cfg.Attrs, nil
 }
 
 type Span = porting.Span
@@ -0,0 +1,111 @@
+package gotcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotdag"
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+)
+
+// BisectStep is the outcome of narrowing down a bisection.
+type BisectStep struct {
+	// Next is the Commit which should be tested next.
+	// It is zero when there is nothing left to test.
+	Next gdat.Ref
+	// Remaining is the number of Commits which could still be the first bad Commit,
+	// including the bad Commit and any skipped Commits.
+	Remaining int
+	// Candidates are the Commits which could still be the first bad Commit, when there is nothing left to test.
+	// If there is exactly one, it is the first bad Commit.
+	// There can be more than one if Commits have been skipped.
+	Candidates []gdat.Ref
+}
+
+// Done returns true if there is nothing left to test.
+func (s BisectStep) Done() bool {
+	return s.Next.IsZero()
+}
+
+var errStopBisect = errors.New("stop bisect")
+
+// Bisect picks the next Commit to test, in a search for the first bad Commit between good and bad.
+// The candidates are the Commits in the history of bad, which are not in the history of any of good.
+// Candidates in skip are never picked.
+// The next Commit is the untested candidate in the middle, ordered by N, which halves linear histories.
+func Bisect(ctx context.Context, vcmach *VCMach, s stores.RO, idx gotkv.Root, bad gdat.Ref, good, skip []gdat.Ref) (*BisectStep, error) {
+	// The walk visits every Commit before its parents, so by the time a Commit is visited
+	// it is known whether it is reachable from good.
+	fromGood := map[gdat.Ref]struct{}{}
+	fromBad := map[gdat.Ref]struct{}{}
+	// pending counts the unvisited Commits which are reachable from bad, but not good.
+	// Once it reaches 0 there are no more candidates.
+	var pending int
+	markGood := func(ref gdat.Ref) {
+		if _, exists := fromGood[ref]; exists {
+			return
+		}
+		fromGood[ref] = struct{}{}
+		if _, exists := fromBad[ref]; exists {
+			pending--
+		}
+	}
+	markBad := func(ref gdat.Ref) {
+		if _, exists := fromBad[ref]; exists {
+			return
+		}
+		fromBad[ref] = struct{}{}
+		if _, exists := fromGood[ref]; !exists {
+			pending++
+		}
+	}
+	for _, ref := range good {
+		markGood(ref)
+	}
+	markBad(bad)
+
+	var candidates []gdat.Ref
+	starts := append([]gdat.Ref{bad}, good...)
+	if err := vcmach.WalkIndex(ctx, s, idx, starts, func(ref gdat.Ref, ent gotdag.IndexEntry) error {
+		if pending == 0 {
+			return errStopBisect
+		}
+		if _, exists := fromGood[ref]; exists {
+			for _, parent := range ent.Parents {
+				markGood(parent)
+			}
+			return nil
+		}
+		if _, exists := fromBad[ref]; exists {
+			pending--
+			candidates = append(candidates, ref)
+			for _, parent := range ent.Parents {
+				markBad(parent)
+			}
+		}
+		return nil
+	}); err != nil && !errors.Is(err, errStopBisect) {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("bad commit %v is in the history of a good commit", bad.CID)
+	}
+
+	step := BisectStep{Remaining: len(candidates)}
+	var untested []gdat.Ref
+	for _, ref := range candidates {
+		if ref != bad && !slices.Contains(skip, ref) {
+			untested = append(untested, ref)
+		}
+	}
+	if len(untested) == 0 {
+		step.Candidates = candidates
+		return &step, nil
+	}
+	step.Next = untested[len(untested)/2]
+	return &step, nil
+}
@@ -0,0 +1,66 @@
+package gotcore
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBisect(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss.FS, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	var refs []gdat.Ref
+	var parents []Commit
+	for i := range 10 {
+		ref, comm := post(parents, map[string]string{"a.txt": fmt.Sprint(i)})
+		refs = append(refs, ref)
+		parents = []Commit{comm}
+	}
+	bisect := func(good, bad gdat.Ref, skip []gdat.Ref) *BisectStep {
+		step, err := Bisect(ctx, &mach.VC, s, gotkv.Root{}, bad, []gdat.Ref{good}, skip)
+		require.NoError(t, err)
+		return step
+	}
+
+	for firstBad := 1; firstBad < len(refs); firstBad++ {
+		good, bad := refs[0], refs[len(refs)-1]
+		var tested int
+		step := bisect(good, bad, nil)
+		for !step.Done() {
+			tested++
+			require.LessOrEqual(t, tested, 4)
+			if slices.Index(refs, step.Next) >= firstBad {
+				bad = step.Next
+			} else {
+				good = step.Next
+			}
+			step = bisect(good, bad, nil)
+		}
+		require.Equal(t, []gdat.Ref{refs[firstBad]}, step.Candidates)
+	}
+
+	// skipping
+	step := bisect(refs[7], refs[9], []gdat.Ref{refs[8]})
+	require.True(t, step.Done())
+	require.Equal(t, 2, step.Remaining)
+	require.Equal(t, []gdat.Ref{refs[9], refs[8]}, step.Candidates)
+
+	// bad is an ancestor of good
+	_, err := Bisect(ctx, &mach.VC, s, gotkv.Root{}, refs[3], []gdat.Ref{refs[5]}, nil)
+	require.Error(t, err)
+}
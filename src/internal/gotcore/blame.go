@@ -0,0 +1,118 @@
+package gotcore
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.brendoncarroll.net/state/posixfs"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/internal/linediff"
+)
+
+// BlameLine is a line of a file, and the Commit which last changed it.
+type BlameLine struct {
+	// Line is the content of the line, including the trailing newline, if any.
+	Line string
+	// Ref refers to the Commit which last changed the line.
+	Ref    gdat.Ref
+	Commit Commit
+}
+
+// Blame attributes each line of the file at p, in the Commit at commRef, to the Commit which last changed it.
+// History is followed through the first parent of each Commit.
+// An error is returned if the file does not exist, is larger than linediff.MaxTextSize, or contains binary data.
+func Blame(ctx context.Context, vcmach *VCMach, fsmach *FSMach, s RO, commRef gdat.Ref, p string) ([]BlameLine, error) {
+	ref := commRef
+	comm, err := vcmach.GetVertex(ctx, s.VC, ref)
+	if err != nil {
+		return nil, err
+	}
+	lines, ok, err := readLines(ctx, fsmach, s.FS, comm.Payload.Snap, p)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("cannot blame %s, it is not a text file, or it is larger than %d bytes", p, linediff.MaxTextSize)
+	}
+	out := make([]BlameLine, len(lines))
+	for i := range lines {
+		out[i].Line = lines[i]
+	}
+	// origin maps each line in the current version of the file to its index in out.
+	// lines which have already been attributed are -1.
+	origin := make([]int, len(lines))
+	for i := range origin {
+		origin[i] = i
+	}
+	remaining := len(lines)
+	attribute := func(i int) {
+		if origin[i] >= 0 {
+			out[origin[i]].Ref = ref
+			out[origin[i]].Commit = comm
+			remaining--
+		}
+	}
+	for remaining > 0 {
+		if len(comm.Parents) == 0 {
+			for i := range lines {
+				attribute(i)
+			}
+			break
+		}
+		parentRef := comm.Parents[0]
+		parent, err := vcmach.GetVertex(ctx, s.VC, parentRef)
+		if err != nil {
+			return nil, err
+		}
+		// if the parent does not have the file as text, then parentLines is empty, and every line is attributed to comm.
+		parentLines, _, err := readLines(ctx, fsmach, s.FS, parent.Payload.Snap, p)
+		if err != nil {
+			return nil, err
+		}
+		parentOrigin := make([]int, len(parentLines))
+		for i := range parentOrigin {
+			parentOrigin[i] = -1
+		}
+		for _, e := range linediff.Diff(parentLines, lines) {
+			switch e.Op {
+			case linediff.Equal:
+				parentOrigin[e.A] = origin[e.B]
+			case linediff.Insert:
+				attribute(e.B)
+			}
+		}
+		ref, comm = parentRef, parent
+		lines, origin = parentLines, parentOrigin
+	}
+	return out, nil
+}
+
+// readLines reads the file at p and splits it into lines.
+// ok is false if there is no regular file at p, or if it is larger than linediff.MaxTextSize, or contains binary data.
+func readLines(ctx context.Context, fsmach *FSMach, s gotfs.RO, root gotfs.Root, p string) (_ []string, ok bool, _ error) {
+	info, err := fsmach.GetInfo(ctx, s.Metadata, root, p)
+	if err != nil {
+		if posixfs.IsErrNotExist(err) {
+			err = nil
+		}
+		return nil, false, err
+	}
+	if !info.Mode.IsRegular() {
+		return nil, false, nil
+	}
+	r, err := fsmach.NewReader(ctx, s, root, p)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, linediff.MaxTextSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > linediff.MaxTextSize || linediff.IsBinary(data) {
+		return nil, false, nil
+	}
+	return linediff.Lines(data), true, nil
+}
@@ -0,0 +1,50 @@
+package gotcore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/internal/linediff"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlame(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss.FS, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	c1Ref, c1 := post(nil, map[string]string{"a.txt": "one\ntwo\nthree\n"})
+	c2Ref, c2 := post([]Commit{c1}, map[string]string{"a.txt": "one\n2\nthree\n"})
+	_, c3 := post([]Commit{c2}, map[string]string{"a.txt": "one\n2\nthree\n", "b.txt": "b"})
+	c4Ref, _ := post([]Commit{c3}, map[string]string{"a.txt": "zero\none\n2\nthree"})
+
+	lines, err := Blame(ctx, &mach.VC, &mach.FS, ss.RO(), c4Ref, "a.txt")
+	require.NoError(t, err)
+	var actual []string
+	var refs []gdat.Ref
+	for _, l := range lines {
+		actual = append(actual, l.Line)
+		refs = append(refs, l.Ref)
+	}
+	require.Equal(t, []string{"zero\n", "one\n", "2\n", "three"}, actual)
+	require.Equal(t, []gdat.Ref{c4Ref, c1Ref, c2Ref, c4Ref}, refs)
+
+	_, err = Blame(ctx, &mach.VC, &mach.FS, ss.RO(), c4Ref, "missing.txt")
+	require.Error(t, err)
+
+	// files which are too large to diff are not blamed.
+	large := strings.Repeat("line\n", linediff.MaxTextSize/5+1)
+	c5Ref, _ := post(nil, map[string]string{"large.txt": large})
+	_, err = Blame(ctx, &mach.VC, &mach.FS, ss.RO(), c5Ref, "large.txt")
+	require.Error(t, err)
+}
@@ -70,6 +70,19 @@ func (p *Payload) Unmarshal(data []byte) error {
 	return nil
 }
 
+// ParseNotes parses the CommitNotes stored in the Payload.
+// If there are no notes, the zero value is returned.
+func (p Payload) ParseNotes() (CommitNotes, error) {
+	var notes CommitNotes
+	if len(p.Notes) == 0 {
+		return notes, nil
+	}
+	if err := json.Unmarshal(p.Notes, &notes); err != nil {
+		return CommitNotes{}, err
+	}
+	return notes, nil
+}
+
 // GetCommit reads a commit from the store.
 func GetCommit(ctx context.Context, s stores.RO, ref gdat.Ref) (Commit, error) {
 	vcmach := gotdag.NewMachine(gotdag.Params[Payload]{Parse: ParsePayload})
@@ -82,6 +95,9 @@ type CommitParams struct {
 	Base        []Commit
 	Snap        gotfs.Root
 	Notes       CommitNotes
+	// Signer, if not nil, is used to sign the Commit.
+	// It must sign for the Committer.
+	Signer *Signer
 }
 
 // CreateCommit creates a new Commit in the store.
@@ -102,7 +118,7 @@ func CreateCommit(ctx context.Context, vcmach *VCMach, srw stores.RW, copa Commi
 	if err != nil {
 		panic(err)
 	}
-	return vcmach.NewVertex(ctx, srw, gotdag.VertexParams[Payload]{
+	comm, err := vcmach.NewVertex(ctx, srw, gotdag.VertexParams[Payload]{
 		Parents:   copa.Base,
 		CreatedAt: copa.CommittedAt,
 		Creator:   copa.Committer,
@@ -111,6 +127,15 @@ func CreateCommit(ctx context.Context, vcmach *VCMach, srw stores.RW, copa Commi
 			Notes: notes,
 		},
 	})
+	if err != nil {
+		return Commit{}, err
+	}
+	if copa.Signer != nil {
+		if err := copa.Signer.Sign(&comm); err != nil {
+			return Commit{}, err
+		}
+	}
+	return comm, nil
 }
 
 // PostCommit write a commit to the store.
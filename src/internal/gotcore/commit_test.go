@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotdag"
 	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
 	"github.com/gotvc/got/src/internal/stores"
 	"github.com/gotvc/got/src/internal/testutil"
 	"github.com/stretchr/testify/require"
@@ -45,3 +47,107 @@ func TestMarshalCommit(t *testing.T) {
 		})
 	}
 }
+
+func TestPathHistory(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss.FS, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	c0Ref, c0 := post(nil, map[string]string{"a.txt": "a"})
+	c1Ref, c1 := post([]Commit{c0}, map[string]string{"a.txt": "a", "dir/b.txt": "b"})
+	c2Ref, c2 := post([]Commit{c1}, map[string]string{"a.txt": "a2", "dir/b.txt": "b"})
+	c3Ref, _ := post([]Commit{c2}, map[string]string{"a.txt": "a2", "dir/b.txt": "b2"})
+
+	tcs := map[string][]gdat.Ref{
+		"dir":       {c3Ref, c1Ref},
+		"dir/b.txt": {c3Ref, c1Ref},
+		"a.txt":     {c2Ref, c0Ref},
+		"c.txt":     nil,
+	}
+	for p, expected := range tcs {
+		var actual []gdat.Ref
+		err := PathHistory(ctx, &mach.VC, &mach.FS, ss.RO(), c3Ref, p, func(ref gdat.Ref, _ Commit) error {
+			actual = append(actual, ref)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected, actual, p)
+	}
+}
+
+func TestCommitGraphIndex(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss.FS, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	aRef, a := post(nil, map[string]string{"a.txt": "a"})
+	bRef, b := post([]Commit{a}, map[string]string{"b.txt": "b"})
+	cRef, c := post([]Commit{a}, map[string]string{"c.txt": "c"})
+	mRef, _ := post([]Commit{b, c}, map[string]string{"m.txt": "m"})
+
+	idx, err := mach.VC.AddToIndex(ctx, s, gotkv.Root{}, bRef)
+	require.NoError(t, err)
+	ent, err := mach.VC.GetIndexEntry(ctx, s, idx, cRef)
+	require.NoError(t, err)
+	require.Nil(t, ent)
+	idx, err = mach.VC.AddToIndex(ctx, s, idx, mRef)
+	require.NoError(t, err)
+	for _, ref := range []gdat.Ref{aRef, bRef, cRef, mRef} {
+		ent, err := mach.VC.GetIndexEntry(ctx, s, idx, ref)
+		require.NoError(t, err)
+		require.NotNil(t, ent)
+		vert, err := mach.VC.GetVertex(ctx, s, ref)
+		require.NoError(t, err)
+		require.Equal(t, vert.N, ent.N)
+		require.Equal(t, vert.Parents, ent.Parents)
+	}
+
+	for _, idx := range []gotkv.Root{{}, idx} {
+		for _, tc := range []struct {
+			A, X     gdat.Ref
+			Expected bool
+		}{
+			{A: aRef, X: mRef, Expected: true},
+			{A: cRef, X: mRef, Expected: true},
+			{A: bRef, X: cRef, Expected: false},
+			{A: mRef, X: aRef, Expected: false},
+			{A: aRef, X: aRef, Expected: false},
+		} {
+			yes, err := mach.VC.IsAncestor(ctx, s, idx, tc.A, tc.X)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, yes)
+		}
+		var visited []gdat.Ref
+		require.NoError(t, mach.VC.WalkIndex(ctx, s, idx, []gdat.Ref{mRef}, func(ref gdat.Ref, _ gotdag.IndexEntry) error {
+			visited = append(visited, ref)
+			return nil
+		}))
+		requireHistoryOrder(t, visited, aRef, bRef, cRef, mRef)
+	}
+	var visited []gdat.Ref
+	require.NoError(t, History(ctx, &mach.VC, s, mRef, func(ref gdat.Ref, _ Commit) error {
+		visited = append(visited, ref)
+		return nil
+	}))
+	requireHistoryOrder(t, visited, aRef, bRef, cRef, mRef)
+}
+
+// requireHistoryOrder checks that the diamond a <- (b, c) <- m was visited newest first.
+func requireHistoryOrder(t testing.TB, visited []gdat.Ref, a, b, c, m gdat.Ref) {
+	require.Len(t, visited, 4)
+	require.Equal(t, m, visited[0])
+	require.ElementsMatch(t, []gdat.Ref{b, c}, visited[1:3])
+	require.Equal(t, a, visited[3])
+}
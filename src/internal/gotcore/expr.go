@@ -3,6 +3,7 @@ package gotcore
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gotvc/got/src/gdat"
@@ -10,11 +11,13 @@ import (
 
 // CommitExpr is a sum type representing the different ways
 // to refer to a Commit in Got
-// So far, there are 2 primitive ways
+// So far, there are 3 primitive ways
 // - Exactly by Ref
 // - By Mark
-// And 1 higher-order way
+// - By a previous target of a Mark, from its log
+// And 2 higher-order ways
 // - By an offset from the result of a previous expression
+// - By a parent of the result of a previous expression
 type CommitExpr interface {
 	GetSpace() string
 	// Resolve returns a valid Ref, which points to a Commit.
@@ -23,14 +26,100 @@ type CommitExpr interface {
 	isSnapExpr()
 }
 
+// ParseCommitExpr parses a CommitExpr from x.
+// x is either an exact Ref or a mark name, each optionally prefixed with a space name and ':'.
+// A mark name can be followed by '@{n}' to refer to the nth previous target of the mark.
+// Either can be followed by any number of relative suffixes:
+// - '~n' to go back n generations, following the first parent.
+// - '^n' to select the nth parent.
+// If n is omitted it defaults to 1.
 func ParseCommitExpr(x string) (CommitExpr, error) {
-	if se, err := ParseCommit_Mark(x); err == nil {
-		return se, nil
+	var rel string
+	if i := strings.IndexAny(x, "~^"); i >= 0 {
+		x, rel = x[:i], x[i:]
+	}
+	if x == "" {
+		return nil, fmt.Errorf("could not parse Commit expression from %q", x+rel)
+	}
+	if i := strings.Index(x, "@{"); i >= 0 {
+		se, err := parseCommit_Log(x[:i], x[i:])
+		if err != nil {
+			return nil, err
+		}
+		return parseRelative(se, rel)
 	}
 	if se, err := ParseCommit_Exact(x); err == nil {
-		return se, nil
+		return parseRelative(se, rel)
+	}
+	se, err := ParseCommit_Mark(x)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("could not parse Commit expression from %q", x)
+	return parseRelative(se, rel)
+}
+
+// FormatCommitExpr returns se as it would be written on the command line.
+// A mark in the default space is written without the space.
+func FormatCommitExpr(se CommitExpr) string {
+	switch x := se.(type) {
+	case CommitExpr_Mark:
+		if x.Space == "" {
+			return x.Name
+		}
+	case *CommitExpr_Mark:
+		return FormatCommitExpr(*x)
+	case CommitExpr_Log:
+		return fmt.Sprintf("%s@{%d}", FormatCommitExpr(x.Mark), x.N)
+	case CommitExpr_Offset:
+		return fmt.Sprintf("%s~%d", FormatCommitExpr(x.X), x.Offset)
+	case CommitExpr_Parent:
+		return fmt.Sprintf("%s^%d", FormatCommitExpr(x.X), x.Index)
+	}
+	return fmt.Sprint(se)
+}
+
+// MarkOf returns the mark which se refers to a Commit relative to.
+// ok is false if se refers to an exact Ref.
+func MarkOf(se CommitExpr) (_ CommitExpr_Mark, ok bool) {
+	switch x := se.(type) {
+	case CommitExpr_Mark:
+		return x, true
+	case *CommitExpr_Mark:
+		return *x, true
+	case CommitExpr_Log:
+		return x.Mark, true
+	case CommitExpr_Offset:
+		return MarkOf(x.X)
+	case CommitExpr_Parent:
+		return MarkOf(x.X)
+	}
+	return CommitExpr_Mark{}, false
+}
+
+// parseRelative wraps x with an expression for each of the relative suffixes in rel.
+func parseRelative(x CommitExpr, rel string) (CommitExpr, error) {
+	for len(rel) > 0 {
+		op := rel[0]
+		rel = rel[1:]
+		digits := len(rel) - len(strings.TrimLeft(rel, "0123456789"))
+		n := uint64(1)
+		if digits > 0 {
+			var err error
+			if n, err = strconv.ParseUint(rel[:digits], 10, 32); err != nil {
+				return nil, err
+			}
+		}
+		rel = rel[digits:]
+		switch op {
+		case '~':
+			x = CommitExpr_Offset{X: x, Offset: uint(n)}
+		case '^':
+			x = CommitExpr_Parent{X: x, Index: uint(n)}
+		default:
+			return nil, fmt.Errorf("invalid relative commit expression %q", string(op)+rel)
+		}
+	}
+	return x, nil
 }
 
 type CommitExpr_Exact struct {
@@ -64,6 +153,10 @@ func (se *CommitExpr_Exact) Resolve(ctx context.Context, tx SpaceTx) (gdat.Ref,
 	return se.Ref, nil
 }
 
+func (se *CommitExpr_Exact) String() string {
+	return se.Space + ":" + se.Ref.String()
+}
+
 type CommitExpr_Mark struct {
 	Space string
 	Name  string
@@ -96,9 +189,120 @@ func (se CommitExpr_Mark) String() string {
 	return se.Space + ":" + se.Name
 }
 
+// CommitExpr_Log refers to a previous target of a Mark, from the Mark's log.
+// N = 0 refers to the current target, N = 1 to the target before the most recent change, and so on.
+type CommitExpr_Log struct {
+	Mark CommitExpr_Mark
+	N    uint
+}
+
+// parseCommit_Log parses a CommitExpr_Log from a mark name x and a suffix like '@{n}'.
+func parseCommit_Log(x, suffix string) (CommitExpr_Log, error) {
+	if x == "" || !strings.HasPrefix(suffix, "@{") || !strings.HasSuffix(suffix, "}") {
+		return CommitExpr_Log{}, fmt.Errorf("invalid mark log expression %q", x+suffix)
+	}
+	n, err := strconv.ParseUint(suffix[2:len(suffix)-1], 10, 32)
+	if err != nil {
+		return CommitExpr_Log{}, fmt.Errorf("invalid mark log expression %q: %w", x+suffix, err)
+	}
+	mark, err := ParseCommit_Mark(x)
+	if err != nil {
+		return CommitExpr_Log{}, err
+	}
+	return CommitExpr_Log{Mark: *mark, N: uint(n)}, nil
+}
+
+func (se CommitExpr_Log) isSnapExpr() {}
+
+func (se CommitExpr_Log) GetSpace() string {
+	return se.Mark.Space
+}
+
+func (se CommitExpr_Log) Resolve(ctx context.Context, tx SpaceTx) (gdat.Ref, error) {
+	if se.N == 0 {
+		return se.Mark.Resolve(ctx, tx)
+	}
+	ents, err := tx.GetLog(ctx, se.Mark.Name)
+	if err != nil {
+		return gdat.Ref{}, err
+	}
+	if se.N > uint(len(ents)) {
+		return gdat.Ref{}, fmt.Errorf("%v: the log only has %d entries", se, len(ents))
+	}
+	return ents[se.N-1].Prev, nil
+}
+
+func (se CommitExpr_Log) String() string {
+	return fmt.Sprintf("%v@{%d}", se.Mark, se.N)
+}
+
+// CommitExpr_Offset refers to the ancestor Offset generations before the Commit at X.
+// Only the first parent of each Commit is followed.
 type CommitExpr_Offset struct {
 	X      CommitExpr
 	Offset uint
 }
 
 func (se CommitExpr_Offset) isSnapExpr() {}
+
+func (se CommitExpr_Offset) GetSpace() string {
+	return se.X.GetSpace()
+}
+
+func (se CommitExpr_Offset) Resolve(ctx context.Context, tx SpaceTx) (gdat.Ref, error) {
+	ref, err := se.X.Resolve(ctx, tx)
+	if err != nil {
+		return gdat.Ref{}, err
+	}
+	for i := uint(0); i < se.Offset && !ref.IsZero(); i++ {
+		comm, err := GetCommit(ctx, tx.Stores().VC, ref)
+		if err != nil {
+			return gdat.Ref{}, err
+		}
+		if len(comm.Parents) == 0 {
+			return gdat.Ref{}, fmt.Errorf("%v: commit %v has no parent", se, ref)
+		}
+		ref = comm.Parents[0]
+	}
+	return ref, nil
+}
+
+func (se CommitExpr_Offset) String() string {
+	return fmt.Sprintf("%v~%d", se.X, se.Offset)
+}
+
+// CommitExpr_Parent refers to a parent of the Commit at X.
+// Parents are numbered from 1, in the order they are stored in the Commit.
+// Index 0 refers to the Commit at X itself.
+type CommitExpr_Parent struct {
+	X     CommitExpr
+	Index uint
+}
+
+func (se CommitExpr_Parent) isSnapExpr() {}
+
+func (se CommitExpr_Parent) GetSpace() string {
+	return se.X.GetSpace()
+}
+
+func (se CommitExpr_Parent) Resolve(ctx context.Context, tx SpaceTx) (gdat.Ref, error) {
+	ref, err := se.X.Resolve(ctx, tx)
+	if err != nil {
+		return gdat.Ref{}, err
+	}
+	if se.Index == 0 || ref.IsZero() {
+		return ref, nil
+	}
+	comm, err := GetCommit(ctx, tx.Stores().VC, ref)
+	if err != nil {
+		return gdat.Ref{}, err
+	}
+	if se.Index > uint(len(comm.Parents)) {
+		return gdat.Ref{}, fmt.Errorf("%v: commit %v has %d parent(s)", se, ref, len(comm.Parents))
+	}
+	return comm.Parents[se.Index-1], nil
+}
+
+func (se CommitExpr_Parent) String() string {
+	return fmt.Sprintf("%v^%d", se.X, se.Index)
+}
@@ -0,0 +1,131 @@
+package gotcore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitExpr(t *testing.T) {
+	ref := gdat.Ref{CID: [32]byte{1}, DEK: [32]byte{2}}
+	mark := &CommitExpr_Mark{Name: "master"}
+	tcs := []struct {
+		In  string
+		Out CommitExpr
+		Err bool
+	}{
+		{In: "master", Out: mark},
+		{In: "origin:master", Out: &CommitExpr_Mark{Space: "origin", Name: "master"}},
+		{In: ref.String(), Out: &CommitExpr_Exact{Ref: ref}},
+		{In: "master~", Out: CommitExpr_Offset{X: mark, Offset: 1}},
+		{In: "master~3", Out: CommitExpr_Offset{X: mark, Offset: 3}},
+		{In: "master^2", Out: CommitExpr_Parent{X: mark, Index: 2}},
+		{In: "master^^", Out: CommitExpr_Parent{X: CommitExpr_Parent{X: mark, Index: 1}, Index: 1}},
+		{In: ref.String() + "~2^2", Out: CommitExpr_Parent{X: CommitExpr_Offset{X: &CommitExpr_Exact{Ref: ref}, Offset: 2}, Index: 2}},
+		{In: "master@{2}", Out: CommitExpr_Log{Mark: CommitExpr_Mark{Name: "master"}, N: 2}},
+		{In: "origin:master@{0}~1", Out: CommitExpr_Offset{X: CommitExpr_Log{Mark: CommitExpr_Mark{Space: "origin", Name: "master"}}, Offset: 1}},
+		{In: "~1", Err: true},
+		{In: "master~x", Err: true},
+		{In: "master@{x}", Err: true},
+		{In: "master@{1", Err: true},
+		{In: "@{1}", Err: true},
+	}
+	for _, tc := range tcs {
+		out, err := ParseCommitExpr(tc.In)
+		if tc.Err {
+			require.Error(t, err, tc.In)
+			continue
+		}
+		require.NoError(t, err, tc.In)
+		require.Equal(t, tc.Out, out, tc.In)
+	}
+}
+
+func TestFormatCommitExpr(t *testing.T) {
+	for _, x := range []string{"master", "origin:master", "master~2", "master@{1}^2", "origin:master~1"} {
+		se, err := ParseCommitExpr(x)
+		require.NoError(t, err)
+		require.Equal(t, x, FormatCommitExpr(se))
+	}
+	require.Equal(t, "master", FormatCommitExpr(CommitExpr_Mark{Name: "master"}))
+}
+
+func TestResolveRelative(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss.FS, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	aRef, a := post(nil, map[string]string{"a.txt": "a"})
+	bRef, b := post([]Commit{a}, map[string]string{"b.txt": "b"})
+	cRef, c := post([]Commit{a}, map[string]string{"c.txt": "c"})
+	mRef, _ := post([]Commit{b, c}, map[string]string{"m.txt": "m"})
+	stx := &testSpaceTx{
+		stores:  ss,
+		targets: map[string]gdat.Ref{"head": mRef},
+		logs: map[string][]LogEntry{"head": {
+			{Prev: bRef, Next: mRef},
+			{Prev: aRef, Next: bRef},
+		}},
+	}
+
+	tcs := map[string]gdat.Ref{
+		"head":       mRef,
+		"head^0":     mRef,
+		"head~2":     aRef,
+		"head^2~1":   aRef,
+		"head@{0}":   mRef,
+		"head@{1}":   bRef,
+		"head@{2}":   aRef,
+		"head@{1}~1": aRef,
+	}
+	m, err := mach.VC.GetVertex(ctx, s, mRef)
+	require.NoError(t, err)
+	tcs["head^1"] = m.Parents[0]
+	tcs["head^2"] = m.Parents[1]
+	require.ElementsMatch(t, []gdat.Ref{bRef, cRef}, m.Parents)
+	for in, expected := range tcs {
+		se, err := ParseCommitExpr(in)
+		require.NoError(t, err)
+		actual, err := se.Resolve(ctx, stx)
+		require.NoError(t, err, in)
+		require.Equal(t, expected, actual, in)
+	}
+	for _, in := range []string{"head~3", "head^3", "head@{3}"} {
+		se, err := ParseCommitExpr(in)
+		require.NoError(t, err)
+		_, err = se.Resolve(ctx, stx)
+		require.Error(t, err, in)
+	}
+}
+
+// testSpaceTx is a SpaceTx with fixed stores and targets.
+// Only the methods used to resolve CommitExprs are implemented.
+type testSpaceTx struct {
+	SpaceTx
+	stores  RW
+	targets map[string]gdat.Ref
+	logs    map[string][]LogEntry
+}
+
+func (stx *testSpaceTx) Stores() RW {
+	return stx.stores
+}
+
+func (stx *testSpaceTx) GetTarget(ctx context.Context, name string) (gdat.Ref, error) {
+	return stx.targets[name], nil
+}
+
+func (stx *testSpaceTx) GetLog(ctx context.Context, name string) ([]LogEntry, error) {
+	return stx.logs[name], nil
+}
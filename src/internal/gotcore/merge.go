@@ -0,0 +1,135 @@
+package gotcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
+	"go.brendoncarroll.net/tai64"
+	"go.inet256.org/inet256/src/inet256"
+)
+
+// ErrMergeConflict is returned when a merge cannot be completed because
+// the same paths were changed differently on both sides.
+type ErrMergeConflict struct {
+	Paths []string
+}
+
+func (e ErrMergeConflict) Error() string {
+	return fmt.Sprintf("merge conflict in %d path(s): %s", len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+func IsMergeConflict(err error) bool {
+	return errors.As(err, &ErrMergeConflict{})
+}
+
+type MergeParams struct {
+	Committer   inet256.ID
+	CommittedAt tai64.TAI64
+	Notes       CommitNotes
+	// Signer, if not nil, signs the merge Commit.
+	Signer *Signer
+}
+
+// MergeResult is the outcome of merging 2 Commits.
+type MergeResult struct {
+	Ours, Theirs Commit
+	// FastForward is set if one of the commits is an ancestor of the other.
+	// It is the descendent, and none of the other fields besides Ours and Theirs are set.
+	FastForward *Commit
+
+	// Base is the filesystem of the merge base.
+	Base gotfs.Root
+	// Snap is the merged filesystem.
+	// Conflicting paths have the contents from Ours.
+	Snap gotfs.Root
+	// Conflicts are the paths which were changed differently by Ours and Theirs.
+	Conflicts []string
+}
+
+// PrepareMerge performs a three-way merge of the filesystems at oursRef and theirsRef,
+// using their merge base as the base.
+// Nothing is committed, and conflicts are returned as part of the result, not as an error.
+// s must contain the history of both commits.
+// idx is a commit graph index used to find the merge base, and can be the zero value.
+func PrepareMerge(ctx context.Context, mach *Machine, s RW, idx gotkv.Root, oursRef, theirsRef gdat.Ref) (*MergeResult, error) {
+	ours, err := mach.VC.GetVertex(ctx, s.VC, oursRef)
+	if err != nil {
+		return nil, err
+	}
+	if oursRef.Equals(&theirsRef) {
+		return &MergeResult{Ours: ours, Theirs: ours, FastForward: &ours}, nil
+	}
+	theirs, err := mach.VC.GetVertex(ctx, s.VC, theirsRef)
+	if err != nil {
+		return nil, err
+	}
+	res := MergeResult{Ours: ours, Theirs: theirs}
+	bases, err := mach.VC.MergeBase(ctx, s.VC, idx, oursRef, theirsRef)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(bases) > 0 && bases[0].Equals(&theirsRef):
+		res.FastForward = &ours
+		return &res, nil
+	case len(bases) > 0 && bases[0].Equals(&oursRef):
+		res.FastForward = &theirs
+		return &res, nil
+	case len(bases) > 0:
+		// If there are multiple merge bases, the one with the highest N is used.
+		baseComm, err := mach.VC.GetVertex(ctx, s.VC, bases[0])
+		if err != nil {
+			return nil, err
+		}
+		res.Base = baseComm.Payload.Snap
+	default:
+		// unrelated histories are merged as if they both started from an empty filesystem.
+		root, err := mach.FS.NewEmpty(ctx, s.FS.Metadata, 0o755)
+		if err != nil {
+			return nil, err
+		}
+		res.Base = *root
+	}
+	snap, conflicts, err := mach.FS.Merge(ctx, s.FS, res.Base, ours.Payload.Snap, theirs.Payload.Snap)
+	if err != nil {
+		return nil, err
+	}
+	res.Snap = *snap
+	res.Conflicts = conflicts
+	return &res, nil
+}
+
+// Merge merges the Commit at theirs into the Commit at ours, and returns the Commit that ours should be replaced with.
+// If one of the commits is an ancestor of the other, then the descendent is returned.
+// Otherwise the filesystems are merged using PrepareMerge, and
+// a new Commit is created with both ours and theirs as parents.
+// s must contain the history of both commits.
+func Merge(ctx context.Context, mach *Machine, s RW, idx gotkv.Root, oursRef, theirsRef gdat.Ref, params MergeParams) (*Commit, error) {
+	res, err := PrepareMerge(ctx, mach, s, idx, oursRef, theirsRef)
+	if err != nil {
+		return nil, err
+	}
+	if res.FastForward != nil {
+		return res.FastForward, nil
+	}
+	if len(res.Conflicts) > 0 {
+		return nil, ErrMergeConflict{Paths: res.Conflicts}
+	}
+	comm, err := CreateCommit(ctx, &mach.VC, s.VC, CommitParams{
+		Committer:   params.Committer,
+		CommittedAt: params.CommittedAt,
+		Base:        []Commit{res.Ours, res.Theirs},
+		Snap:        res.Snap,
+		Notes:       params.Notes,
+		Signer:      params.Signer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comm, nil
+}
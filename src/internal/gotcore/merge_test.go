@@ -0,0 +1,96 @@
+package gotcore
+
+import (
+	"testing"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"go.inet256.org/inet256/src/inet256"
+)
+
+func TestMerge(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss.FS, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	params := MergeParams{Committer: inet256.ID{1}}
+
+	baseRef, base := post(nil, map[string]string{"a.txt": "a"})
+	oursRef, ours := post([]Commit{base}, map[string]string{"a.txt": "a", "b.txt": "b"})
+	theirsRef, _ := post([]Commit{base}, map[string]string{"a.txt": "a", "c.txt": "c"})
+
+	// fast-forward
+	out, err := Merge(ctx, &mach, ss, gotkv.Root{}, baseRef, oursRef, params)
+	require.NoError(t, err)
+	require.True(t, out.Equals(ours))
+	// already up to date
+	out, err = Merge(ctx, &mach, ss, gotkv.Root{}, oursRef, baseRef, params)
+	require.NoError(t, err)
+	require.True(t, out.Equals(ours))
+
+	out, err = Merge(ctx, &mach, ss, gotkv.Root{}, oursRef, theirsRef, params)
+	require.NoError(t, err)
+	require.Len(t, out.Parents, 2)
+	for _, p := range []string{"a.txt", "b.txt", "c.txt"} {
+		yes, err := mach.FS.Exists(ctx, s, out.Payload.Snap, p)
+		require.NoError(t, err)
+		require.True(t, yes, p)
+	}
+
+	conflictingRef, _ := post([]Commit{base}, map[string]string{"a.txt": "a2", "b.txt": "b2"})
+	_, err = Merge(ctx, &mach, ss, gotkv.Root{}, oursRef, conflictingRef, params)
+	require.True(t, IsMergeConflict(err))
+	require.Equal(t, []string{"b.txt"}, err.(ErrMergeConflict).Paths)
+}
+
+func TestMergeBase(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := gotfs.RW{Metadata: s, Data: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, files map[string]string) (gdat.Ref, Commit) {
+		comm := makeCommit(t, DSConfig{}, s, parents, makeFS(t, ss, files))
+		ref, err := mach.VC.PostVertex(ctx, s, *comm)
+		require.NoError(t, err)
+		return ref, *comm
+	}
+	_, base := post(nil, map[string]string{"a.txt": "a"})
+	xRef, x := post([]Commit{base}, map[string]string{"x.txt": "x"})
+	yRef, y := post([]Commit{base}, map[string]string{"y.txt": "y"})
+	// criss-cross
+	m1Ref, _ := post([]Commit{x, y}, map[string]string{"m1.txt": "m1"})
+	m2Ref, _ := post([]Commit{x, y}, map[string]string{"m2.txt": "m2"})
+
+	unrelatedRef, _ := post(nil, map[string]string{"b.txt": "b"})
+	idx, err := mach.VC.AddToIndex(ctx, s, gotkv.Root{}, m1Ref)
+	require.NoError(t, err)
+	idx, err = mach.VC.AddToIndex(ctx, s, idx, m2Ref)
+	require.NoError(t, err)
+
+	// the results must be the same, whether or not the commits are in the index.
+	for name, idx := range map[string]gotkv.Root{"NoIndex": {}, "Index": idx} {
+		t.Run(name, func(t *testing.T) {
+			bases, err := mach.VC.MergeBase(ctx, s, idx, m1Ref, m2Ref)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []gdat.Ref{xRef, yRef}, bases)
+
+			bases, err = mach.VC.MergeBase(ctx, s, idx, xRef, m1Ref)
+			require.NoError(t, err)
+			require.Equal(t, []gdat.Ref{xRef}, bases)
+
+			bases, err = mach.VC.MergeBase(ctx, s, idx, unrelatedRef, m1Ref)
+			require.NoError(t, err)
+			require.Empty(t, bases)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package gotcore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
+	"go.brendoncarroll.net/tai64"
+	"go.inet256.org/inet256/src/inet256"
+)
+
+// ReplayParams are the parameters used to create the new Commits when
+// the changes from existing Commits are replayed.
+// The CommitNotes are always taken from the original Commit.
+type ReplayParams struct {
+	Committer   inet256.ID
+	CommittedAt tai64.TAI64
+	// Signer, if not nil, signs each of the new Commits.
+	Signer *Signer
+}
+
+// CherryPick replays the change made by the Commit at ref, relative to its parent, on top of the Commit at ontoRef.
+// The new Commit has onto as its only parent, and the same CommitNotes as the original.
+// If the change cannot be applied cleanly, then ErrMergeConflict is returned.
+// s must contain both Commits, and the parent of the Commit at ref.
+func CherryPick(ctx context.Context, mach *Machine, s RW, ontoRef, ref gdat.Ref, params ReplayParams) (*Commit, error) {
+	onto, err := mach.VC.GetVertex(ctx, s.VC, ontoRef)
+	if err != nil {
+		return nil, err
+	}
+	comm, err := mach.VC.GetVertex(ctx, s.VC, ref)
+	if err != nil {
+		return nil, err
+	}
+	next, err := replay(ctx, mach, s, onto, comm, params)
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// Rebase replays each of the Commits in the history of ref, which are not in the history of ontoRef,
+// on top of the Commit at ontoRef, oldest first.
+// It returns the Commit that ref should be replaced with.
+// If ref is already a descendent of ontoRef, then the Commit at ref is returned,
+// and if it is an ancestor, then the Commit at ontoRef is returned.
+// Only linear history can be replayed, an error is returned if there is a merge commit to replay.
+// s must contain the history of both Commits.
+// idx is a commit graph index used to find the merge base, and can be the zero value.
+func Rebase(ctx context.Context, mach *Machine, s RW, idx gotkv.Root, ref, ontoRef gdat.Ref, params ReplayParams) (*Commit, error) {
+	bases, err := mach.VC.MergeBase(ctx, s.VC, idx, ref, ontoRef)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) > 0 && bases[0].Equals(&ontoRef) {
+		comm, err := mach.VC.GetVertex(ctx, s.VC, ref)
+		if err != nil {
+			return nil, err
+		}
+		return &comm, nil
+	}
+	isBase := func(x gdat.Ref) bool {
+		return slices.ContainsFunc(bases, func(b gdat.Ref) bool { return b.Equals(&x) })
+	}
+	// collect the commits to replay, newest first.
+	type todoItem struct {
+		Ref    gdat.Ref
+		Commit Commit
+	}
+	var todo []todoItem
+	for x := ref; !isBase(x); {
+		comm, err := mach.VC.GetVertex(ctx, s.VC, x)
+		if err != nil {
+			return nil, err
+		}
+		if len(comm.Parents) > 1 {
+			return nil, fmt.Errorf("cannot rebase, %v is a merge commit", x)
+		}
+		todo = append(todo, todoItem{Ref: x, Commit: comm})
+		if len(comm.Parents) == 0 {
+			break
+		}
+		x = comm.Parents[0]
+	}
+	onto, err := mach.VC.GetVertex(ctx, s.VC, ontoRef)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(todo) - 1; i >= 0; i-- {
+		onto, err = replay(ctx, mach, s, onto, todo[i].Commit, params)
+		if err != nil {
+			return nil, fmt.Errorf("replaying %v: %w", todo[i].Ref, err)
+		}
+	}
+	return &onto, nil
+}
+
+// Revert creates a new Commit on top of the Commit at ontoRef, which undoes the change made by the Commit at ref
+// relative to its parent.
+// The new Commit is given a message saying which Commit was reverted.
+// If the change cannot be undone cleanly, then ErrMergeConflict is returned.
+// s must contain both Commits, and the parent of the Commit at ref.
+func Revert(ctx context.Context, mach *Machine, s RW, ontoRef, ref gdat.Ref, params ReplayParams) (*Commit, error) {
+	onto, err := mach.VC.GetVertex(ctx, s.VC, ontoRef)
+	if err != nil {
+		return nil, err
+	}
+	comm, err := mach.VC.GetVertex(ctx, s.VC, ref)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := parentSnap(ctx, mach, s, comm)
+	if err != nil {
+		return nil, err
+	}
+	// reverting is the same as replaying the change from comm back to its parent.
+	snap, conflicts, err := mach.FS.Merge(ctx, s.FS, comm.Payload.Snap, onto.Payload.Snap, prev)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, ErrMergeConflict{Paths: conflicts}
+	}
+	notes, err := comm.Payload.ParseNotes()
+	if err != nil {
+		return nil, err
+	}
+	subject, _, _ := strings.Cut(notes.Message, "\n")
+	next, err := CreateCommit(ctx, &mach.VC, s.VC, CommitParams{
+		Committer:   params.Committer,
+		CommittedAt: params.CommittedAt,
+		Base:        []Commit{onto},
+		Snap:        *snap,
+		Notes: CommitNotes{
+			Message: fmt.Sprintf("revert %q\n\nThis reverts commit %v.", subject, ref),
+		},
+		Signer: params.Signer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// replay creates a new Commit on top of onto, which makes the same change to the filesystem as comm
+// made to its parent.
+func replay(ctx context.Context, mach *Machine, s RW, onto, comm Commit, params ReplayParams) (Commit, error) {
+	base, err := parentSnap(ctx, mach, s, comm)
+	if err != nil {
+		return Commit{}, err
+	}
+	snap, conflicts, err := mach.FS.Merge(ctx, s.FS, base, onto.Payload.Snap, comm.Payload.Snap)
+	if err != nil {
+		return Commit{}, err
+	}
+	if len(conflicts) > 0 {
+		return Commit{}, ErrMergeConflict{Paths: conflicts}
+	}
+	notes, err := comm.Payload.ParseNotes()
+	if err != nil {
+		return Commit{}, err
+	}
+	return CreateCommit(ctx, &mach.VC, s.VC, CommitParams{
+		Committer:   params.Committer,
+		CommittedAt: params.CommittedAt,
+		Base:        []Commit{onto},
+		Snap:        *snap,
+		Notes:       notes,
+		Signer:      params.Signer,
+	})
+}
+
+// parentSnap returns the filesystem of comm's parent, which comm's change is relative to.
+// If comm has no parents, then the change is relative to an empty filesystem.
+// Merge commits do not have a single change, so an error is returned for them.
+func parentSnap(ctx context.Context, mach *Machine, s RW, comm Commit) (gotfs.Root, error) {
+	switch len(comm.Parents) {
+	case 0:
+		root, err := mach.FS.NewEmpty(ctx, s.FS.Metadata, 0o755)
+		if err != nil {
+			return gotfs.Root{}, err
+		}
+		return *root, nil
+	case 1:
+		parent, err := mach.VC.GetVertex(ctx, s.VC, comm.Parents[0])
+		if err != nil {
+			return gotfs.Root{}, err
+		}
+		return parent.Payload.Snap, nil
+	default:
+		return gotfs.Root{}, fmt.Errorf("commit has %d parents, cannot determine its change", len(comm.Parents))
+	}
+}
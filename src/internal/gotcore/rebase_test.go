@@ -0,0 +1,92 @@
+package gotcore
+
+import (
+	"testing"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"go.inet256.org/inet256/src/inet256"
+)
+
+func TestRebase(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, msg string, files map[string]string) (gdat.Ref, Commit) {
+		comm, err := CreateCommit(ctx, &mach.VC, s, CommitParams{
+			Committer: inet256.ID{2},
+			Base:      parents,
+			Snap:      makeFS(t, ss.FS, files),
+			Notes:     CommitNotes{Message: msg},
+		})
+		require.NoError(t, err)
+		ref, err := mach.VC.PostVertex(ctx, s, comm)
+		require.NoError(t, err)
+		return ref, comm
+	}
+	requireFiles := func(comm Commit, files map[string]string) {
+		t.Helper()
+		for p, data := range files {
+			actual, err := mach.FS.ReadFile(ctx, ss.FS.RO(), comm.Payload.Snap, p, 1024)
+			require.NoError(t, err)
+			require.Equal(t, data, string(actual))
+		}
+	}
+	params := ReplayParams{Committer: inet256.ID{1}}
+
+	baseRef, base := post(nil, "base", map[string]string{"a.txt": "a"})
+	ontoRef, onto := post([]Commit{base}, "onto", map[string]string{"a.txt": "a", "b.txt": "b"})
+	_, x1 := post([]Commit{base}, "x1", map[string]string{"a.txt": "a1"})
+	x2Ref, _ := post([]Commit{x1}, "x2", map[string]string{"a.txt": "a1", "c.txt": "c"})
+
+	t.Run("CherryPick", func(t *testing.T) {
+		out, err := CherryPick(ctx, &mach, ss, ontoRef, x2Ref, params)
+		require.NoError(t, err)
+		require.Len(t, out.Parents, 1)
+		require.Equal(t, onto.N+1, out.N)
+		// only the change made by x2 is applied.
+		requireFiles(*out, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+		notes, err := out.Payload.ParseNotes()
+		require.NoError(t, err)
+		require.Equal(t, "x2", notes.Message)
+		require.Equal(t, inet256.ID{1}, out.Creator)
+	})
+	t.Run("Rebase", func(t *testing.T) {
+		out, err := Rebase(ctx, &mach, ss, gotkv.Root{}, x2Ref, ontoRef, params)
+		require.NoError(t, err)
+		require.Equal(t, onto.N+2, out.N)
+		requireFiles(*out, map[string]string{"a.txt": "a1", "b.txt": "b", "c.txt": "c"})
+		notes, err := out.Payload.ParseNotes()
+		require.NoError(t, err)
+		require.Equal(t, "x2", notes.Message)
+	})
+	t.Run("UpToDate", func(t *testing.T) {
+		out, err := Rebase(ctx, &mach, ss, gotkv.Root{}, ontoRef, baseRef, params)
+		require.NoError(t, err)
+		require.True(t, out.Equals(onto))
+		out, err = Rebase(ctx, &mach, ss, gotkv.Root{}, baseRef, ontoRef, params)
+		require.NoError(t, err)
+		require.True(t, out.Equals(onto))
+	})
+	t.Run("Conflict", func(t *testing.T) {
+		conflictingRef, _ := post([]Commit{onto}, "conflict", map[string]string{"a.txt": "a2", "b.txt": "b"})
+		_, err := Rebase(ctx, &mach, ss, gotkv.Root{}, x2Ref, conflictingRef, params)
+		require.True(t, IsMergeConflict(err))
+	})
+	t.Run("Revert", func(t *testing.T) {
+		x1Ref, err := mach.VC.PostVertex(ctx, s, x1)
+		require.NoError(t, err)
+		// the c.txt added after x1 is kept.
+		out, err := Revert(ctx, &mach, ss, x2Ref, x1Ref, params)
+		require.NoError(t, err)
+		requireFiles(*out, map[string]string{"a.txt": "a", "c.txt": "c"})
+		notes, err := out.Payload.ParseNotes()
+		require.NoError(t, err)
+		require.Contains(t, notes.Message, "x1")
+	})
+}
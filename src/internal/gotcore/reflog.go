@@ -0,0 +1,105 @@
+package gotcore
+
+import (
+	"context"
+
+	"go.brendoncarroll.net/tai64"
+	"go.inet256.org/inet256/src/inet256"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/internal/sbe"
+)
+
+// LogEntry records a change to the target of a Mark.
+type LogEntry struct {
+	Prev, Next gdat.Ref
+	At         tai64.TAI64
+	// Actor is the identity which made the change.
+	// It is zero if the actor is not known.
+	Actor inet256.ID
+	// Op names the operation which made the change. e.g. "commit" or "sync"
+	Op string
+}
+
+func (e LogEntry) Marshal(out []byte) []byte {
+	out = gdat.AppendRef(out, e.Prev)
+	out = gdat.AppendRef(out, e.Next)
+	out = append(out, e.At.Marshal()...)
+	out = append(out, e.Actor[:]...)
+	out = sbe.AppendLP(out, []byte(e.Op))
+	return out
+}
+
+func (e *LogEntry) Unmarshal(data []byte) error {
+	var refs [2]gdat.Ref
+	for i := range refs {
+		refData, rest, err := sbe.ReadN(data, gdat.RefSize)
+		if err != nil {
+			return err
+		}
+		if refs[i], err = gdat.ParseRef(refData); err != nil {
+			return err
+		}
+		data = rest
+	}
+	atData, data, err := sbe.ReadN(data, 8)
+	if err != nil {
+		return err
+	}
+	at, err := tai64.Parse(atData)
+	if err != nil {
+		return err
+	}
+	actorData, data, err := sbe.ReadN(data, inet256.AddrSize)
+	if err != nil {
+		return err
+	}
+	op, _, err := sbe.ReadLP(data)
+	if err != nil {
+		return err
+	}
+	e.Prev, e.Next = refs[0], refs[1]
+	e.At = at
+	e.Actor = inet256.IDFromBytes(actorData)
+	e.Op = string(op)
+	return nil
+}
+
+type logInfoKey struct{}
+
+type logInfo struct {
+	actor inet256.ID
+	op    string
+}
+
+// WithLogInfo returns a context which attributes changes to Mark targets, made using it, to actor and op.
+// Anything already set in ctx takes precedence, so the outermost operation names the change.
+// actor can be zero, and op can be empty, to leave them unset.
+func WithLogInfo(ctx context.Context, actor inet256.ID, op string) context.Context {
+	li := getLogInfo(ctx)
+	if li.actor.IsZero() {
+		li.actor = actor
+	}
+	if li.op == "" {
+		li.op = op
+	}
+	return context.WithValue(ctx, logInfoKey{}, li)
+}
+
+// NewLogEntry returns a LogEntry for a change from prev to next, made now.
+// The actor and op are taken from ctx. See WithLogInfo.
+func NewLogEntry(ctx context.Context, prev, next gdat.Ref) LogEntry {
+	li := getLogInfo(ctx)
+	return LogEntry{
+		Prev:  prev,
+		Next:  next,
+		At:    tai64.Now().TAI64(),
+		Actor: li.actor,
+		Op:    li.op,
+	}
+}
+
+func getLogInfo(ctx context.Context) logInfo {
+	li, _ := ctx.Value(logInfoKey{}).(logInfo)
+	return li
+}
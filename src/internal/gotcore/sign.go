@@ -0,0 +1,67 @@
+package gotcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.inet256.org/inet256/src/inet256"
+)
+
+var (
+	// ErrUnsigned is returned when verifying a Commit without a signature.
+	ErrUnsigned = errors.New("commit is not signed")
+	// ErrUnknownSigner is returned when verifying a Commit signed by an identity which cannot be looked up.
+	ErrUnknownSigner = errors.New("commit was signed by an unknown identity")
+)
+
+var sigCtxCommit = inet256.SigCtxString("got/commit")
+
+// Signer signs Commits on behalf of an identity.
+type Signer struct {
+	PKI        inet256.PKI
+	PrivateKey inet256.PrivateKey
+}
+
+// ID returns the ID of the identity that the Signer signs for.
+// Signed Commits must have this ID as their Creator.
+func (s *Signer) ID() inet256.ID {
+	return s.PKI.NewID(s.PrivateKey.Public().(inet256.PublicKey))
+}
+
+// Sign sets the signature on comm.
+// comm.Creator must be the Signer's ID.
+func (s *Signer) Sign(comm *Commit) error {
+	if id := s.ID(); comm.Creator != id {
+		return fmt.Errorf("cannot sign commit created by %v as %v", comm.Creator, id)
+	}
+	comm.Sig = s.PKI.Sign(&sigCtxCommit, s.PrivateKey, comm.SigData(nil), nil)
+	return nil
+}
+
+// GetVerifierFunc returns the public signing key for the identity with the given ID.
+// If the identity is not known, then (nil, nil) should be returned.
+type GetVerifierFunc = func(ctx context.Context, id inet256.ID) (inet256.PublicKey, error)
+
+// VerifyCommit checks that comm was signed by its Creator.
+// The Creator's public key is looked up with getVerifier.
+// ErrUnsigned is returned if there is no signature, and ErrUnknownSigner if getVerifier does not know the Creator.
+func VerifyCommit(ctx context.Context, pki inet256.PKI, getVerifier GetVerifierFunc, comm Commit) error {
+	if len(comm.Sig) == 0 {
+		return ErrUnsigned
+	}
+	pubKey, err := getVerifier(ctx, comm.Creator)
+	if err != nil {
+		return err
+	}
+	if pubKey == nil {
+		return fmt.Errorf("%w: %v", ErrUnknownSigner, comm.Creator)
+	}
+	if id := pki.NewID(pubKey); id != comm.Creator {
+		return fmt.Errorf("verifier for %v has ID %v", comm.Creator, id)
+	}
+	if !pki.Verify(&sigCtxCommit, pubKey, comm.SigData(nil), comm.Sig) {
+		return fmt.Errorf("invalid signature on commit by %v", comm.Creator)
+	}
+	return nil
+}
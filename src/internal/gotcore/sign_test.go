@@ -0,0 +1,91 @@
+package gotcore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/ed25519"
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"go.inet256.org/inet256/src/inet256"
+)
+
+func TestSignCommit(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	pki := inet256.PKI{
+		Default: inet256.SignAlgo_Ed25519,
+		Schemes: map[string]sign.Scheme{inet256.SignAlgo_Ed25519: ed25519.Scheme()},
+	}
+	newSigner := func() *Signer {
+		_, priv, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		return &Signer{PKI: pki, PrivateKey: priv}
+	}
+	signer := newSigner()
+	getVerifier := func(ctx context.Context, id inet256.ID) (inet256.PublicKey, error) {
+		if id == signer.ID() {
+			return signer.PrivateKey.Public().(inet256.PublicKey), nil
+		}
+		return nil, nil
+	}
+
+	comm := makeCommit(t, DSConfig{}, s, nil, makeFS(t, ss.FS, map[string]string{"a.txt": "a"}))
+	require.ErrorIs(t, VerifyCommit(ctx, pki, getVerifier, *comm), ErrUnsigned)
+	// the signer must be the creator
+	require.Error(t, signer.Sign(comm))
+
+	comm.Creator = signer.ID()
+	require.NoError(t, signer.Sign(comm))
+	ref, err := mach.VC.PostVertex(ctx, s, *comm)
+	require.NoError(t, err)
+	comm2, err := mach.VC.GetVertex(ctx, s, ref)
+	require.NoError(t, err)
+	require.Equal(t, comm.Sig, comm2.Sig)
+	require.NoError(t, VerifyCommit(ctx, pki, getVerifier, comm2))
+
+	// tampering with the commit invalidates the signature.
+	comm2.N++
+	err = VerifyCommit(ctx, pki, getVerifier, comm2)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrUnknownSigner)
+
+	other := newSigner()
+	comm3 := *comm
+	comm3.Creator = other.ID()
+	require.NoError(t, other.Sign(&comm3))
+	require.ErrorIs(t, VerifyCommit(ctx, pki, getVerifier, comm3), ErrUnknownSigner)
+}
+
+func TestVerifyTag(t *testing.T) {
+	ctx := testutil.Context(t)
+	pki := inet256.PKI{
+		Default: inet256.SignAlgo_Ed25519,
+		Schemes: map[string]sign.Scheme{inet256.SignAlgo_Ed25519: ed25519.Scheme()},
+	}
+	_, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer := &Signer{PKI: pki, PrivateKey: priv}
+	getVerifier := func(ctx context.Context, id inet256.ID) (inet256.PublicKey, error) {
+		if id == signer.ID() {
+			return signer.PrivateKey.Public().(inet256.PublicKey), nil
+		}
+		return nil, nil
+	}
+	target := gdat.Ref{CID: stores.Hash([]byte("target"))}
+	other := gdat.Ref{CID: stores.Hash([]byte("other"))}
+
+	info := Info{Annotations: tagAnnotations("v1", TagParams{Target: target, Message: "hello", Signer: signer})}
+	require.NoError(t, VerifyTag(ctx, pki, getVerifier, "v1", info, target))
+	require.Error(t, VerifyTag(ctx, pki, getVerifier, "v2", info, target))
+	require.Error(t, VerifyTag(ctx, pki, getVerifier, "v1", info, other))
+
+	unsigned := Info{Annotations: tagAnnotations("v1", TagParams{Target: target})}
+	require.ErrorIs(t, VerifyTag(ctx, pki, getVerifier, "v1", unsigned, target), ErrUnsigned)
+}
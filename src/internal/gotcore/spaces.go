@@ -12,7 +12,9 @@ import (
 
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
 	"github.com/gotvc/got/src/internal/stores"
+	"go.inet256.org/inet256/src/inet256"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -121,7 +123,10 @@ type SpaceTx interface {
 	// SetMetadata sets the metadata for the Mark at name to md
 	SetMetadata(ctx context.Context, name string, md Metadata) error
 	// Delete deletes a Mark and all of it's metadata, the Commit is not removed.
+	// Tags which have a target cannot be deleted, Delete returns ErrImmutable for them.
 	Delete(ctx context.Context, name string) error
+	// ForceDelete is like Delete, but also deletes tags.
+	ForceDelete(ctx context.Context, name string) error
 	// All iterates over all the mark names.
 	All(context.Context) iter.Seq2[string, error]
 
@@ -131,11 +136,22 @@ type SpaceTx interface {
 	// 1: GotFS metadata
 	// 2: GotVC
 	Stores() RW
-	// SetTarget changes the mark so it points to a different commit
+	// SetTarget changes the mark so it points to a different commit.
+	// If the target changes, then a LogEntry is added to the mark's log.
 	SetTarget(ctx context.Context, name string, ref gdat.Ref) error
 	// GetTarget retrieves the Commit referenced by gdat.Ref
 	// If the name has no ref, then the zero value, and nil should be returned.
 	GetTarget(ctx context.Context, name string) (gdat.Ref, error)
+
+	// GetIndex returns the root of the commit graph index for the mark at name.
+	// If the mark does not have an index, then the zero value, and nil should be returned.
+	GetIndex(ctx context.Context, name string) (gotkv.Root, error)
+	// SetIndex sets the root of the commit graph index for the mark at name.
+	SetIndex(ctx context.Context, name string, root gotkv.Root) error
+
+	// GetLog returns the changes to the target of the mark at name, most recent first.
+	// The log is bounded, so only some of the most recent changes are kept.
+	GetLog(ctx context.Context, name string) ([]LogEntry, error)
 }
 
 func CreateIfNotExists(ctx context.Context, stx SpaceTx, k string, cfg Metadata) (*Info, error) {
@@ -167,6 +183,7 @@ func ForEach(ctx context.Context, stx SpaceTx, span Span, fn func(string) error)
 }
 
 func CloneMark(ctx context.Context, st SpaceTx, from, to string) error {
+	ctx = WithLogInfo(ctx, inet256.ID{}, "cp")
 	baseInfo, err := st.Inspect(ctx, from)
 	if err != nil {
 		return err
@@ -0,0 +1,104 @@
+package gotcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gotvc/got/src/gdat"
+	"go.brendoncarroll.net/tai64"
+)
+
+// Squash replaces the last n Commits in the history of ref with a single Commit.
+// The new Commit has the filesystem of the Commit at ref, and the parents of the oldest squashed Commit.
+// Its CommitNotes combine the messages and authors of all the squashed Commits.
+// If n is 1, then the Commit at ref is returned unchanged.
+func Squash(ctx context.Context, mach *Machine, s RW, ref gdat.Ref, n int, params ReplayParams) (*Commit, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("cannot squash %d commits", n)
+	}
+	comm, err := mach.VC.GetVertex(ctx, s.VC, ref)
+	if err != nil {
+		return nil, err
+	}
+	if n == 1 {
+		return &comm, nil
+	}
+	// the squashed commits, newest first.
+	squashed := []Commit{comm}
+	for len(squashed) < n {
+		x := squashed[len(squashed)-1]
+		if len(x.Parents) != 1 {
+			return nil, fmt.Errorf("cannot squash %d commits, only %d commits in linear history", n, len(squashed))
+		}
+		parent, err := mach.VC.GetVertex(ctx, s.VC, x.Parents[0])
+		if err != nil {
+			return nil, err
+		}
+		squashed = append(squashed, parent)
+	}
+	y, err := mach.VC.Squash(ctx, s.VC, comm, n-1)
+	if err != nil {
+		return nil, err
+	}
+	var notes CommitNotes
+	var msgs []string
+	for _, x := range slices.Backward(squashed) {
+		xnotes, err := x.Payload.ParseNotes()
+		if err != nil {
+			return nil, err
+		}
+		if xnotes.Message != "" {
+			msgs = append(msgs, xnotes.Message)
+		}
+		for _, author := range xnotes.Authors {
+			if !slices.Contains(notes.Authors, author) {
+				notes.Authors = append(notes.Authors, author)
+			}
+		}
+		notes.AuthoredAt = max(notes.AuthoredAt, xnotes.AuthoredAt)
+	}
+	notes.Message = strings.Join(msgs, "\n\n")
+	notesData, err := json.Marshal(notes)
+	if err != nil {
+		return nil, err
+	}
+	if params.CommittedAt == 0 {
+		params.CommittedAt = tai64.Now().TAI64()
+	}
+	y.Creator = params.Committer
+	y.CreatedAt = max(params.CommittedAt, comm.CreatedAt)
+	y.Payload = Payload{
+		Snap:  comm.Payload.Snap,
+		Notes: notesData,
+	}
+	if params.Signer != nil {
+		if err := params.Signer.Sign(y); err != nil {
+			return nil, err
+		}
+	}
+	return y, nil
+}
+
+// SquashUntil squashes all of the Commits in the history of ref, which come after the Commit at untilRef,
+// into a single Commit, whose parent is the Commit at untilRef.
+// untilRef must be reachable from ref by following linear history.
+func SquashUntil(ctx context.Context, mach *Machine, s RW, ref, untilRef gdat.Ref, params ReplayParams) (*Commit, error) {
+	var n int
+	for x := ref; !x.Equals(&untilRef); n++ {
+		comm, err := mach.VC.GetVertex(ctx, s.VC, x)
+		if err != nil {
+			return nil, err
+		}
+		if len(comm.Parents) != 1 {
+			return nil, fmt.Errorf("%v is not an ancestor of %v in linear history", untilRef, ref)
+		}
+		x = comm.Parents[0]
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("nothing to squash, %v is the same as %v", untilRef, ref)
+	}
+	return Squash(ctx, mach, s, ref, n, params)
+}
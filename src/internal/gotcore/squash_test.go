@@ -0,0 +1,60 @@
+package gotcore
+
+import (
+	"testing"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/internal/stores"
+	"github.com/gotvc/got/src/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"go.inet256.org/inet256/src/inet256"
+)
+
+func TestSquash(t *testing.T) {
+	ctx := testutil.Context(t)
+	s := stores.NewMem()
+	ss := RW{FS: gotfs.RW{Metadata: s, Data: s}, VC: s}
+	mach := NewMachine(DSConfig{})
+	post := func(parents []Commit, author inet256.ID, msg string, files map[string]string) (gdat.Ref, Commit) {
+		comm, err := CreateCommit(ctx, &mach.VC, s, CommitParams{
+			Committer: author,
+			Base:      parents,
+			Snap:      makeFS(t, ss.FS, files),
+			Notes:     CommitNotes{Message: msg},
+		})
+		require.NoError(t, err)
+		ref, err := mach.VC.PostVertex(ctx, s, comm)
+		require.NoError(t, err)
+		return ref, comm
+	}
+	params := ReplayParams{Committer: inet256.ID{1}}
+
+	baseRef, base := post(nil, inet256.ID{1}, "base", map[string]string{"a.txt": "a"})
+	_, x1 := post([]Commit{base}, inet256.ID{2}, "wip 1", map[string]string{"a.txt": "a1"})
+	_, x2 := post([]Commit{x1}, inet256.ID{3}, "wip 2", map[string]string{"a.txt": "a2"})
+	x3Ref, x3 := post([]Commit{x2}, inet256.ID{2}, "wip 3", map[string]string{"a.txt": "a3"})
+
+	check := func(out *Commit) {
+		t.Helper()
+		require.Equal(t, []gdat.Ref{baseRef}, out.Parents)
+		require.Equal(t, base.N+1, out.N)
+		require.Equal(t, x3.Payload.Snap, out.Payload.Snap)
+		notes, err := out.Payload.ParseNotes()
+		require.NoError(t, err)
+		require.Equal(t, "wip 1\n\nwip 2\n\nwip 3", notes.Message)
+		require.Equal(t, []inet256.ID{{2}, {3}}, notes.Authors)
+	}
+	out, err := Squash(ctx, &mach, ss, x3Ref, 3, params)
+	require.NoError(t, err)
+	check(out)
+	out, err = SquashUntil(ctx, &mach, ss, x3Ref, baseRef, params)
+	require.NoError(t, err)
+	check(out)
+
+	out, err = Squash(ctx, &mach, ss, x3Ref, 1, params)
+	require.NoError(t, err)
+	require.True(t, out.Equals(x3))
+	_, err = Squash(ctx, &mach, ss, x3Ref, 5, params)
+	require.Error(t, err)
+}
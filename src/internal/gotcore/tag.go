@@ -0,0 +1,175 @@
+package gotcore
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.inet256.org/inet256/src/inet256"
+
+	"github.com/gotvc/got/src/gdat"
+	"github.com/gotvc/got/src/internal/sbe"
+)
+
+// Annotations with these keys are reserved for tags.
+const (
+	// AnnotationTag is set to "true" on marks which are tags.
+	AnnotationTag = "got.tag"
+	// AnnotationTagMessage holds the tag's message.
+	AnnotationTagMessage = "got.tag.message"
+	// AnnotationTagSigner holds the hex encoded ID of the identity which signed the tag.
+	AnnotationTagSigner = "got.tag.signer"
+	// AnnotationTagSig holds the hex encoded signature of the tag.
+	AnnotationTagSig = "got.tag.sig"
+)
+
+var tagAnnotationKeys = []string{AnnotationTag, AnnotationTagMessage, AnnotationTagSigner, AnnotationTagSig}
+
+// ErrImmutable is returned when trying to move a tag.
+var ErrImmutable = errors.New("mark is an immutable tag")
+
+var sigCtxTag = inet256.SigCtxString("got/tag")
+
+// IsTag returns true if the mark is a tag.
+// Once a tag has a target, it cannot be changed.
+func (i Info) IsTag() bool {
+	return isTag(i.Annotations)
+}
+
+// IsTag returns true if a mark created with md would be a tag.
+func (md Metadata) IsTag() bool {
+	return isTag(md.Annotations)
+}
+
+func isTag(as []Annotation) bool {
+	return slices.ContainsFunc(GetAnnotation(as, AnnotationTag), func(a Annotation) bool {
+		return a.Value == "true"
+	})
+}
+
+// TagMessage returns the message of the tag, or "" if there is none.
+func (i Info) TagMessage() string {
+	return getAnnotationValue(i.Annotations, AnnotationTagMessage)
+}
+
+func getAnnotationValue(as []Annotation, key string) string {
+	if anns := GetAnnotation(as, key); len(anns) > 0 {
+		return anns[0].Value
+	}
+	return ""
+}
+
+// CheckTagChange returns ErrImmutable if changing a mark from prev to next would move a tag.
+// A tag without a target can still be set, but after that its target, and the tag annotations, are fixed.
+func CheckTagChange(prev Info, prevTarget gdat.Ref, next Info, nextTarget gdat.Ref) error {
+	if !prev.IsTag() || prevTarget.IsZero() {
+		return nil
+	}
+	if !prevTarget.Equals(&nextTarget) {
+		return fmt.Errorf("%w: cannot change target from %v to %v", ErrImmutable, prevTarget.CID, nextTarget.CID)
+	}
+	for _, key := range tagAnnotationKeys {
+		if !slices.Equal(GetAnnotation(prev.Annotations, key), GetAnnotation(next.Annotations, key)) {
+			return fmt.Errorf("%w: cannot change annotation %q", ErrImmutable, key)
+		}
+	}
+	return nil
+}
+
+// TagParams are the parameters for creating a tag.
+type TagParams struct {
+	Config DSConfig
+	Target gdat.Ref
+	// Message is optional.
+	Message string
+	// Signer is optional. If set, the tag is signed.
+	Signer *Signer
+}
+
+// CreateTag creates a new mark at name, which is a tag pointing at params.Target.
+func CreateTag(ctx context.Context, stx SpaceTx, name string, params TagParams) (*Info, error) {
+	if params.Target.IsZero() {
+		return nil, fmt.Errorf("cannot create tag %q without a target", name)
+	}
+	ctx = WithLogInfo(ctx, inet256.ID{}, "tag")
+	anns := tagAnnotations(name, params)
+	if _, err := stx.Create(ctx, name, Metadata{Config: params.Config, Annotations: anns}); err != nil {
+		return nil, err
+	}
+	mtx, err := NewMarkTx(ctx, stx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := mtx.Save(ctx, params.Target); err != nil {
+		return nil, err
+	}
+	info := mtx.Info()
+	return &info, nil
+}
+
+// tagAnnotations returns the annotations for a tag at name.
+func tagAnnotations(name string, params TagParams) []Annotation {
+	anns := []Annotation{{Key: AnnotationTag, Value: "true"}}
+	if params.Message != "" {
+		anns = append(anns, Annotation{Key: AnnotationTagMessage, Value: params.Message})
+	}
+	if params.Signer != nil {
+		id := params.Signer.ID()
+		sig := params.Signer.PKI.Sign(&sigCtxTag, params.Signer.PrivateKey, tagSigData(nil, name, params.Target, params.Message), nil)
+		anns = append(anns,
+			Annotation{Key: AnnotationTagSigner, Value: hex.EncodeToString(id[:])},
+			Annotation{Key: AnnotationTagSig, Value: hex.EncodeToString(sig)},
+		)
+	}
+	SortAnnotations(anns)
+	return anns
+}
+
+// VerifyTag checks the signature on the tag at name.
+// It returns ErrUnsigned if the tag is not signed, and ErrUnknownSigner if getVerifier does not know the signer.
+func VerifyTag(ctx context.Context, pki inet256.PKI, getVerifier GetVerifierFunc, name string, info Info, target gdat.Ref) error {
+	if !info.IsTag() {
+		return fmt.Errorf("mark %q is not a tag", name)
+	}
+	sigHex := getAnnotationValue(info.Annotations, AnnotationTagSig)
+	if sigHex == "" {
+		return fmt.Errorf("tag %q: %w", name, ErrUnsigned)
+	}
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return fmt.Errorf("parsing tag signature: %w", err)
+	}
+	idBytes, err := hex.DecodeString(getAnnotationValue(info.Annotations, AnnotationTagSigner))
+	if err != nil {
+		return fmt.Errorf("parsing tag signer: %w", err)
+	}
+	var signer inet256.ID
+	if len(idBytes) != len(signer) {
+		return fmt.Errorf("tag signer has wrong length %d", len(idBytes))
+	}
+	signer = inet256.IDFromBytes(idBytes)
+	pubKey, err := getVerifier(ctx, signer)
+	if err != nil {
+		return err
+	}
+	if pubKey == nil {
+		return fmt.Errorf("%w: %v", ErrUnknownSigner, signer)
+	}
+	if id := pki.NewID(pubKey); id != signer {
+		return fmt.Errorf("verifier for %v has ID %v", signer, id)
+	}
+	if !pki.Verify(&sigCtxTag, pubKey, tagSigData(nil, name, target, info.TagMessage()), sig) {
+		return fmt.Errorf("invalid signature on tag %q by %v", name, signer)
+	}
+	return nil
+}
+
+// tagSigData is the data signed by a tag's signer.
+func tagSigData(out []byte, name string, target gdat.Ref, msg string) []byte {
+	out = sbe.AppendLP(out, []byte(name))
+	out = gdat.AppendRef(out, target)
+	out = sbe.AppendLP(out, []byte(msg))
+	return out
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/gotvc/got/src/internal/stores"
 	"github.com/gotvc/got/src/internal/testutil"
 	"github.com/stretchr/testify/require"
+	"go.inet256.org/inet256/src/inet256"
 )
 
 func TestSpace(t *testing.T, newSpace func(t testing.TB) Space) {
@@ -69,6 +70,127 @@ func TestSpace(t *testing.T, newSpace func(t testing.TB) Space) {
 	t.Run("Sync", func(t *testing.T) {
 		TestSync(t, newSpace)
 	})
+	t.Run("Tag", func(t *testing.T) {
+		TestTag(t, newSpace)
+	})
+	t.Run("Log", func(t *testing.T) {
+		TestLog(t, newSpace)
+	})
+}
+
+// TestLog checks that changes to the target of a mark are logged.
+func TestLog(t *testing.T, setup func(testing.TB) Space) {
+	ctx := testutil.Context(t)
+	x := setup(t)
+	mach := NewMachine(DSConfig{})
+	var refs []gdat.Ref
+	require.NoError(t, x.Do(ctx, true, func(st SpaceTx) error {
+		ss := st.Stores()
+		var parents []Commit
+		for i := range 3 {
+			comm := makeCommit(t, DSConfig{}, ss.VC, parents, makeFS(t, ss.FS, map[string]string{"a": strconv.Itoa(i)}))
+			ref, err := mach.VC.PostVertex(ctx, ss.VC, *comm)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, ref)
+			parents = []Commit{*comm}
+		}
+		if _, err := st.Create(ctx, "test", Metadata{}); err != nil {
+			return err
+		}
+		ctx := WithLogInfo(ctx, inet256.ID{1}, "test-op")
+		for _, ref := range refs {
+			if err := st.SetTarget(ctx, "test", ref); err != nil {
+				return err
+			}
+		}
+		// setting the same target again is not a change
+		return st.SetTarget(ctx, "test", refs[2])
+	}))
+	require.NoError(t, x.Do(ctx, false, func(st SpaceTx) error {
+		ents, err := st.GetLog(ctx, "test")
+		require.NoError(t, err)
+		require.Len(t, ents, 3)
+		require.Equal(t, refs[2], ents[0].Next)
+		require.Equal(t, refs[1], ents[0].Prev)
+		require.True(t, ents[2].Prev.IsZero())
+		require.Equal(t, inet256.ID{1}, ents[0].Actor)
+		require.Equal(t, "test-op", ents[0].Op)
+
+		se, err := ParseCommitExpr("test@{2}")
+		require.NoError(t, err)
+		ref, err := se.Resolve(ctx, st)
+		require.NoError(t, err)
+		require.Equal(t, refs[0], ref)
+		return nil
+	}))
+}
+
+// TestTag checks that a tag cannot be moved once it has a target.
+func TestTag(t *testing.T, setup func(testing.TB) Space) {
+	ctx := testutil.Context(t)
+	x := setup(t)
+	mach := NewMachine(DSConfig{})
+	var ref0, ref1 gdat.Ref
+	require.NoError(t, x.Do(ctx, true, func(st SpaceTx) error {
+		ss := st.Stores()
+		comm0 := makeCommit(t, DSConfig{}, ss.VC, nil, makeFS(t, ss.FS, map[string]string{"a": "0"}))
+		comm1 := makeCommit(t, DSConfig{}, ss.VC, []Commit{*comm0}, makeFS(t, ss.FS, map[string]string{"a": "1"}))
+		var err error
+		if ref0, err = mach.VC.PostVertex(ctx, ss.VC, *comm0); err != nil {
+			return err
+		}
+		if ref1, err = mach.VC.PostVertex(ctx, ss.VC, *comm1); err != nil {
+			return err
+		}
+		info, err := CreateTag(ctx, st, "v1.0.0", TagParams{Target: ref0, Message: "first release"})
+		if err != nil {
+			return err
+		}
+		require.True(t, info.IsTag())
+		require.Equal(t, "first release", info.TagMessage())
+		return nil
+	}))
+
+	require.NoError(t, x.Do(ctx, true, func(st SpaceTx) error {
+		require.ErrorIs(t, st.SetTarget(ctx, "v1.0.0", ref1), ErrImmutable)
+		require.ErrorIs(t, st.SetTarget(ctx, "v1.0.0", gdat.Ref{}), ErrImmutable)
+		require.ErrorIs(t, st.SetMetadata(ctx, "v1.0.0", Metadata{}), ErrImmutable)
+		mtx, err := NewMarkTx(ctx, st, "v1.0.0")
+		require.NoError(t, err)
+		require.ErrorIs(t, mtx.Save(ctx, ref1), ErrImmutable)
+		// saving the same target is not a move.
+		require.NoError(t, mtx.Save(ctx, ref0))
+
+		if _, err := st.Create(ctx, "main", Metadata{}); err != nil {
+			return err
+		}
+		src, err := NewMarkTx(ctx, st, "main")
+		require.NoError(t, err)
+		require.NoError(t, src.Save(ctx, ref1))
+		_, err = Sync(ctx, src, mtx, true)
+		require.ErrorIs(t, err, ErrImmutable)
+		// deleting the tag, and then creating it again, would also move it.
+		require.ErrorIs(t, st.Delete(ctx, "v1.0.0"), ErrImmutable)
+		return nil
+	}))
+
+	require.NoError(t, x.Do(ctx, false, func(st SpaceTx) error {
+		ref, err := st.GetTarget(ctx, "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, ref0, ref)
+		return nil
+	}))
+
+	require.NoError(t, x.Do(ctx, true, func(st SpaceTx) error {
+		return st.ForceDelete(ctx, "v1.0.0")
+	}))
+	require.NoError(t, x.Do(ctx, false, func(st SpaceTx) error {
+		_, err := st.Inspect(ctx, "v1.0.0")
+		require.ErrorIs(t, err, ErrNotExist)
+		return nil
+	}))
 }
 
 func TestSync(t *testing.T, setup func(testing.TB) Space) {
@@ -9,8 +9,11 @@ import (
 	"github.com/gotvc/got/src/gdat"
 	"github.com/gotvc/got/src/gotdag"
 	"github.com/gotvc/got/src/gotfs"
+	"github.com/gotvc/got/src/gotkv"
 	"github.com/gotvc/got/src/internal/metrics"
 	"github.com/gotvc/got/src/internal/stores"
+	"go.brendoncarroll.net/exp/streams"
+	"go.inet256.org/inet256/src/inet256"
 )
 
 // EnsureMark calls Create, but ignores Exists errors.
@@ -95,17 +98,46 @@ func (mtx *MarkTx) WO() WO {
 	return mtx.stx.Stores().WO()
 }
 
-// Save saves the commit to the Mark
+// Save saves the commit to the Mark, and adds it to the Mark's commit graph index.
+// If the Mark is a tag which already has a target, then ErrImmutable is returned.
 func (m *MarkTx) Save(ctx context.Context, ref gdat.Ref) error {
 	ss := m.stx.Stores()
-	if !ref.IsZero() {
-		if exists, err := bcsdk.ExistsUnit(ctx, ss.VC, ref.CID); err != nil {
+	if m.info.IsTag() {
+		prev, err := m.stx.GetTarget(ctx, m.name)
+		if err != nil {
+			return err
+		}
+		if err := CheckTagChange(m.info, prev, m.info, ref); err != nil {
 			return err
-		} else if !exists {
-			return ErrRefIntegrity{Ref: ref, Store: "gotvc"}
 		}
 	}
-	return m.stx.SetTarget(ctx, m.name, ref)
+	if ref.IsZero() {
+		// the index is left as is, everything in it is still true.
+		return m.stx.SetTarget(ctx, m.name, ref)
+	}
+	if exists, err := bcsdk.ExistsUnit(ctx, ss.VC, ref.CID); err != nil {
+		return err
+	} else if !exists {
+		return ErrRefIntegrity{Ref: ref, Store: "gotvc"}
+	}
+	if err := m.stx.SetTarget(ctx, m.name, ref); err != nil {
+		return err
+	}
+	idx, err := m.stx.GetIndex(ctx, m.name)
+	if err != nil {
+		return err
+	}
+	idx, err = m.GotVC().AddToIndex(ctx, ss.VC, idx, ref)
+	if err != nil {
+		return err
+	}
+	return m.stx.SetIndex(ctx, m.name, idx)
+}
+
+// Index returns the root of the Mark's commit graph index.
+// It will be the zero value if the Mark has never been saved.
+func (m *MarkTx) Index(ctx context.Context) (gotkv.Root, error) {
+	return m.stx.GetIndex(ctx, m.name)
 }
 
 // Load loads a commit from the Mark
@@ -155,11 +187,16 @@ func (m *MarkTx) Modify(ctx context.Context, fn func(mctx ModifyCtx) (*Commit, e
 			return err
 		}
 	}
+	idx, err := m.stx.GetIndex(ctx, m.name)
+	if err != nil {
+		return err
+	}
 	modctx := ModifyCtx{
 		Machine: &m.mach,
 		Stores:  ss,
 		Target:  target,
 		Commit:  &comm,
+		Index:   idx,
 	}
 	y, err := fn(modctx)
 	if err != nil {
@@ -186,6 +223,9 @@ type ModifyCtx struct {
 	Stores RW
 	Target gdat.Ref
 	Commit *Commit
+	// Index is the commit graph index of the Mark.
+	// It contains the history of Target, and may be the zero value.
+	Index gotkv.Root
 }
 
 // Sync syncs a commit into the Space's store
@@ -199,19 +239,21 @@ func (mctx *ModifyCtx) Sync(ctx context.Context, srcs RO, root Commit) error {
 	})
 }
 
+// SyncRef is like Sync, but takes the Ref of the Commit.
+// The Commit itself is also copied, so it can be read from the mark's stores by its Ref.
+func (mctx *ModifyCtx) SyncRef(ctx context.Context, srcs RO, ref gdat.Ref) error {
+	return syncCommitRef(ctx, &mctx.VC, &mctx.FS, srcs, mctx.Stores.WO(), ref)
+}
+
 func (b *MarkTx) History(ctx context.Context, fn func(ref gdat.Ref, comm Commit) error) error {
 	b.init()
-	var comm Commit
-	if ok, err := b.LoadCommit(ctx, &comm); err != nil {
+	ref, err := b.Load(ctx)
+	if err != nil {
 		return err
-	} else if !ok {
+	} else if ref.IsZero() {
 		return nil
 	}
-	ref := b.mach.VC.RefFromVertex(comm)
-	if err := fn(ref, comm); err != nil {
-		return err
-	}
-	return b.mach.VC.ForEach(ctx, b.VCRO(), comm.Parents, fn)
+	return History(ctx, &b.mach.VC, b.VCRO(), ref, fn)
 }
 
 func (b *MarkTx) LoadFS(ctx context.Context, dst *gotfs.Root) (bool, error) {
@@ -232,6 +274,9 @@ type ViewCtx struct {
 	Stores RO
 	Target gdat.Ref
 	Root   *Commit
+	// Index is the commit graph index of the mark that se refers to, if any.
+	// It may be the zero value.
+	Index gotkv.Root
 }
 
 func (vc *ViewCtx) FSRO() gotfs.RO {
@@ -255,12 +300,19 @@ func ViewCommit(ctx context.Context, stx SpaceTx, se CommitExpr, fn func(vctx *V
 	if err != nil {
 		return err
 	}
+	var idx gotkv.Root
+	if mark, ok := MarkOf(se); ok {
+		if idx, err = stx.GetIndex(ctx, mark.Name); err != nil {
+			return err
+		}
+	}
 	vctx := ViewCtx{
 		VC:     &vcmach,
 		FS:     &fsmach,
 		Stores: ss.RO(),
 		Target: ref,
 		Root:   &comm,
+		Index:  idx,
 	}
 	return fn(&vctx)
 }
@@ -276,6 +328,7 @@ func Sync(ctx context.Context, src, dst *MarkTx, force bool) (MarkDelta, error)
 	if !force && src.info.Config.Salt != dst.info.Config.Salt {
 		return MarkDelta{}, fmt.Errorf("cannot sync volumes with different salts, must use force=true")
 	}
+	ctx = WithLogInfo(ctx, inet256.ID{}, "sync")
 	var mdelta MarkDelta
 	err := dst.Apply(ctx, func(dsts RW, x gdat.Ref) (ret gdat.Ref, _ error) {
 		mdelta.Prev = x
@@ -285,6 +338,10 @@ func Sync(ctx context.Context, src, dst *MarkTx, force bool) (MarkDelta, error)
 			return gdat.Ref{}, err
 		}
 
+		// tags cannot be moved, even with force.
+		if err := CheckTagChange(dst.info, x, dst.info, goalRef); err != nil {
+			return gdat.Ref{}, err
+		}
 		switch {
 		case goalRef.IsZero() && x.IsZero():
 			return gdat.Ref{}, nil
@@ -294,16 +351,11 @@ func Sync(ctx context.Context, src, dst *MarkTx, force bool) (MarkDelta, error)
 		case goalRef.Equals(&x):
 			return x, nil
 		default:
-			vcmach := dst.GotVC()
-			prevCommit, err := vcmach.GetVertex(ctx, dst.VCRO(), x)
-			if err != nil {
-				return gdat.Ref{}, err
-			}
-			goal, err := src.GotVC().GetVertex(ctx, src.VCRO(), goalRef)
+			srcIdx, err := src.Index(ctx)
 			if err != nil {
 				return gdat.Ref{}, err
 			}
-			hasAncestor, err := vcmach.IsDescendentOf(ctx, src.VCRO(), goal, prevCommit)
+			hasAncestor, err := src.GotVC().IsAncestor(ctx, src.VCRO(), srcIdx, x, goalRef)
 			if err != nil {
 				return gdat.Ref{}, err
 			}
@@ -320,15 +372,43 @@ func Sync(ctx context.Context, src, dst *MarkTx, force bool) (MarkDelta, error)
 	return mdelta, err
 }
 
+// History calls fn for each Commit in the history of the Commit at commRef, including itself.
+// Commits are visited newest first, by N.
 func History(ctx context.Context, vcmach *VCMach, s stores.RO, commRef gdat.Ref, fn func(ref gdat.Ref, comm Commit) error) error {
-	comm, err := vcmach.GetVertex(ctx, s, commRef)
+	return vcmach.ForEachByN(ctx, s, []gdat.Ref{commRef}, fn)
+}
+
+// PathHistory is like History, but fn is only called for the Commits which change the path p,
+// or anything beneath it, relative to their first parent.
+// A Commit without parents changes p if p exists in it.
+func PathHistory(ctx context.Context, vcmach *VCMach, fsmach *FSMach, s RO, commRef gdat.Ref, p string, fn func(ref gdat.Ref, comm Commit) error) error {
+	return History(ctx, vcmach, s.VC, commRef, func(ref gdat.Ref, comm Commit) error {
+		if yes, err := changesPath(ctx, vcmach, fsmach, s, comm, p); err != nil {
+			return err
+		} else if !yes {
+			return nil
+		}
+		return fn(ref, comm)
+	})
+}
+
+// changesPath returns true if comm changes anything at or beneath p, relative to its first parent.
+func changesPath(ctx context.Context, vcmach *VCMach, fsmach *FSMach, s RO, comm Commit, p string) (bool, error) {
+	if len(comm.Parents) == 0 {
+		return fsmach.Exists(ctx, s.FS.Metadata, comm.Payload.Snap, p)
+	}
+	parent, err := vcmach.GetVertex(ctx, s.VC, comm.Parents[0])
 	if err != nil {
-		return err
+		return false, err
 	}
-	if err := fn(commRef, comm); err != nil {
-		return err
+	d := fsmach.NewDifferSpan(s.FS.Metadata, parent.Payload.Snap, comm.Payload.Snap, gotfs.SpanForPath(p))
+	if err := streams.NextUnit(ctx, d, &gotfs.DiffEntry{}); err != nil {
+		if streams.IsEOS(err) {
+			return false, nil
+		}
+		return false, err
 	}
-	return vcmach.ForEach(ctx, s, comm.Parents, fn)
+	return true, nil
 }
 
 // syncCommitRef ensures that all content reachable from Ref is in the dst store.
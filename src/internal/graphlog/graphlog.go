@@ -0,0 +1,123 @@
+// Package graphlog draws a DAG as ASCII art, one node per row, for printing history in a terminal.
+//
+// Each line of descent gets a lane, drawn as a column of '|'.
+// Nodes are drawn as '*' in their lane, and lanes which split or join are connected with '/' and '\'.
+package graphlog
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// Row is the output for a single node.
+type Row struct {
+	// Before are the lines which come before the node line.
+	// They join the lanes which were all waiting for the node.
+	Before []string
+	// Node is the line containing the node, marked with a '*'.
+	Node string
+	// After are the lines which come after the node line.
+	// They split the node's lane into one lane for each of its parents.
+	After []string
+}
+
+// Renderer lays out nodes, which must be added children first.
+// The zero value is ready to use.
+type Renderer[ID comparable] struct {
+	// lanes holds the ID of the node that each lane is waiting for.
+	lanes []ID
+}
+
+// Add adds the next node and returns the lines to draw it.
+func (r *Renderer[ID]) Add(id ID, parents []ID) Row {
+	var row Row
+	// join all the lanes waiting for id into the leftmost one.
+	var waiting []int
+	for i, x := range r.lanes {
+		if x == id {
+			waiting = append(waiting, i)
+		}
+	}
+	var col int
+	if len(waiting) == 0 {
+		col = len(r.lanes)
+		r.lanes = append(r.lanes, id)
+	} else {
+		col = waiting[0]
+		edges := make([]edge, len(r.lanes))
+		var removed int
+		for i := range r.lanes {
+			edges[i] = edge{cur: i, dst: i - removed}
+			if i != col && slices.Contains(waiting, i) {
+				edges[i].dst = col
+				removed++
+			}
+		}
+		row.Before = drawEdges(edges)
+		r.lanes = slices.DeleteFunc(r.lanes, func(x ID) bool { return x == id })
+		r.lanes = slices.Insert(r.lanes, col, id)
+	}
+
+	// the node line
+	node := []byte(r.Padding())
+	node[2*col] = '*'
+	row.Node = string(node)
+
+	// replace the node's lane with one lane for each parent.
+	edges := make([]edge, 0, len(r.lanes)+len(parents))
+	for i := range r.lanes {
+		switch {
+		case i < col:
+			edges = append(edges, edge{cur: i, dst: i})
+		case i == col:
+			for j := range parents {
+				edges = append(edges, edge{cur: i, dst: i + j})
+			}
+		default:
+			edges = append(edges, edge{cur: i, dst: i + len(parents) - 1})
+		}
+	}
+	row.After = drawEdges(edges)
+	r.lanes = slices.Replace(r.lanes, col, col+1, parents...)
+	return row
+}
+
+// Padding returns a line with just the open lanes.
+// It is used to draw the lanes next to any extra lines of text for a node.
+func (r *Renderer[ID]) Padding() string {
+	return strings.Repeat("| ", len(r.lanes))
+}
+
+// edge connects the lane at cur to the lane at dst.
+type edge struct {
+	cur, dst int
+}
+
+// drawEdges draws lines moving each edge at most one lane per line, until every edge has reached its destination.
+// Nothing is drawn if all the edges are straight.
+func drawEdges(edges []edge) []string {
+	var lines []string
+	for slices.ContainsFunc(edges, func(e edge) bool { return e.cur != e.dst }) {
+		var width int
+		for _, e := range edges {
+			width = max(width, e.cur+1, e.dst+1)
+		}
+		line := bytes.Repeat([]byte{' '}, 2*width)
+		for i := range edges {
+			e := &edges[i]
+			switch {
+			case e.cur == e.dst:
+				line[2*e.cur] = '|'
+			case e.dst < e.cur:
+				line[2*e.cur-1] = '/'
+				e.cur--
+			default:
+				line[2*e.cur+1] = '\\'
+				e.cur++
+			}
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
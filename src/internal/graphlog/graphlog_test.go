@@ -0,0 +1,84 @@
+package graphlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer(t *testing.T) {
+	type node struct {
+		ID      string
+		Parents []string
+	}
+	tcs := []struct {
+		Nodes []node
+		Out   string
+	}{
+		{
+			Nodes: []node{{"c", []string{"b"}}, {"b", []string{"a"}}, {"a", nil}},
+			Out: `
+* c
+* b
+* a`,
+		},
+		{
+			Nodes: []node{
+				{"m", []string{"b", "c"}},
+				{"b", []string{"a"}},
+				{"c", []string{"a"}},
+				{"a", nil},
+			},
+			Out: `
+* m
+|\
+* | b
+| * c
+|/
+* a`,
+		},
+		{
+			// two heads, which share a root.
+			Nodes: []node{
+				{"x", []string{"a"}},
+				{"y", []string{"a"}},
+				{"a", nil},
+			},
+			Out: `
+* x
+| * y
+|/
+* a`,
+		},
+		{
+			// a root on the left ends its lane, and the lanes to its right move over.
+			Nodes: []node{
+				{"x", []string{"r"}},
+				{"y", []string{"a"}},
+				{"r", nil},
+				{"a", nil},
+			},
+			Out: `
+* x
+| * y
+* | r
+ /
+* a`,
+		},
+	}
+	for i, tc := range tcs {
+		var r Renderer[string]
+		var lines []string
+		for _, n := range tc.Nodes {
+			row := r.Add(n.ID, n.Parents)
+			lines = append(lines, row.Before...)
+			lines = append(lines, row.Node+n.ID)
+			lines = append(lines, row.After...)
+		}
+		for j := range lines {
+			lines[j] = strings.TrimRight(lines[j], " ")
+		}
+		require.Equal(t, strings.TrimPrefix(tc.Out, "\n"), strings.Join(lines, "\n"), "test case %d", i)
+	}
+}
@@ -0,0 +1,169 @@
+// Package linediff computes line oriented differences between two texts.
+package linediff
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Op is the kind of an Edit
+type Op uint8
+
+const (
+	// Equal means the line is in both texts.
+	Equal Op = iota
+	// Delete means the line is only in the left text.
+	Delete
+	// Insert means the line is only in the right text.
+	Insert
+)
+
+func (o Op) String() string {
+	switch o {
+	case Equal:
+		return "="
+	case Delete:
+		return "-"
+	case Insert:
+		return "+"
+	default:
+		return "?"
+	}
+}
+
+// Edit is a single step in a line oriented edit script.
+// A is the index of the line in the left text, and B is the index of the line in the right text.
+// For Delete, B is the index in the right text where the line would have been.
+// For Insert, A is the index in the left text where the line is inserted.
+type Edit struct {
+	Op Op
+	A  int
+	B  int
+}
+
+// Lines splits data into lines, each of which includes its trailing newline.
+// The last line will not have a trailing newline if data does not end with one.
+func Lines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// maxCost is the most lines that Diff will search for a shortest edit script between,
+// after the common prefix and suffix are removed.
+// The memory used by the search grows with the square of the number of differences.
+const maxCost = 1000
+
+// Diff returns a shortest edit script which turns a into b.
+// The edits are in order, and every line of a and b is covered by exactly one edit.
+// Diff uses the algorithm from "An O(ND) Difference Algorithm and Its Variations" by Myers.
+// If more than maxCost lines differ, then the lines between the common prefix and suffix
+// are all deleted and inserted instead, and the edit script is not the shortest.
+func Diff(a, b []string) []Edit {
+	var pre, suf int
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var edits []Edit
+	for i := range pre {
+		edits = append(edits, Edit{Op: Equal, A: i, B: i})
+	}
+	for _, e := range diff(a[pre:len(a)-suf], b[pre:len(b)-suf]) {
+		e.A += pre
+		e.B += pre
+		edits = append(edits, e)
+	}
+	for i := range suf {
+		edits = append(edits, Edit{Op: Equal, A: len(a) - suf + i, B: len(b) - suf + i})
+	}
+	return edits
+}
+
+// diff is Diff, without removing the common prefix and suffix first.
+func diff(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v[-d-1 : d+2] as it was before step d.
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxCost {
+			return replaceAll(n, m)
+		}
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// replaceAll returns an edit script which deletes all n lines of the left text, and then inserts all m lines of the right text.
+func replaceAll(n, m int) []Edit {
+	edits := make([]Edit, 0, n+m)
+	for i := range n {
+		edits = append(edits, Edit{Op: Delete, A: i, B: 0})
+	}
+	for j := range m {
+		edits = append(edits, Edit{Op: Insert, A: n, B: j})
+	}
+	return edits
+}
+
+func backtrack(trace [][]int, n, m int) []Edit {
+	var edits []Edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Op: Equal, A: x, B: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Op: Insert, A: x, B: y - 1})
+			} else {
+				edits = append(edits, Edit{Op: Delete, A: x - 1, B: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(edits)
+	return edits
+}
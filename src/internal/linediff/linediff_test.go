@@ -0,0 +1,133 @@
+package linediff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLines(t *testing.T) {
+	require.Nil(t, Lines(nil))
+	require.Equal(t, []string{"a\n", "b\n"}, Lines([]byte("a\nb\n")))
+	require.Equal(t, []string{"a\n", "b"}, Lines([]byte("a\nb")))
+	require.Equal(t, []string{"\n", "\n"}, Lines([]byte("\n\n")))
+}
+
+func TestDiff(t *testing.T) {
+	tcs := []struct {
+		A, B    string
+		Changes int
+	}{
+		{A: "", B: ""},
+		{A: "abc", B: "abc"},
+		{A: "", B: "abc", Changes: 3},
+		{A: "abc", B: "", Changes: 3},
+		{A: "abc", B: "axc", Changes: 2},
+		{A: "abcabba", B: "cbabac", Changes: 5},
+		{A: "abcdef", B: "abXdef", Changes: 2},
+		{A: "abcdef", B: "Xabcdef", Changes: 1},
+	}
+	for _, tc := range tcs {
+		a, b := strings.Split(tc.A, ""), strings.Split(tc.B, "")
+		changes := checkEdits(t, a, b, Diff(a, b))
+		require.Equal(t, tc.Changes, changes, "%q -> %q", tc.A, tc.B)
+	}
+}
+
+// TestDiffLarge tests that large texts with nothing in common are diffed, without searching for the shortest edit script.
+func TestDiffLarge(t *testing.T) {
+	const n = 200_000
+	a, b := make([]string, n), make([]string, n)
+	for i := range n {
+		a[i] = fmt.Sprintf("left %d\n", i)
+		b[i] = fmt.Sprintf("right %d\n", i)
+	}
+	require.Equal(t, 2*n, checkEdits(t, a, b, Diff(a, b)))
+
+	// the common prefix and suffix are still found.
+	a2 := slices.Concat([]string{"same\n"}, a, []string{"end\n"})
+	b2 := slices.Concat([]string{"same\n"}, b, []string{"end\n"})
+	edits := Diff(a2, b2)
+	require.Equal(t, 2*n, checkEdits(t, a2, b2, edits))
+	require.Equal(t, Edit{Op: Equal, A: 0, B: 0}, edits[0])
+	require.Equal(t, Edit{Op: Equal, A: n + 1, B: n + 1}, edits[len(edits)-1])
+}
+
+// checkEdits checks that edits turns a into b, and returns the number of lines which are deleted or inserted.
+func checkEdits(t testing.TB, a, b []string, edits []Edit) int {
+	t.Helper()
+	var changes int
+	var out []string
+	var i, j int
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			require.Equal(t, a[e.A], b[e.B])
+			require.Equal(t, i, e.A)
+			require.Equal(t, j, e.B)
+			out = append(out, a[e.A])
+			i++
+			j++
+		case Delete:
+			require.Equal(t, i, e.A)
+			changes++
+			i++
+		case Insert:
+			require.Equal(t, j, e.B)
+			out = append(out, b[e.B])
+			changes++
+			j++
+		}
+	}
+	require.Equal(t, len(a), i)
+	require.Equal(t, len(b), j)
+	require.Equal(t, strings.Join(b, ""), strings.Join(out, ""))
+	return changes
+}
+
+func TestIsBinary(t *testing.T) {
+	require.False(t, IsBinary(nil))
+	require.False(t, IsBinary([]byte("hello\n")))
+	require.True(t, IsBinary([]byte("hel\x00lo\n")))
+}
+
+func TestUnified(t *testing.T) {
+	tcs := []struct {
+		A, B     string
+		Context  int
+		Expected string
+	}{
+		{A: "a\nb\nc\n", B: "a\nb\nc\n", Context: 3, Expected: ""},
+		{
+			A: "a\nb\nc\n", B: "a\nx\nc\n", Context: 3,
+			Expected: "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			A: "", B: "a\nb\n", Context: 3,
+			Expected: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			A: "a\n", B: "a", Context: 3,
+			Expected: "@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			A: "1\n2\n3\n4\n5\n6\n7\n8\n9\n", B: "1\nx\n3\n4\n5\n6\n7\ny\n9\n", Context: 1,
+			Expected: "@@ -1,3 +1,3 @@\n 1\n-2\n+x\n 3\n@@ -7,3 +7,3 @@\n 7\n-8\n+y\n 9\n",
+		},
+		{
+			A: "1\n2\n3\n4\n5\n", B: "1\nx\n3\ny\n5\n", Context: 1,
+			Expected: "@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n-4\n+y\n 5\n",
+		},
+	}
+	for _, tc := range tcs {
+		a, b := Lines([]byte(tc.A)), Lines([]byte(tc.B))
+		var buf strings.Builder
+		for _, h := range Hunks(Diff(a, b), tc.Context) {
+			require.NoError(t, WriteHunk(&buf, a, b, h))
+		}
+		require.Equal(t, tc.Expected, buf.String(), "%q -> %q", tc.A, tc.B)
+	}
+}
@@ -0,0 +1,129 @@
+package linediff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// binarySniffLen is how much of a file IsBinary looks at.
+const binarySniffLen = 8000
+
+// MaxTextSize is the largest file which is treated as text, and diffed line by line.
+const MaxTextSize = 1 << 20
+
+// IsBinary returns true if data looks like it is not text.
+// Like git, it only looks for a NUL byte near the start of data.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0
+}
+
+// Hunk is a run of Edits which contains changes, surrounded by Equal lines for context.
+type Hunk []Edit
+
+// Lens returns the number of lines the Hunk covers in the left and right texts.
+func (h Hunk) Lens() (aLen, bLen int) {
+	for _, e := range h {
+		switch e.Op {
+		case Equal:
+			aLen++
+			bLen++
+		case Delete:
+			aLen++
+		case Insert:
+			bLen++
+		}
+	}
+	return aLen, bLen
+}
+
+// Header returns the "@@ -a,n +b,m @@" line for the Hunk, without a trailing newline.
+func (h Hunk) Header() string {
+	if len(h) == 0 {
+		return "@@ -0,0 +0,0 @@"
+	}
+	aLen, bLen := h.Lens()
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h[0].A, aLen), hunkRange(h[0].B, bLen))
+}
+
+// hunkRange formats a range of lines, starting at the 0 based index start, as it appears in a Hunk header.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		// an empty range refers to the line before it.
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+// Hunks groups the changes in edits into Hunks, each with up to context Equal lines before and after each change.
+// Changes which are at most 2*context lines apart are put in the same Hunk.
+func Hunks(edits []Edit, context int) []Hunk {
+	context = max(context, 0)
+	var hunks []Hunk
+	// start and end are the range of edits in the current Hunk, not including the trailing context.
+	start, end := -1, -1
+	for i, e := range edits {
+		if e.Op == Equal {
+			continue
+		}
+		if start >= 0 && i-end > 2*context {
+			hunks = append(hunks, edits[start:min(len(edits), end+context)])
+			start = -1
+		}
+		if start < 0 {
+			start = max(0, i-context)
+		}
+		end = i + 1
+	}
+	if start >= 0 {
+		hunks = append(hunks, edits[start:min(len(edits), end+context)])
+	}
+	return hunks
+}
+
+// WriteHunk writes h to w in unified diff format, starting with its header.
+// a and b are the texts which h refers to.
+func WriteHunk(w io.Writer, a, b []string, h Hunk) error {
+	if _, err := fmt.Fprintln(w, h.Header()); err != nil {
+		return err
+	}
+	for _, e := range h {
+		var prefix byte
+		var line string
+		switch e.Op {
+		case Equal:
+			prefix, line = ' ', a[e.A]
+		case Delete:
+			prefix, line = '-', a[e.A]
+		case Insert:
+			prefix, line = '+', b[e.B]
+		}
+		if _, err := fmt.Fprintf(w, "%c%s", prefix, line); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(line, "\n") {
+			if _, err := io.WriteString(w, "\n\\ No newline at end of file\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Count returns the number of lines deleted from, and inserted into, the left text by edits.
+func Count(edits []Edit) (deleted, inserted int) {
+	for _, e := range edits {
+		switch e.Op {
+		case Delete:
+			deleted++
+		case Insert:
+			inserted++
+		}
+	}
+	return deleted, inserted
+}